@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"q-q-tem-pra-hoje/internal/config"
+	"q-q-tem-pra-hoje/internal/database"
+	"q-q-tem-pra-hoje/internal/domain/recipe"
+	"q-q-tem-pra-hoje/internal/repository/postgres"
+	"q-q-tem-pra-hoje/internal/repository/sqlite"
+	"q-q-tem-pra-hoje/internal/seed"
+	"q-q-tem-pra-hoje/seeds"
+	"strconv"
+	"strings"
+)
+
+func migrateCommand(ctx context.Context, logger *slog.Logger, fs *flag.FlagSet, args []string) error {
+	storage, sqlitePath := storageFlags(fs)
+	all := fs.Bool("all", false, "with down, roll back every migration")
+	rest, err := parse(fs, args, 1, 2)
+	if err != nil {
+		return err
+	}
+	// Flags may follow the action too, except for force, whose version may
+	// be -1.
+	action, rest := rest[0], rest[1:]
+	if action != "force" {
+		if rest, err = parse(fs, rest, 0, 1); err != nil {
+			return err
+		}
+	}
+
+	var run func(db storageDB) error
+	switch {
+	case action == "up" && len(rest) == 0:
+		run = func(db storageDB) error { return db.migrate(ctx) }
+	case action == "down" && *all && len(rest) == 0:
+		run = func(db storageDB) error { return db.migrateDown(ctx, 0) }
+	case action == "down" && !*all:
+		steps := 1
+		if len(rest) == 1 {
+			if steps, err = strconv.Atoi(rest[0]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of migrations %q", rest[0])
+			}
+		}
+		run = func(db storageDB) error { return db.migrateDown(ctx, steps) }
+	case action == "force" && len(rest) == 1:
+		version, err := strconv.Atoi(rest[0])
+		if err != nil || version < -1 {
+			return fmt.Errorf("invalid version %q", rest[0])
+		}
+		run = func(db storageDB) error { return db.forceMigration(ctx, version) }
+	case action == "status" && len(rest) == 0:
+		run = func(db storageDB) error { return nil }
+	default:
+		fs.Usage()
+		return errUsage
+	}
+
+	db, err := openStorageDB(ctx, *storage, *sqlitePath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := run(db); err != nil {
+		return err
+	}
+	return printMigrationStatus(ctx, db)
+}
+
+func printMigrationStatus(ctx context.Context, db storageDB) error {
+	status, err := db.migrationStatus(ctx)
+	if err != nil {
+		return err
+	}
+	version := "none"
+	if status.Applied {
+		version = strconv.FormatUint(uint64(status.Version), 10)
+		if status.Dirty {
+			version += " (dirty)"
+		}
+	}
+	fmt.Printf("version: %s\nlatest:  %d\npending: %d\n", version, status.Latest, status.Pending)
+	return nil
+}
+
+func seedCommand(ctx context.Context, logger *slog.Logger, fs *flag.FlagSet, args []string) error {
+	environments, err := seed.Environments(seeds.FS)
+	if err != nil {
+		return err
+	}
+	storage, sqlitePath := storageFlags(fs)
+	env := fs.String("env", "", fmt.Sprintf("environment whose embedded fixtures to apply: %s", strings.Join(environments, ", ")))
+	file := fs.String("f", "", "YAML or JSON fixture file to apply instead")
+	if _, err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	if (*env == "") == (*file == "") {
+		fs.Usage()
+		return errUsage
+	}
+
+	fixture, err := readFixture(*env, *file)
+	if err != nil {
+		return err
+	}
+
+	db, err := openStorageDB(ctx, *storage, *sqlitePath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// No user in ctx: the seeded recipes belong to nobody.
+	result, err := seed.Apply(ctx, db.recipes(logger), fixture)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("added %d recipes, updated %d\n", result.Added, result.Updated)
+	return nil
+}
+
+func readFixture(env, file string) (seed.Fixture, error) {
+	if env != "" {
+		return seed.ReadEnvironment(seeds.FS, env)
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return seed.Fixture{}, err
+	}
+	defer f.Close()
+	fixture, err := seed.Read(f)
+	if err != nil {
+		return seed.Fixture{}, fmt.Errorf("%s: %w", file, err)
+	}
+	return fixture, nil
+}
+
+var errNotMigrated = errors.New("the schema is not fully migrated")
+
+// checkDB exits non-zero unless the database answers and the schema is
+// current, for deploy scripts to run before switching traffic.
+func checkDB(ctx context.Context, logger *slog.Logger, fs *flag.FlagSet, args []string) error {
+	storage, sqlitePath := storageFlags(fs)
+	if _, err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	db, err := openStorageDB(ctx, *storage, *sqlitePath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	pingCtx, cancel := context.WithTimeout(ctx, config.LoadServerConfig().HealthCheckTimeout)
+	defer cancel()
+	if err := db.PingContext(pingCtx); err != nil {
+		return fmt.Errorf("database unreachable: %w", err)
+	}
+	fmt.Println("database: reachable")
+
+	if err := printMigrationStatus(ctx, db); err != nil {
+		return err
+	}
+	status, err := db.migrationStatus(ctx)
+	if err != nil {
+		return err
+	}
+	if !status.Applied || status.Dirty || status.Pending > 0 {
+		return errNotMigrated
+	}
+	return nil
+}
+
+// storageDB is the database of the storage the admin commands work on, the
+// one serve would run on with the same flags and environment.
+type storageDB struct {
+	*sql.DB
+	storage string
+	// sqlitePath is the file of the sqlite storage.
+	sqlitePath string
+}
+
+// openStorageDB opens the database of storage without migrating it, even
+// the SQLite file serve migrates as it opens it.
+func openStorageDB(ctx context.Context, storage, sqlitePath string) (storageDB, error) {
+	switch storage {
+	case "postgres":
+		db, err := openDB()
+		return storageDB{DB: db, storage: storage}, err
+	case "sqlite":
+		db, err := database.ConnectSQLite(ctx, sqlitePath)
+		return storageDB{DB: db, storage: storage, sqlitePath: sqlitePath}, err
+	case "memory":
+		return storageDB{}, errors.New("the memory storage has no database")
+	default:
+		return storageDB{}, fmt.Errorf("unknown storage %q, want postgres or sqlite", storage)
+	}
+}
+
+func (db storageDB) migrate(ctx context.Context) error {
+	if db.storage == "sqlite" {
+		return database.MigrateSQLite(ctx, db.sqlitePath)
+	}
+	return database.Migrate(ctx, db.DB)
+}
+
+func (db storageDB) migrateDown(ctx context.Context, steps int) error {
+	if db.storage == "sqlite" {
+		return database.MigrateSQLiteDown(ctx, db.sqlitePath, steps)
+	}
+	return database.MigrateDown(ctx, db.DB, steps)
+}
+
+func (db storageDB) forceMigration(ctx context.Context, version int) error {
+	if db.storage == "sqlite" {
+		return database.ForceSQLiteMigration(ctx, db.sqlitePath, version)
+	}
+	return database.ForceMigration(ctx, db.DB, version)
+}
+
+func (db storageDB) migrationStatus(ctx context.Context) (database.MigrationStatus, error) {
+	if db.storage == "sqlite" {
+		return database.CurrentSQLiteMigrationStatus(ctx, db.DB)
+	}
+	return database.CurrentMigrationStatus(ctx, db.DB)
+}
+
+func (db storageDB) recipes(logger *slog.Logger) recipe.RecipeManager {
+	if db.storage == "sqlite" {
+		return sqlite.NewRecipeManager(db.DB, logger)
+	}
+	return postgres.NewRecipeManager(db.DB, logger)
+}
@@ -1,33 +1,118 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"net/http"
-	"q-q-tem-pra-hoje/internal/app"
-	"q-q-tem-pra-hoje/internal/database"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"q-q-tem-pra-hoje/internal/config"
+	"q-q-tem-pra-hoje/internal/logging"
+	"syscall"
+	"text/tabwriter"
 
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
 
+// command is one subcommand of the server binary.
+type command struct {
+	name     string
+	synopsis string
+	summary  string
+	// run registers its flags on fs and parses args with parse.
+	run func(ctx context.Context, logger *slog.Logger, fs *flag.FlagSet, args []string) error
+}
+
+// commands are listed in the order the usage shows them. Without arguments
+// the binary serves, as it always has.
+var commands = []command{
+	{"serve", "", "run the HTTP server", serve},
+	{"migrate", "up|down [-all] [n]|status|force <version>", "apply, roll back, inspect or repair the schema migrations", migrateCommand},
+	{"seed", "-env <name> | -f <file>", "add or update the recipes of an environment's fixtures or of a fixture file", seedCommand},
+	{"check-db", "", "check that the database is reachable and fully migrated", checkDB},
+}
+
+// errUsage reports a command line that does not make sense, after the
+// usage has been printed.
+var errUsage = errors.New("usage")
+
 func main() {
-	err := godotenv.Load(".env")
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	envErr := godotenv.Load(".env")
+
+	loggingConfig := config.LoadLoggingConfig()
+	logger := logging.NewLogger(os.Stdout, loggingConfig.LogFormat, loggingConfig.LogLevel)
 
-	if err != nil {
-		fmt.Printf("error loading .env file: %v", err)
+	if envErr != nil {
+		logger.Warn("error loading .env file", "error", envErr)
 	}
 
-	connStr := database.SetupDB()
-	db, err := database.Connect(connStr)
-	if err != nil {
-		panic(err)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if len(args) == 0 {
+		args = []string{"serve"}
+	}
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		err := cmd.run(ctx, logger, cmd.flags(), args[1:])
+		switch {
+		case err == nil, errors.Is(err, flag.ErrHelp):
+			return 0
+		case errors.Is(err, errUsage):
+			return 2
+		default:
+			logger.Error(cmd.name+" failed", "error", err)
+			return 1
+		}
 	}
-	defer db.Close()
 
-	server := app.NewServer(db)
+	if args[0] != "-h" && args[0] != "-help" && args[0] != "--help" {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+	}
+	usage()
+	return 2
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [command]\n\nCommands:\n", filepath.Base(os.Args[0]))
+	tw := tabwriter.NewWriter(os.Stderr, 0, 0, 3, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(tw, "  %s %s\t%s\n", cmd.name, cmd.synopsis, cmd.summary)
+	}
+	tw.Flush()
+}
 
-	fmt.Println("Server started at :8080")
-	if err := server.Start(); err != nil && err != http.ErrServerClosed {
-		panic(err)
+func (cmd command) flags() *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s %s\n\n%s.\n", filepath.Base(os.Args[0]), cmd.name, cmd.synopsis, cmd.summary)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses args into fs, checking that between min and max positional
+// arguments remain.
+func parse(fs *flag.FlagSet, args []string, min, max int) ([]string, error) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, err
+		}
+		return nil, errUsage
+	}
+	if fs.NArg() < min || fs.NArg() > max {
+		fs.Usage()
+		return nil, errUsage
 	}
+	return fs.Args(), nil
 }
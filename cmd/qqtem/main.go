@@ -0,0 +1,17 @@
+// Command qqtem manages the pantry and recipes through the HTTP API.
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"q-q-tem-pra-hoje/internal/cli"
+	"syscall"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := cli.Run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"q-q-tem-pra-hoje/internal/app"
+	"q-q-tem-pra-hoje/internal/config"
+	"q-q-tem-pra-hoje/internal/database"
+	"q-q-tem-pra-hoje/internal/tracing"
+	"time"
+)
+
+func serve(ctx context.Context, logger *slog.Logger, fs *flag.FlagSet, args []string) error {
+	dbConfig := config.LoadDatabaseConfig()
+	storage, sqlitePath := storageFlags(fs)
+	if _, err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	tracingConfig := config.LoadTracingConfig()
+	shutdownTracing, err := tracing.Setup(ctx, tracingConfig.Exporter, tracingConfig.SampleRatio, tracingConfig.ServiceName, os.Stdout)
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logger.Error("failed to flush traces", "error", err)
+		}
+	}()
+
+	// db is the Postgres database, whose migrations are applied separately;
+	// the sqlite storage migrates its file as it opens it.
+	var db *sql.DB
+	var s app.Storage
+	switch *storage {
+	case "postgres":
+		if db, err = openDB(); err != nil {
+			return err
+		}
+		defer db.Close()
+		s = app.PostgresStorage(db, logger)
+	case "sqlite":
+		sqliteDB, err := database.OpenSQLite(ctx, *sqlitePath)
+		if err != nil {
+			return err
+		}
+		defer sqliteDB.Close()
+		logger.Info("keeping data in SQLite", "path", *sqlitePath)
+		s = app.SQLiteStorage(sqliteDB, logger)
+	case "memory":
+		logger.Warn("keeping data in memory; it is lost when the server stops")
+		s = app.MemoryStorage()
+	default:
+		return fmt.Errorf("unknown storage %q, want postgres, sqlite or memory", *storage)
+	}
+
+	server := app.NewServer(s, logger)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Info("server started", "addr", server.Addr())
+		if err := server.Start(); err != nil && err != http.ErrServerClosed {
+			serverErr <- err
+		}
+	}()
+
+	// The server is already listening so /healthz answers while migrations
+	// run; /readyz keeps failing until they are done.
+	switch {
+	case db == nil:
+	case dbConfig.MigrateOnStart:
+		if err := database.Migrate(ctx, db); err != nil {
+			return err
+		}
+	default:
+		warnPendingMigrations(ctx, db, logger)
+	}
+	server.MarkReady()
+	logger.Info("server ready")
+
+	select {
+	case err := <-serverErr:
+		return fmt.Errorf("server stopped: %w", err)
+	case <-ctx.Done():
+	}
+
+	serverConfig := config.LoadServerConfig()
+
+	logger.Info("draining server", "delay", serverConfig.ShutdownDrainDelay)
+	server.MarkDraining()
+	time.Sleep(serverConfig.ShutdownDrainDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serverConfig.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Error("failed to shut down gracefully", "error", err)
+	}
+	logger.Info("server stopped")
+	return nil
+}
+
+// warnPendingMigrations points out migrations left for the migrate command.
+// Readiness keeps failing until they are applied.
+func warnPendingMigrations(ctx context.Context, db *sql.DB, logger *slog.Logger) {
+	status, err := database.CurrentMigrationStatus(ctx, db)
+	if err != nil {
+		logger.Warn("failed to read the migration status", "error", err)
+		return
+	}
+	if status.Pending > 0 {
+		logger.Warn("migrations are pending; run the migrate up command or set MIGRATE_ON_START", "version", status.Version, "pending", status.Pending)
+	}
+}
+
+// storageFlags registers the flags that select the storage, for serve and
+// the commands working on the same database.
+func storageFlags(fs *flag.FlagSet) (storage, sqlitePath *string) {
+	dbConfig := config.LoadDatabaseConfig()
+	storage = fs.String("storage", dbConfig.Storage, "where to keep data: postgres, sqlite, or memory to run without a database")
+	sqlitePath = fs.String("sqlite-path", dbConfig.SQLitePath, "the database file of the sqlite storage")
+	return storage, sqlitePath
+}
+
+func openDB() (*sql.DB, error) {
+	db, err := database.Connect(database.SetupDB())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the database: %w", err)
+	}
+	return db, nil
+}
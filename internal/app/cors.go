@@ -0,0 +1,142 @@
+package app
+
+import (
+	"log/slog"
+	"net/http"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/server/problem"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	corsAllowedHeaders = "Content-Type, Authorization, X-Request-ID, X-API-Version, X-Household-ID"
+	corsExposedHeaders = "X-Request-ID, X-API-Version, X-Next-Cursor, Deprecation, Link, Retry-After, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset"
+)
+
+// corsMethods are the methods a preflight may be answered for.
+var corsMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// corsPolicy decides which browser origins may call the API. Origins are
+// listed exactly ("https://app.example.com"), as a subdomain pattern
+// ("https://*.example.com", which does not match example.com itself) or as
+// "*" for any origin. Credentials are never allowed for "*".
+type corsPolicy struct {
+	origins     map[string]bool
+	patterns    []originPattern
+	anyOrigin   bool
+	credentials bool
+	maxAge      time.Duration
+}
+
+type originPattern struct {
+	prefix string
+	suffix string
+}
+
+func newCORSPolicy(origins []string, credentials bool, maxAge time.Duration) corsPolicy {
+	policy := corsPolicy{origins: map[string]bool{}, credentials: credentials, maxAge: maxAge}
+	for _, origin := range origins {
+		origin = strings.TrimSuffix(strings.ToLower(origin), "/")
+		if origin == "*" {
+			policy.anyOrigin = true
+		} else if prefix, suffix, ok := strings.Cut(origin, "*"); ok {
+			policy.patterns = append(policy.patterns, originPattern{prefix: prefix, suffix: suffix})
+		} else {
+			policy.origins[origin] = true
+		}
+	}
+	return policy
+}
+
+// match reports whether origin may call the API, and whether it was listed
+// rather than only allowed by "*".
+func (p corsPolicy) match(origin string) (allowed bool, listed bool) {
+	origin = strings.ToLower(origin)
+	if p.origins[origin] {
+		return true, true
+	}
+	for _, pattern := range p.patterns {
+		if pattern.matches(origin) {
+			return true, true
+		}
+	}
+	return p.anyOrigin, false
+}
+
+func (o originPattern) matches(origin string) bool {
+	if len(origin) <= len(o.prefix)+len(o.suffix) || !strings.HasPrefix(origin, o.prefix) || !strings.HasSuffix(origin, o.suffix) {
+		return false
+	}
+	subdomain := origin[len(o.prefix) : len(origin)-len(o.suffix)]
+	return !strings.ContainsAny(subdomain, "/:@") && !strings.HasPrefix(subdomain, ".")
+}
+
+// corsMiddleware applies the policy in front of mux. Preflight requests are
+// answered here, with the methods mux actually routes for the path; any
+// other request goes through with the CORS headers added when its origin is
+// allowed.
+func corsMiddleware(policy corsPolicy, mux *http.ServeMux, logger *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Origin")
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if origin == "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		allowed, listed := policy.match(origin)
+		if !allowed {
+			if preflight {
+				problem.Respond(w, r, logger, domain.NewForbidden("origin not allowed"))
+				return
+			}
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		if policy.credentials && listed {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		} else if listed {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		}
+
+		if !preflight {
+			w.Header().Set("Access-Control-Expose-Headers", corsExposedHeaders)
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		methods := routedMethods(mux, r)
+		if len(methods) == 0 {
+			mux.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+		w.Header().Set("Access-Control-Allow-Headers", corsAllowedHeaders)
+		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(policy.maxAge.Seconds())))
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// routedMethods lists the methods mux has a route for at the request's path.
+// The legacy routes dispatch on the method themselves and are reported as
+// taking every method.
+func routedMethods(mux *http.ServeMux, r *http.Request) []string {
+	var methods []string
+	for _, method := range corsMethods {
+		probe := r.Clone(r.Context())
+		probe.Method = method
+		if _, pattern := mux.Handler(probe); pattern != "" {
+			methods = append(methods, method)
+		}
+	}
+	return methods
+}
@@ -0,0 +1,148 @@
+package app
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newCORSTestHandler(policy corsPolicy) http.Handler {
+	mux := http.NewServeMux()
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	mux.HandleFunc("GET /api/v1/ingredients", ok)
+	mux.HandleFunc("POST /api/v1/ingredients", ok)
+	mux.HandleFunc("PATCH /api/v1/ingredients/{id}", ok)
+	mux.HandleFunc("DELETE /api/v1/ingredients/{id}", ok)
+	return corsMiddleware(policy, mux, slog.New(slog.DiscardHandler))
+}
+
+func preflight(handler http.Handler, origin, target, method string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodOptions, target, nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORSPolicy_Match(t *testing.T) {
+	policy := newCORSPolicy([]string{"https://app.example.com/", "https://*.example.org"}, false, time.Minute)
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://app.example.com", true},
+		{"https://APP.example.com", true},
+		{"http://app.example.com", false},
+		{"https://app.example.com:8443", false},
+		{"https://evil.com", false},
+		{"https://shop.example.org", true},
+		{"https://eu.shop.example.org", true},
+		{"https://example.org", false},
+		{"https://.example.org", false},
+		{"https://evil.com/.example.org", false},
+		{"https://evil.com:1.example.org", false},
+		{"https://shop.example.org.evil.com", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.origin, func(t *testing.T) {
+			allowed, _ := policy.match(tc.origin)
+
+			assert.Equal(t, tc.allowed, allowed)
+		})
+	}
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	t.Run("it should answer a preflight with the methods routed for the path", func(t *testing.T) {
+		handler := newCORSTestHandler(newCORSPolicy([]string{"https://app.example.com"}, false, 10*time.Minute))
+
+		w := preflight(handler, "https://app.example.com", "/api/v1/ingredients/1", http.MethodPatch)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "PATCH, DELETE", w.Header().Get("Access-Control-Allow-Methods"))
+		assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "Authorization")
+		assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Contains(t, w.Header().Values("Vary"), "Origin")
+	})
+
+	t.Run("it should refuse a preflight from an origin not allowed", func(t *testing.T) {
+		handler := newCORSTestHandler(newCORSPolicy([]string{"https://app.example.com"}, false, time.Minute))
+
+		w := preflight(handler, "https://evil.com", "/api/v1/ingredients", http.MethodPost)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("it should refuse every origin by default", func(t *testing.T) {
+		handler := newCORSTestHandler(newCORSPolicy(nil, false, time.Minute))
+
+		w := preflight(handler, "https://app.example.com", "/api/v1/ingredients", http.MethodPost)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("it should leave a preflight for an unknown path to the router", func(t *testing.T) {
+		handler := newCORSTestHandler(newCORSPolicy([]string{"https://app.example.com"}, false, time.Minute))
+
+		w := preflight(handler, "https://app.example.com", "/api/v1/unknown", http.MethodGet)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Methods"))
+	})
+
+	t.Run("it should allow credentials only for listed origins", func(t *testing.T) {
+		handler := newCORSTestHandler(newCORSPolicy([]string{"https://*.example.com", "*"}, true, time.Minute))
+
+		listed := preflight(handler, "https://app.example.com", "/api/v1/ingredients", http.MethodGet)
+		anyOrigin := preflight(handler, "https://elsewhere.com", "/api/v1/ingredients", http.MethodGet)
+
+		assert.Equal(t, "https://app.example.com", listed.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", listed.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, "*", anyOrigin.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, anyOrigin.Header().Get("Access-Control-Allow-Credentials"))
+	})
+
+	t.Run("it should expose headers on an allowed cross-origin request", func(t *testing.T) {
+		handler := newCORSTestHandler(newCORSPolicy([]string{"https://app.example.com"}, false, time.Minute))
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/ingredients", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Contains(t, w.Header().Get("Access-Control-Expose-Headers"), "X-Next-Cursor")
+	})
+
+	t.Run("it should serve a request from an origin not allowed without CORS headers", func(t *testing.T) {
+		handler := newCORSTestHandler(newCORSPolicy([]string{"https://app.example.com"}, false, time.Minute))
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/ingredients", nil)
+		req.Header.Set("Origin", "https://evil.com")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, []string{"Origin"}, w.Header().Values("Vary"))
+	})
+
+	t.Run("it should not answer OPTIONS requests that are not preflights", func(t *testing.T) {
+		handler := newCORSTestHandler(newCORSPolicy([]string{"https://app.example.com"}, false, time.Minute))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/api/v1/ingredients", nil))
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"q-q-tem-pra-hoje/internal/domain/apikey"
+	"q-q-tem-pra-hoje/internal/ratelimit"
+	authController "q-q-tem-pra-hoje/internal/server/controller/auth"
+	"q-q-tem-pra-hoje/internal/server/problem"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rateLimits holds a limiter per route group. A nil limiter limits nothing.
+type rateLimits struct {
+	api             *ratelimit.Limiter
+	auth            *ratelimit.Limiter
+	recommendations *ratelimit.Limiter
+	// clientIPHeader is the header a trusted proxy puts the client address
+	// in; the connection address is used when it is empty.
+	clientIPHeader string
+	logger         *slog.Logger
+}
+
+// newLimiter returns nil, which limits nothing, for a zero rate or burst.
+func newLimiter(rate float64, burst int) *ratelimit.Limiter {
+	if rate <= 0 || burst <= 0 {
+		return nil
+	}
+	return ratelimit.New(ratelimit.Limit{Rate: rate, Burst: burst})
+}
+
+// limit counts requests against limiter per client, reporting the client's
+// tightest bucket in RateLimit-* headers and refusing requests with 429 once
+// one is empty. It runs before authentication, so guessing tokens is
+// throttled too.
+func (rl rateLimits) limit(limiter *ratelimit.Limiter, next http.Handler) http.Handler {
+	if limiter == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var result ratelimit.Result
+		for i, key := range rl.clientKeys(r) {
+			counted := limiter.Allow(key)
+			if i == 0 || !counted.Allowed || counted.Remaining < result.Remaining {
+				result = counted
+			}
+			if !result.Allowed {
+				break
+			}
+		}
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		w.Header().Set("RateLimit-Reset", seconds(result.Reset))
+		if !result.Allowed {
+			w.Header().Set("Retry-After", seconds(result.RetryAfter))
+			problem.Respond(w, r, rl.logger, problem.New(http.StatusTooManyRequests, "rate limit exceeded"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientKeys lists the buckets a request counts against: its address's, and
+// its API key's when it carries one, so a key used from many places is
+// limited as a whole. The key is not authenticated yet, so it never spares
+// its sender the address's bucket: made-up keys would get a fresh one each.
+func (rl rateLimits) clientKeys(r *http.Request) []string {
+	keys := []string{"ip:" + clientIP(r, rl.clientIPHeader)}
+	if token, ok := authController.BearerToken(r); ok && apikey.IsKey(token) {
+		sum := sha256.Sum256([]byte(token))
+		keys = append(keys, "key:"+hex.EncodeToString(sum[:]))
+	}
+	return keys
+}
+
+// clientIP reads the address a trusted proxy put in header, taking the last
+// entry of a list since earlier ones are whatever the client sent.
+func clientIP(r *http.Request, header string) string {
+	if header != "" {
+		values := strings.Split(r.Header.Get(header), ",")
+		if ip := strings.TrimSpace(values[len(values)-1]); ip != "" {
+			return ip
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// seconds rounds up, so clients do not retry a moment too early.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// limitBody caps request bodies at maxBytes, or imports at importBytes,
+// before any handler decodes them. A cap of zero or less disables it. Bodies
+// announced as larger are refused straight away; others fail to decode once
+// they pass the cap, see problem.InvalidBody.
+func limitBody(maxBytes, importBytes int64, logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit := maxBytes
+		if r.Method == http.MethodPost && r.URL.Path == importPath {
+			limit = importBytes
+		}
+		if limit <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if r.ContentLength > limit {
+			problem.Respond(w, r, logger, problem.InvalidBody(&http.MaxBytesError{Limit: limit}))
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		next.ServeHTTP(w, r)
+	})
+}
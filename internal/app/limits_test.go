@@ -0,0 +1,142 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"q-q-tem-pra-hoje/internal/server/problem"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimits(t *testing.T) {
+	newHandler := func(clientIPHeader string) http.Handler {
+		rl := rateLimits{api: newLimiter(1, 2), clientIPHeader: clientIPHeader, logger: slog.New(slog.DiscardHandler)}
+		return rl.limit(rl.api, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	}
+	serve := func(handler http.Handler, remoteAddr string, headers ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/recommendations", nil)
+		req.RemoteAddr = remoteAddr
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("it should report the bucket and refuse requests once it is empty", func(t *testing.T) {
+		handler := newHandler("")
+
+		first := serve(handler, "10.0.0.1:1234")
+		serve(handler, "10.0.0.1:1234")
+		refused := serve(handler, "10.0.0.1:5678")
+
+		assert.Equal(t, http.StatusOK, first.Code)
+		assert.Equal(t, "2", first.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "1", first.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "1", first.Header().Get("RateLimit-Reset"))
+		assert.Equal(t, http.StatusTooManyRequests, refused.Code)
+		assert.Equal(t, "1", refused.Header().Get("Retry-After"))
+		assert.Equal(t, "0", refused.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, problem.ContentType, refused.Header().Get("Content-Type"))
+	})
+
+	t.Run("it should count each address apart", func(t *testing.T) {
+		handler := newHandler("")
+		serve(handler, "10.0.0.1:1234")
+		serve(handler, "10.0.0.1:1234")
+
+		assert.Equal(t, http.StatusOK, serve(handler, "10.0.0.2:1234").Code)
+		assert.Equal(t, http.StatusTooManyRequests, serve(handler, "10.0.0.1:1234", "Authorization", "Bearer session").Code)
+	})
+
+	t.Run("it should count an API key across addresses as well", func(t *testing.T) {
+		handler := newHandler("")
+		serve(handler, "10.0.0.1:1234", "Authorization", "Bearer qqk_scanner")
+		serve(handler, "10.0.0.2:1234", "Authorization", "Bearer qqk_scanner")
+
+		refused := serve(handler, "10.0.0.3:1234", "Authorization", "Bearer qqk_scanner")
+		assert.Equal(t, http.StatusTooManyRequests, refused.Code)
+		assert.Equal(t, http.StatusOK, serve(handler, "10.0.0.3:1234").Code)
+	})
+
+	t.Run("it should not give made-up API keys a bucket of their own", func(t *testing.T) {
+		rl := rateLimits{auth: newLimiter(0.2, 2), logger: slog.New(slog.DiscardHandler)}
+		handler := rl.limit(rl.auth, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		login := func(token string) int {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(`{"email":"cook@example.com","password":"guess"}`))
+			req.RemoteAddr = "10.0.0.1:1234"
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			return w.Code
+		}
+
+		assert.Equal(t, http.StatusOK, login("qqk_guess1"))
+		assert.Equal(t, http.StatusOK, login("qqk_guess2"))
+		assert.Equal(t, http.StatusTooManyRequests, login("qqk_guess3"))
+	})
+
+	t.Run("it should take the address from the last proxy when configured", func(t *testing.T) {
+		handler := newHandler("X-Forwarded-For")
+		serve(handler, "10.0.0.1:1234", "X-Forwarded-For", "spoofed, 203.0.113.7")
+		serve(handler, "10.0.0.1:1234", "X-Forwarded-For", "other, 203.0.113.7")
+
+		assert.Equal(t, http.StatusTooManyRequests, serve(handler, "10.0.0.9:1234", "X-Forwarded-For", "203.0.113.7").Code)
+		assert.Equal(t, http.StatusOK, serve(handler, "10.0.0.1:1234", "X-Forwarded-For", "203.0.113.8").Code)
+	})
+
+	t.Run("it should not limit a group without a limiter", func(t *testing.T) {
+		rl := rateLimits{}
+		handler := rl.limit(rl.recommendations, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		for range 5 {
+			w := serve(handler, "10.0.0.1:1234")
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+		}
+	})
+}
+
+func TestLimitBody(t *testing.T) {
+	handler := limitBody(16, 16, slog.New(slog.DiscardHandler), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			problem.Respond(w, r, slog.New(slog.DiscardHandler), problem.InvalidBody(err))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	t.Run("it should let a small body through", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/recipes", strings.NewReader(`{"name":"Rice"}`)))
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("it should refuse a body announced as too large", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/recipes", strings.NewReader(`{"name":"Rice and beans"}`)))
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Contains(t, w.Body.String(), "at most 16 bytes")
+	})
+
+	t.Run("it should stop reading a streamed body past the cap", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/recipes", io.NopCloser(bytes.NewBufferString(`{"name":"Rice and beans"}`)))
+		req.ContentLength = -1
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+}
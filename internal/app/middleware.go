@@ -0,0 +1,335 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/apikey"
+	"q-q-tem-pra-hoje/internal/domain/household"
+	"q-q-tem-pra-hoje/internal/domain/user"
+	"q-q-tem-pra-hoje/internal/logging"
+	"q-q-tem-pra-hoje/internal/metrics"
+	authController "q-q-tem-pra-hoje/internal/server/controller/auth"
+	householdController "q-q-tem-pra-hoje/internal/server/controller/household"
+	pageController "q-q-tem-pra-hoje/internal/server/controller/page"
+	"q-q-tem-pra-hoje/internal/server/problem"
+	"q-q-tem-pra-hoje/internal/tracing"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// statusRecorder captures the status code and body size written by the
+// wrapped handler so they can be reported once the request is done.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (sr *statusRecorder) WriteHeader(code int) {
+	if sr.status == 0 {
+		sr.status = code
+	}
+	sr.ResponseWriter.WriteHeader(code)
+}
+
+func (sr *statusRecorder) Write(b []byte) (int, error) {
+	if sr.status == 0 {
+		sr.status = http.StatusOK
+	}
+	n, err := sr.ResponseWriter.Write(b)
+	sr.bytes += n
+	return n, err
+}
+
+func (sr *statusRecorder) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
+
+// requestIDMiddleware reuses the caller's X-Request-ID when it looks sane,
+// otherwise generates a new one, and exposes it on the context and response.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(requestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+
+		w.Header().Set(requestIDHeader, requestID)
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), requestID)))
+	})
+}
+
+func loggingMiddleware(logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(recorder, r)
+
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+
+		level := slog.LevelInfo
+		if recorder.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		logger.Log(r.Context(), level, "http request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", recorder.status,
+			"bytes", recorder.bytes,
+			"latency", time.Since(start),
+		)
+	})
+}
+
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > 128 {
+		return false
+	}
+	for _, c := range requestID {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func metricsMiddleware(m *metrics.Metrics, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(recorder, r)
+
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+
+		// ServeMux records the matched pattern on the request it was given.
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+
+		m.ObserveHTTPRequest(route, r.Method, recorder.status, time.Since(start))
+	})
+}
+
+// tracingMiddleware starts a server span per request, continuing any trace
+// propagated by the caller. The span is renamed after the matched route once
+// ServeMux has routed the request.
+func tracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Tracer().Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		recorder := &statusRecorder{ResponseWriter: w}
+		routed := r.WithContext(ctx)
+
+		next.ServeHTTP(recorder, routed)
+
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		if routed.Pattern != "" {
+			span.SetName(routed.Pattern)
+			span.SetAttributes(semconv.HTTPRoute(routed.Pattern))
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(recorder.status))
+		if recorder.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(recorder.status))
+		}
+	})
+}
+
+// deprecated marks responses of a legacy route as deprecated and links to the
+// route that replaces it.
+func deprecated(successor string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// guard authenticates private routes. A bearer token is either a session,
+// which may do anything its user may, or an API key, which is bound to one
+// household and limited to the scopes it was granted. The guard runs the
+// rest of the request with the caller's user and household membership in
+// the context, which scopes every repository query to them. It wraps routes
+// individually, after the mux has matched them, so the route pattern stays
+// visible to the metrics and tracing middlewares.
+type guard struct {
+	auth       user.AuthProvider
+	keys       apikey.APIKeyProvider
+	households household.HouseholdProvider
+	logger     *slog.Logger
+}
+
+// sessionOnly admits sessions only; account, household and key management
+// are not something a script may do.
+func (g guard) sessionOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.serve(w, r, next, false, nil)
+	})
+}
+
+// require admits sessions and API keys granted every one of scopes.
+func (g guard) require(next http.Handler, scopes ...apikey.Scope) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.serve(w, r, next, true, scopes)
+	})
+}
+
+// requireByMethod is require for the legacy routes, which dispatch on the
+// method themselves: safe methods need read, the others write.
+func (g guard) requireByMethod(next http.Handler, read, write apikey.Scope) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scope := write
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			scope = read
+		}
+		g.serve(w, r, next, true, []apikey.Scope{scope})
+	})
+}
+
+// page admits the HTML pages' session cookie, sending anyone without a
+// valid one to the login page. Forms posted with it must carry the
+// session's CSRF token, since the browser attaches the cookie to requests
+// other sites start too.
+func (g guard) page(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := pageController.SessionToken(r)
+		if !ok {
+			redirectToLogin(w, r)
+			return
+		}
+		ctx, err := g.sessionContext(r, token)
+		var unauthenticated *domain.UnauthenticatedError
+		if errors.As(err, &unauthenticated) {
+			redirectToLogin(w, r)
+			return
+		}
+		if err != nil {
+			problem.Respond(w, r, g.logger, err)
+			return
+		}
+		if r.Method == http.MethodPost && !pageController.ValidCSRF(r, token) {
+			problem.Respond(w, r, g.logger, domain.NewForbidden("invalid CSRF token"))
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func redirectToLogin(w http.ResponseWriter, r *http.Request) {
+	next := r.URL.Path
+	if r.Method != http.MethodGet {
+		next = "/pantry"
+	}
+	http.Redirect(w, r, "/login?next="+url.QueryEscape(next), http.StatusSeeOther)
+}
+
+func (g guard) serve(w http.ResponseWriter, r *http.Request, next http.Handler, allowKeys bool, scopes []apikey.Scope) {
+	token, ok := authController.BearerToken(r)
+	if !ok {
+		problem.Respond(w, r, g.logger, domain.NewUnauthenticated("authentication required"))
+		return
+	}
+
+	var ctx context.Context
+	var err error
+	if apikey.IsKey(token) {
+		if !allowKeys {
+			problem.Respond(w, r, g.logger, domain.NewForbidden("API keys cannot be used on this route"))
+			return
+		}
+		ctx, err = g.keyContext(r, token, scopes)
+	} else {
+		ctx, err = g.sessionContext(r, token)
+	}
+	if err != nil {
+		problem.Respond(w, r, g.logger, err)
+		return
+	}
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+func (g guard) sessionContext(r *http.Request, token string) (context.Context, error) {
+	userID, err := g.auth.Authenticate(r.Context(), token)
+	if err != nil {
+		return nil, err
+	}
+	requested, err := householdController.RequestedHousehold(r)
+	if err != nil {
+		return nil, err
+	}
+	membership, err := g.households.Resolve(r.Context(), userID, requested)
+	if err != nil {
+		return nil, err
+	}
+	return household.WithMembership(user.WithUserID(r.Context(), userID), membership), nil
+}
+
+// keyContext ignores X-Household-ID: a key only ever acts within the
+// household it was created in, and stops working once its owner leaves it.
+func (g guard) keyContext(r *http.Request, token string, scopes []apikey.Scope) (context.Context, error) {
+	key, err := g.keys.Authenticate(r.Context(), token)
+	if err != nil {
+		return nil, err
+	}
+	if !key.Allows(scopes...) {
+		return nil, domain.NewForbidden(fmt.Sprintf("API key lacks scope %s", missingScopes(key, scopes)))
+	}
+	membership, err := g.households.Resolve(r.Context(), key.UserId, &key.HouseholdId)
+	var notFound *domain.NotFoundError
+	if errors.As(err, &notFound) {
+		// The key's owner has left the household the key was made for.
+		return nil, domain.NewUnauthenticated("API key is no longer valid")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return household.WithMembership(user.WithUserID(r.Context(), key.UserId), membership), nil
+}
+
+func missingScopes(key apikey.Key, scopes []apikey.Scope) string {
+	var missing []string
+	for _, scope := range scopes {
+		if !key.Allows(scope) {
+			missing = append(missing, string(scope))
+		}
+	}
+	return strings.Join(missing, ", ")
+}
@@ -0,0 +1,349 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/apikey"
+	"q-q-tem-pra-hoje/internal/domain/household"
+	"q-q-tem-pra-hoje/internal/domain/user"
+	"q-q-tem-pra-hoje/internal/logging"
+	"q-q-tem-pra-hoje/internal/metrics"
+	"q-q-tem-pra-hoje/internal/repository/in_memory_repository"
+	pageController "q-q-tem-pra-hoje/internal/server/controller/page"
+	apiKeyService "q-q-tem-pra-hoje/internal/service/apikey"
+	householdService "q-q-tem-pra-hoje/internal/service/household"
+	ingredientService "q-q-tem-pra-hoje/internal/service/ingredient"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	t.Run("it should propagate the caller request id", func(t *testing.T) {
+		var requestIDSeen string
+		handler := requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestIDSeen = logging.RequestIDFromContext(r.Context())
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/ingredient", nil)
+		req.Header.Set("X-Request-ID", "abc-123")
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, "abc-123", requestIDSeen)
+		assert.Equal(t, "abc-123", w.Header().Get("X-Request-ID"))
+	})
+
+	t.Run("it should generate a request id when missing or invalid", func(t *testing.T) {
+		handler := requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		req := httptest.NewRequest(http.MethodGet, "/ingredient", nil)
+		req.Header.Set("X-Request-ID", "has spaces")
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Len(t, w.Header().Get("X-Request-ID"), 32)
+	})
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	t.Run("it should log the request with its status and request id", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := logging.NewLogger(&buf, "json", "info")
+
+		handler := requestIDMiddleware(loggingMiddleware(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		})))
+
+		req := httptest.NewRequest(http.MethodPost, "/recipe", nil)
+		req.Header.Set("X-Request-ID", "req-1")
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		var line map[string]any
+		err := json.Unmarshal(buf.Bytes(), &line)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "http request", line["msg"])
+		assert.Equal(t, "POST", line["method"])
+		assert.Equal(t, "/recipe", line["path"])
+		assert.Equal(t, float64(http.StatusTeapot), line["status"])
+		assert.Equal(t, "req-1", line["request_id"])
+		assert.Contains(t, line, "latency")
+	})
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	t.Run("it should label requests with the matched route pattern", func(t *testing.T) {
+		db, err := sql.Open("postgres", "host=localhost")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		m := metrics.New(db, "postgres")
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("PATCH /ingredient/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+		handler := metricsMiddleware(m, mux)
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPatch, "/ingredient/7", nil))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nowhere", nil))
+
+		w := httptest.NewRecorder()
+		m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		body, _ := io.ReadAll(w.Body)
+
+		assert.Contains(t, string(body), `qqtem_http_requests_total{method="PATCH",route="PATCH /ingredient/{id}",status="404"} 1`)
+		assert.Contains(t, string(body), `qqtem_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	})
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	t.Run("it should trace the request and the service calls it makes", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+		previous := otel.GetTracerProvider()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+		t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+		var buf bytes.Buffer
+		logger := logging.NewLogger(&buf, "json", "info")
+
+		repository := in_memory_repository.NewIngredientStorageManager()
+		service := ingredientService.NewService(repository, logger)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /ingredient", func(w http.ResponseWriter, r *http.Request) {
+			service.FindIngredients(r.Context())
+			logger.InfoContext(r.Context(), "listed")
+		})
+		handler := tracingMiddleware(mux)
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ingredient", nil))
+
+		spans := recorder.Ended()
+		assert.Len(t, spans, 2)
+
+		serviceSpan, serverSpan := spans[0], spans[1]
+		assert.Equal(t, "IngredientStorageService.FindIngredients", serviceSpan.Name())
+		assert.Equal(t, "GET /ingredient", serverSpan.Name())
+		assert.Equal(t, trace.SpanKindServer, serverSpan.SpanKind())
+		assert.Equal(t, serverSpan.SpanContext().SpanID(), serviceSpan.Parent().SpanID())
+
+		var line map[string]any
+		assert.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+		assert.Equal(t, serverSpan.SpanContext().TraceID().String(), line["trace_id"])
+	})
+
+	t.Run("it should continue a trace propagated by the caller", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+		previous := otel.GetTracerProvider()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+		t.Cleanup(func() { otel.SetTracerProvider(previous) })
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+
+		handler := tracingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		req := httptest.NewRequest(http.MethodGet, "/recipe", nil)
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		spans := recorder.Ended()
+		assert.Len(t, spans, 1)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+		assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+	})
+}
+
+type stubAuthenticator struct {
+	user.AuthProvider
+	tokens map[string]int
+}
+
+func (s stubAuthenticator) Authenticate(ctx context.Context, token string) (int, error) {
+	if id, ok := s.tokens[token]; ok {
+		return id, nil
+	}
+	return 0, domain.NewUnauthenticated("invalid or expired token")
+}
+
+func TestGuard(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	auth := stubAuthenticator{tokens: map[string]int{"secret": 7, "other": 8}}
+	households := householdService.NewHouseholdService(in_memory_repository.NewHouseholdManager(in_memory_repository.NewUserManager()), time.Hour, logger)
+	keys := apiKeyService.NewAPIKeyService(in_memory_repository.NewAPIKeyManager(), logger)
+	g := guard{auth: auth, keys: keys, households: households, logger: logger}
+	var userSeen int
+	var membershipSeen household.Membership
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userSeen, _ = user.UserIDFromContext(r.Context())
+		membershipSeen, _ = household.MembershipFromContext(r.Context())
+	})
+	handler := g.require(next)
+
+	serve := func(handler http.Handler, method, token string, headers ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/recipes", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("it should run the handler as the token's user in a personal household", func(t *testing.T) {
+		w := serve(handler, http.MethodGet, "secret")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 7, userSeen)
+		assert.Equal(t, household.Membership{Household: household.Household{Id: 1, Name: household.PersonalName}, UserId: 7, Role: household.RoleOwner}, membershipSeen)
+	})
+
+	t.Run("it should reject a household the user does not belong to", func(t *testing.T) {
+		w := serve(handler, http.MethodGet, "other", "X-Household-ID", "1")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("it should reject a malformed household header", func(t *testing.T) {
+		w := serve(handler, http.MethodGet, "secret", "X-Household-ID", "kitchen")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	for _, header := range []string{"", "Bearer", "Basic secret", "Bearer wrong", "Bearer qqk_wrong"} {
+		t.Run("it should reject the authorization header "+header, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/recipes", nil)
+			req.Header.Set("Authorization", header)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+		})
+	}
+
+	membership, err := households.Resolve(context.Background(), 7, nil)
+	require.NoError(t, err)
+	asOwner := household.WithMembership(user.WithUserID(context.Background(), 7), membership)
+	_, token, err := keys.Create(asOwner, apikey.Key{Name: "scanner", Scopes: []apikey.Scope{apikey.ScopePantryWrite}})
+	require.NoError(t, err)
+
+	t.Run("it should run the handler as the key's user in the key's household", func(t *testing.T) {
+		userSeen, membershipSeen = 0, household.Membership{}
+
+		w := serve(g.require(next, apikey.ScopePantryWrite), http.MethodPost, token, "X-Household-ID", "2")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 7, userSeen)
+		assert.Equal(t, membership, membershipSeen)
+	})
+
+	t.Run("it should reject a key without the route's scopes", func(t *testing.T) {
+		w := serve(g.require(next, apikey.ScopePantryWrite, apikey.ScopeRecipesRead), http.MethodGet, token)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "API key lacks scope recipes:read")
+	})
+
+	t.Run("it should pick the scope of a legacy route from the method", func(t *testing.T) {
+		legacy := g.requireByMethod(next, apikey.ScopePantryRead, apikey.ScopePantryWrite)
+
+		assert.Equal(t, http.StatusOK, serve(legacy, http.MethodPost, token).Code)
+		assert.Equal(t, http.StatusForbidden, serve(legacy, http.MethodGet, token).Code)
+	})
+
+	t.Run("it should keep keys off session-only routes", func(t *testing.T) {
+		sessionOnly := g.sessionOnly(next)
+
+		assert.Equal(t, http.StatusForbidden, serve(sessionOnly, http.MethodGet, token).Code)
+		assert.Equal(t, http.StatusOK, serve(sessionOnly, http.MethodGet, "secret").Code)
+	})
+
+	t.Run("it should reject a key whose user left its household", func(t *testing.T) {
+		code, _, err := households.Invite(asOwner, membership.Household.Id, household.RoleOwner)
+		require.NoError(t, err)
+		_, err = households.Join(user.WithUserID(context.Background(), 8), code)
+		require.NoError(t, err)
+		require.NoError(t, households.RemoveMember(asOwner, membership.Household.Id, 7))
+
+		w := serve(g.require(next, apikey.ScopePantryWrite), http.MethodPost, token)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "API key is no longer valid")
+	})
+}
+
+func TestGuardPage(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	households := householdService.NewHouseholdService(in_memory_repository.NewHouseholdManager(in_memory_repository.NewUserManager()), time.Hour, logger)
+	g := guard{auth: stubAuthenticator{tokens: map[string]int{"secret": 7}}, households: households, logger: logger}
+	var userSeen int
+	handler := g.page(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userSeen, _ = user.UserIDFromContext(r.Context())
+	}))
+
+	serve := func(method, cookie string, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/recipes", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if cookie != "" {
+			req.AddCookie(&http.Cookie{Name: pageController.SessionCookie, Value: cookie})
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("it should run the handler as the cookie's user", func(t *testing.T) {
+		w := serve(http.MethodGet, "secret", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 7, userSeen)
+	})
+
+	for _, cookie := range []string{"", "wrong"} {
+		t.Run("it should send the cookie "+cookie+" to the login page", func(t *testing.T) {
+			w := serve(http.MethodGet, cookie, nil)
+
+			assert.Equal(t, http.StatusSeeOther, w.Code)
+			assert.Equal(t, "/login?next=%2Frecipes", w.Header().Get("Location"))
+		})
+	}
+
+	t.Run("it should accept a form carrying the session's CSRF token", func(t *testing.T) {
+		w := serve(http.MethodPost, "secret", url.Values{"csrf": {pageController.CSRFToken("secret")}})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	for _, token := range []string{"", pageController.CSRFToken("other")} {
+		t.Run("it should reject a form with the CSRF token "+token, func(t *testing.T) {
+			w := serve(http.MethodPost, "secret", url.Values{"csrf": {token}})
+
+			assert.Equal(t, http.StatusForbidden, w.Code)
+		})
+	}
+}
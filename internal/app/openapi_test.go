@@ -0,0 +1,205 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"q-q-tem-pra-hoje/internal/domain/ingredient"
+	"q-q-tem-pra-hoje/internal/domain/recipe"
+	"q-q-tem-pra-hoje/internal/domain/user"
+	"q-q-tem-pra-hoje/internal/health"
+	"q-q-tem-pra-hoje/internal/metrics"
+	"q-q-tem-pra-hoje/internal/repository/in_memory_repository"
+	"q-q-tem-pra-hoje/internal/server/openapi"
+	apiKeyService "q-q-tem-pra-hoje/internal/service/apikey"
+	authService "q-q-tem-pra-hoje/internal/service/auth"
+	householdService "q-q-tem-pra-hoje/internal/service/household"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	openapi3filter.RegisterBodyDecoder("text/html", openapi3filter.PlainBodyDecoder)
+}
+
+func loadSpec(t *testing.T) *openapi3.T {
+	t.Helper()
+	doc, err := openapi3.NewLoader().LoadFromData(openapi.Spec())
+	require.NoError(t, err)
+	require.NoError(t, doc.Validate(context.Background()))
+	// Match routes on paths only, whatever host httptest uses.
+	doc.Servers = nil
+	return doc
+}
+
+// newSpecTestMux returns the routes over in-memory storage and a bearer token
+// for a registered user.
+func newSpecTestMux(t *testing.T) (http.Handler, string) {
+	t.Helper()
+	db, err := sql.Open("postgres", "host=localhost")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	logger := slog.New(slog.DiscardHandler)
+	ism := in_memory_repository.NewIngredientStorageManager()
+	rice := ingredient.Ingredient{Name: "Rice", MeasureType: "g", Quantity: 500}
+	id := 1
+	rm := in_memory_repository.NewRecipeManager([]recipe.Recipe{
+		{Id: &id, Name: "Plain rice", Ingredients: []ingredient.Ingredient{rice}},
+	})
+
+	users := in_memory_repository.NewUserManager()
+	as := authService.NewAuthService(users, in_memory_repository.NewSessionManager(), time.Hour, logger)
+	hs := householdService.NewHouseholdService(in_memory_repository.NewHouseholdManager(users), time.Hour, logger)
+	ks := apiKeyService.NewAPIKeyService(in_memory_repository.NewAPIKeyManager(), logger)
+	credentials := user.Credentials{Email: "cook@example.com", Password: "correct horse"}
+	_, err = as.Register(context.Background(), credentials)
+	require.NoError(t, err)
+	token, _, err := as.Login(context.Background(), credentials)
+	require.NoError(t, err)
+
+	checker := health.NewChecker(time.Second)
+	checker.SetState(health.Ready)
+	return routes(ism, rm, in_memory_repository.NewSearchManager(ism, rm), in_memory_repository.NewTransactor(), as, hs, ks, rateLimits{}, metrics.New(db, "postgres"), checker, logger), token
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	doc := loadSpec(t)
+	router, err := legacy.NewRouter(doc)
+	require.NoError(t, err)
+	handler, token := newSpecTestMux(t)
+
+	tests := []struct {
+		method         string
+		target         string
+		body           string
+		expectedStatus int
+	}{
+		{http.MethodPost, "/api/v1/auth/register", `{"email":"baker@example.com","password":"sourdough"}`, http.StatusCreated},
+		{http.MethodPost, "/api/v1/auth/register", `{"email":"cook@example.com","password":"sourdough"}`, http.StatusConflict},
+		{http.MethodPost, "/api/v1/auth/register", `{"email":"baker","password":"short"}`, http.StatusUnprocessableEntity},
+		{http.MethodPost, "/api/v1/auth/register", `{"email":"baker@example.com","password":"` + strings.Repeat("sourdough", 9) + `"}`, http.StatusUnprocessableEntity},
+		{http.MethodPost, "/api/v1/auth/login", `{"email":"baker@example.com","password":"sourdough"}`, http.StatusOK},
+		{http.MethodPost, "/api/v1/auth/login", `{"email":"baker@example.com","password":"wrong password"}`, http.StatusUnauthorized},
+		{http.MethodGet, "/api/v1/auth/me", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/households", "", http.StatusOK},
+		{http.MethodPost, "/api/v1/households", `{"name":"Beach house"}`, http.StatusCreated},
+		{http.MethodPost, "/api/v1/households", `{"name":" "}`, http.StatusUnprocessableEntity},
+		{http.MethodGet, "/api/v1/households/2/members", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/households/99/members", "", http.StatusNotFound},
+		{http.MethodPost, "/api/v1/households/2/invitations", `{"role":"viewer"}`, http.StatusCreated},
+		{http.MethodPost, "/api/v1/households/2/invitations", `{"role":"chef"}`, http.StatusUnprocessableEntity},
+		{http.MethodPost, "/api/v1/households/join", `{"code":"not-a-code"}`, http.StatusNotFound},
+		{http.MethodDelete, "/api/v1/households/2/members/1", "", http.StatusConflict},
+		{http.MethodPost, "/api/v1/api-keys", `{"name":"Barcode scanner","scopes":["pantry:read","pantry:write"]}`, http.StatusCreated},
+		{http.MethodPost, "/api/v1/api-keys", `{"name":"Barcode scanner","scopes":["pantry:admin"]}`, http.StatusUnprocessableEntity},
+		{http.MethodGet, "/api/v1/api-keys", "", http.StatusOK},
+		{http.MethodPost, "/api/v1/api-keys/1/rotate", "", http.StatusOK},
+		{http.MethodPost, "/api/v1/api-keys/99/rotate", "", http.StatusNotFound},
+		{http.MethodDelete, "/api/v1/api-keys/1", "", http.StatusNoContent},
+		{http.MethodDelete, "/api/v1/api-keys/99", "", http.StatusNotFound},
+		{http.MethodGet, "/api/v1/ingredients", "", http.StatusUnauthorized},
+		{http.MethodGet, "/api/v1/ingredients", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/ingredients?q=ri&sort=-quantity&limit=1", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/ingredients?sort=color", "", http.StatusUnprocessableEntity},
+		{http.MethodPost, "/api/v1/ingredients", `{"name":"Salt","measureType":"g","quantity":10}`, http.StatusCreated},
+		{http.MethodPost, "/api/v1/ingredients", `{"name":`, http.StatusBadRequest},
+		{http.MethodPost, "/api/v1/ingredients", `{"name":"","measureType":"g","quantity":10}`, http.StatusUnprocessableEntity},
+		{http.MethodPatch, "/api/v1/ingredients/1", `{"name":"Salt","measureType":"g","quantity":5}`, http.StatusOK},
+		{http.MethodDelete, "/api/v1/ingredients/1", "", http.StatusNoContent},
+		{http.MethodGet, "/api/v1/recipes", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/recipes?sort=name&limit=1", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/recipes?limit=0", "", http.StatusUnprocessableEntity},
+		{http.MethodPost, "/api/v1/recipes", `{"name":"Salted rice","ingredients":[{"name":"Rice","measureType":"g","quantity":100}]}`, http.StatusCreated},
+		{http.MethodPost, "/api/v1/recipes", `{"name":"Rice for everyone","visibility":"household","ingredients":[{"name":"Rice","measureType":"g","quantity":100}]}`, http.StatusCreated},
+		{http.MethodPost, "/api/v1/recipes", `{"name":"","ingredients":[]}`, http.StatusUnprocessableEntity},
+		{http.MethodGet, "/api/v1/search?q=ric&limit=5", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/search?q=", "", http.StatusUnprocessableEntity},
+		{http.MethodGet, "/api/v1/recipes/1", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/recipes/99", "", http.StatusNotFound},
+		{http.MethodDelete, "/api/v1/recipes/2", "", http.StatusNoContent},
+		{http.MethodGet, "/api/v1/recommendations", "", http.StatusOK},
+		{http.MethodGet, "/ingredient", "", http.StatusOK},
+		{http.MethodPost, "/ingredient", `{"name":"Salt","measureType":"g","quantity":10}`, http.StatusCreated},
+		{http.MethodPatch, "/ingredient/2", `{"name":"Salt","measureType":"g","quantity":5}`, http.StatusOK},
+		{http.MethodDelete, "/ingredient?id=2", "", http.StatusNoContent},
+		{http.MethodDelete, "/ingredient?id=abc", "", http.StatusBadRequest},
+		{http.MethodGet, "/recipe", "", http.StatusOK},
+		{http.MethodPost, "/recipe", `{"name":"Fried rice","ingredients":[{"name":"Rice","measureType":"g","quantity":100}]}`, http.StatusCreated},
+		{http.MethodDelete, "/recipe?id=4", "", http.StatusNoContent},
+		{http.MethodGet, "/recommendation", "", http.StatusOK},
+		{http.MethodGet, "/api/v1/export", "", http.StatusOK},
+		{http.MethodPost, "/api/v1/import?dryRun=true", `{"version":1,"ingredients":[{"name":"Rice","measureType":"g","quantity":750}],"recipes":[]}`, http.StatusOK},
+		{http.MethodPost, "/api/v1/import?mode=replace", `{"version":1,"ingredients":[{"name":"Beans","measureType":"g","quantity":250}],"recipes":[{"name":"Beans","ingredients":[{"name":"Beans","measureType":"g","quantity":100}]}]}`, http.StatusOK},
+		{http.MethodPost, "/api/v1/import?dryRun=maybe", `{"version":1,"ingredients":[],"recipes":[]}`, http.StatusBadRequest},
+		{http.MethodPost, "/api/v1/import", `{"version":2,"ingredients":[],"recipes":[]}`, http.StatusUnprocessableEntity},
+		{http.MethodPost, "/api/v1/auth/logout", "", http.StatusNoContent},
+		{http.MethodGet, "/metrics", "", http.StatusOK},
+		{http.MethodGet, "/healthz", "", http.StatusOK},
+		{http.MethodGet, "/readyz", "", http.StatusOK},
+		{http.MethodGet, "/openapi.json", "", http.StatusOK},
+		{http.MethodGet, "/docs", "", http.StatusOK},
+	}
+
+	covered := map[string]bool{}
+	for _, tc := range tests {
+		t.Run(tc.method+" "+tc.target, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.target, bytes.NewBufferString(tc.body))
+			if tc.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			// 401 cases are exercised by leaving the token out.
+			if tc.expectedStatus != http.StatusUnauthorized {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			require.Equal(t, tc.expectedStatus, w.Code, w.Body.String())
+
+			route, pathParams, err := router.FindRoute(req)
+			require.NoError(t, err, "route is not documented")
+			covered[route.Method+" "+route.Path] = true
+			if route.Operation.Deprecated {
+				assert.Equal(t, "true", w.Header().Get("Deprecation"))
+			}
+
+			input := &openapi3filter.RequestValidationInput{
+				Request:    req,
+				PathParams: pathParams,
+				Route:      route,
+				Options:    &openapi3filter.Options{IncludeResponseStatus: true, AuthenticationFunc: openapi3filter.NoopAuthenticationFunc},
+			}
+			// Only well-formed requests are expected to match the request schema.
+			if tc.expectedStatus < http.StatusBadRequest {
+				req.Body = io.NopCloser(bytes.NewBufferString(tc.body))
+				assert.NoError(t, openapi3filter.ValidateRequest(context.Background(), input))
+			}
+
+			err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: input,
+				Status:                 w.Code,
+				Header:                 w.Header(),
+				Body:                   io.NopCloser(bytes.NewReader(w.Body.Bytes())),
+				Options:                input.Options,
+			})
+			assert.NoError(t, err)
+		})
+	}
+
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			assert.True(t, covered[method+" "+path], "%s %s is documented but not exercised", method, path)
+		}
+	}
+}
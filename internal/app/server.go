@@ -1,65 +1,226 @@
 package app
 
 import (
+	"context"
 	"database/sql"
+	"log/slog"
 	"net/http"
-	"q-q-tem-pra-hoje/internal/repository/postgres"
+	"q-q-tem-pra-hoje/internal/config"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/apikey"
+	"q-q-tem-pra-hoje/internal/domain/household"
+	"q-q-tem-pra-hoje/internal/domain/ingredient"
+	"q-q-tem-pra-hoje/internal/domain/recipe"
+	"q-q-tem-pra-hoje/internal/domain/search"
+	"q-q-tem-pra-hoje/internal/domain/user"
+	"q-q-tem-pra-hoje/internal/health"
+	"q-q-tem-pra-hoje/internal/metrics"
+	apiKeyController "q-q-tem-pra-hoje/internal/server/controller/apikey"
+	authController "q-q-tem-pra-hoje/internal/server/controller/auth"
+	backupController "q-q-tem-pra-hoje/internal/server/controller/backup"
+	householdController "q-q-tem-pra-hoje/internal/server/controller/household"
 	ingredientController "q-q-tem-pra-hoje/internal/server/controller/ingredient"
+	pageController "q-q-tem-pra-hoje/internal/server/controller/page"
 	recipeController "q-q-tem-pra-hoje/internal/server/controller/recipe"
 	recommendationController "q-q-tem-pra-hoje/internal/server/controller/recommendation"
+	searchController "q-q-tem-pra-hoje/internal/server/controller/search"
+	"q-q-tem-pra-hoje/internal/server/openapi"
+	apiKeyService "q-q-tem-pra-hoje/internal/service/apikey"
+	authService "q-q-tem-pra-hoje/internal/service/auth"
+	backupService "q-q-tem-pra-hoje/internal/service/backup"
+	householdService "q-q-tem-pra-hoje/internal/service/household"
 	ingredientService "q-q-tem-pra-hoje/internal/service/ingredient"
 	recipeService "q-q-tem-pra-hoje/internal/service/recipe"
 	recommendationService "q-q-tem-pra-hoje/internal/service/recommendation"
+	searchService "q-q-tem-pra-hoje/internal/service/search"
+	"q-q-tem-pra-hoje/web"
+	"time"
 )
 
 type Server struct {
 	server *http.Server
+	health *health.Checker
 }
 
 func (s Server) Start() error {
 	return s.server.ListenAndServe()
 }
 
-func NewServer(db *sql.DB) *Server {
-	handler := NewHandler(db)
+func (s Server) Addr() string {
+	return s.server.Addr
+}
+
+// MarkReady makes /readyz report the server as able to take traffic. It
+// should be called once migrations have been applied.
+func (s Server) MarkReady() {
+	s.health.SetState(health.Ready)
+}
+
+// MarkDraining makes /readyz fail so load balancers stop routing new
+// requests while in-flight ones finish.
+func (s Server) MarkDraining() {
+	s.health.SetState(health.Draining)
+}
+
+func (s Server) Shutdown(ctx context.Context) error {
+	s.MarkDraining()
+	return s.server.Shutdown(ctx)
+}
+
+// NewServer builds a server over s whose readiness starts as failing; see
+// MarkReady.
+func NewServer(s Storage, logger *slog.Logger) *Server {
+	serverConfig := config.LoadServerConfig()
+	checker := health.NewChecker(serverConfig.HealthCheckTimeout, s.Checks...)
+	handler := newHandler(s, logger, checker)
 	return &Server{
 		server: &http.Server{
-			Addr:    ":8080",
-			Handler: handler,
-		}}
+			Addr:     serverConfig.Addr,
+			Handler:  handler,
+			ErrorLog: slog.NewLogLogger(logger.Handler(), slog.LevelError),
+		},
+		health: checker,
+	}
 }
 
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+// NewHandler builds the application handler for an already migrated
+// database, so its readiness endpoint reports ready from the start.
+func NewHandler(db *sql.DB, logger *slog.Logger) http.Handler {
+	s := PostgresStorage(db, logger)
+	checker := health.NewChecker(2*time.Second, s.Checks...)
+	checker.SetState(health.Ready)
+	return newHandler(s, logger, checker)
+}
 
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
+func newHandler(s Storage, logger *slog.Logger, checker *health.Checker) http.Handler {
+	m := metrics.New(s.DB, s.Name)
+	authConfig := config.LoadAuthConfig()
+	as := authService.NewAuthService(s.Users, s.Sessions, authConfig.SessionTTL, logger)
+	hs := householdService.NewHouseholdService(s.Households, authConfig.InvitationTTL, logger)
+	ks := apiKeyService.NewAPIKeyService(s.APIKeys, logger)
+	limitConfig := config.LoadRateLimitConfig()
+	limits := rateLimits{
+		api:             newLimiter(limitConfig.APIRate, limitConfig.APIBurst),
+		auth:            newLimiter(limitConfig.AuthRate, limitConfig.AuthBurst),
+		recommendations: newLimiter(limitConfig.RecommendationsRate, limitConfig.RecommendationsBurst),
+		clientIPHeader:  limitConfig.ClientIPHeader,
+		logger:          logger,
+	}
+	mux := routes(s.Ingredients, s.Recipes, s.Search, s.Transactor, as, hs, ks, limits, m, checker, logger)
+	pages(mux, s.Ingredients, s.Recipes, as, hs, ks, limits, m, authConfig.SecureCookies, logger)
 
-		next.ServeHTTP(w, r)
-	})
+	corsConfig := config.LoadCORSConfig()
+	cors := newCORSPolicy(corsConfig.AllowedOrigins, corsConfig.AllowCredentials, corsConfig.MaxAge)
+
+	return requestIDMiddleware(tracingMiddleware(loggingMiddleware(logger, metricsMiddleware(m, limitBody(limitConfig.MaxBodyBytes, limitConfig.MaxImportBytes, logger, corsMiddleware(cors, mux, logger))))))
 }
 
-func NewHandler(db *sql.DB) http.Handler {
-	ism := postgres.NewIngredientStorageManager(db)
-	rm := postgres.NewRecipeManager(db)
-	is := ingredientService.NewService(&ism)
-	rs := recipeService.NewRecipeService(rm)
-	res := recommendationService.NewRecommendationService(rm)
-	ic := ingredientController.NewIngredientController(is)
-	rc := recipeController.NewRecipeController(is, rs)
-	rec := recommendationController.NewRecommendationController(is, res)
+// importPath is where documents are imported, under their own body limit.
+const importPath = "/api/v1/import"
+
+// routes wires the services and controllers over the given storage and
+// registers every route; the OpenAPI document must describe all of them.
+// Pantry and recipe routes require a session from ap or an API key from kp
+// granted the route's scopes, and act within the household hp resolves for
+// the caller. Imports run in transactions of tx, and rl throttles every API
+// route.
+func routes(ism ingredient.IngredientStorageManager, rm recipe.RecipeManager, sm search.SearchManager, tx domain.Transactor, ap user.AuthProvider, hp household.HouseholdProvider, kp apikey.APIKeyProvider, rl rateLimits, m *metrics.Metrics, checker *health.Checker, logger *slog.Logger) *http.ServeMux {
+	is := ingredientService.NewService(ism, logger)
+	rs := recipeService.NewRecipeService(rm, logger)
+	res := recommendationService.NewRecommendationService(rm, logger)
+	ic := ingredientController.NewIngredientController(is, logger)
+	rc := recipeController.NewRecipeController(is, rs, logger)
+	rec := recommendationController.NewRecommendationController(is, m.InstrumentRecommendations(res), logger)
+	sc := searchController.NewSearchController(searchService.NewSearchService(sm, logger), logger)
+	ac := authController.NewAuthController(ap, logger)
+	hc := householdController.NewHouseholdController(hp, logger)
+	kc := apiKeyController.NewAPIKeyController(kp, logger)
+	bc := backupController.NewBackupController(backupService.NewBackupService(ism, rm, tx, logger), logger)
+	m.RegisterDomainGauges(ism, rm)
 
 	mux := http.NewServeMux()
-	mux.Handle("/ingredient", ic)
-	mux.Handle("/ingredient/{id}", ic)
-	mux.Handle("/recipe", rc)
-	mux.Handle("/recommendation", rec)
+	g := guard{auth: ap, keys: kp, households: hp, logger: logger}
+	private := func(pattern string, handler http.HandlerFunc, scopes ...apikey.Scope) {
+		mux.Handle(pattern, rl.limit(rl.api, g.require(handler, scopes...)))
+	}
+	sessionOnly := func(pattern string, handler http.HandlerFunc) {
+		mux.Handle(pattern, rl.limit(rl.api, g.sessionOnly(handler)))
+	}
+
+	mux.Handle("POST /api/v1/auth/register", rl.limit(rl.auth, http.HandlerFunc(ac.Register)))
+	mux.Handle("POST /api/v1/auth/login", rl.limit(rl.auth, http.HandlerFunc(ac.Login)))
+	sessionOnly("POST /api/v1/auth/logout", ac.Logout)
+	sessionOnly("GET /api/v1/auth/me", ac.Me)
+
+	sessionOnly("GET /api/v1/households", hc.List)
+	sessionOnly("POST /api/v1/households", hc.Create)
+	sessionOnly("POST /api/v1/households/join", hc.Join)
+	sessionOnly("GET /api/v1/households/{id}/members", hc.Members)
+	sessionOnly("DELETE /api/v1/households/{id}/members/{userId}", hc.RemoveMember)
+	sessionOnly("POST /api/v1/households/{id}/invitations", hc.Invite)
+
+	sessionOnly("GET /api/v1/api-keys", kc.List)
+	sessionOnly("POST /api/v1/api-keys", kc.Create)
+	sessionOnly("POST /api/v1/api-keys/{id}/rotate", kc.Rotate)
+	sessionOnly("DELETE /api/v1/api-keys/{id}", kc.Revoke)
+
+	private("GET /api/v1/ingredients", ic.GetAll, apikey.ScopePantryRead)
+	private("POST /api/v1/ingredients", ic.Add, apikey.ScopePantryWrite)
+	private("PATCH /api/v1/ingredients/{id}", ic.Update, apikey.ScopePantryWrite)
+	private("DELETE /api/v1/ingredients/{id}", ic.Delete, apikey.ScopePantryWrite)
+	private("GET /api/v1/recipes", rc.GetRecipes, apikey.ScopeRecipesRead)
+	private("POST /api/v1/recipes", rc.Add, apikey.ScopeRecipesWrite)
+	private("GET /api/v1/recipes/{id}", rc.Get, apikey.ScopeRecipesRead)
+	private("DELETE /api/v1/recipes/{id}", rc.Delete, apikey.ScopeRecipesWrite)
+	mux.Handle("GET /api/v1/recommendations", rl.limit(rl.recommendations, g.require(http.HandlerFunc(rec.GetRecommendation), apikey.ScopeRecommendationsRead)))
+	private("GET /api/v1/search", sc.Search, apikey.ScopePantryRead, apikey.ScopeRecipesRead)
+	private("GET /api/v1/export", bc.Export, apikey.ScopePantryRead, apikey.ScopeRecipesRead)
+	private("POST "+importPath, bc.Import, apikey.ScopePantryWrite, apikey.ScopeRecipesWrite)
+
+	// Pre-versioning routes, kept until clients move to /api/v1.
+	mux.Handle("/ingredient", rl.limit(rl.api, g.requireByMethod(deprecated("/api/v1/ingredients", ic), apikey.ScopePantryRead, apikey.ScopePantryWrite)))
+	mux.Handle("/ingredient/{id}", rl.limit(rl.api, g.requireByMethod(deprecated("/api/v1/ingredients/{id}", ic), apikey.ScopePantryRead, apikey.ScopePantryWrite)))
+	mux.Handle("/recipe", rl.limit(rl.api, g.requireByMethod(deprecated("/api/v1/recipes", rc), apikey.ScopeRecipesRead, apikey.ScopeRecipesWrite)))
+	mux.Handle("/recommendation", rl.limit(rl.recommendations, g.requireByMethod(deprecated("/api/v1/recommendations", rec), apikey.ScopeRecommendationsRead, apikey.ScopeRecommendationsRead)))
+
+	mux.Handle("GET /metrics", m.Handler())
+	mux.HandleFunc("GET /healthz", checker.Liveness)
+	mux.HandleFunc("GET /readyz", checker.Readiness)
+	mux.HandleFunc("GET /openapi.json", openapi.SpecHandler)
+	mux.HandleFunc("GET /docs", openapi.DocsHandler)
+
+	return mux
+}
+
+// pages registers the server-rendered HTML UI, which shares the API's
+// services but authenticates with a session cookie instead of a bearer
+// token, marked Secure on every request when secureCookies is set. The
+// OpenAPI document does not describe these routes.
+func pages(mux *http.ServeMux, ism ingredient.IngredientStorageManager, rm recipe.RecipeManager, ap user.AuthProvider, hp household.HouseholdProvider, kp apikey.APIKeyProvider, rl rateLimits, m *metrics.Metrics, secureCookies bool, logger *slog.Logger) {
+	ui := web.New()
+	is := ingredientService.NewService(ism, logger)
+	res := m.InstrumentRecommendations(recommendationService.NewRecommendationService(rm, logger))
+	pc := pageController.NewPageController(ap, is, recipeService.NewRecipeService(rm, logger), res, ui, secureCookies, logger)
+
+	g := guard{auth: ap, keys: kp, households: hp, logger: logger}
+	page := func(pattern string, handler http.HandlerFunc) {
+		mux.Handle(pattern, rl.limit(rl.api, g.page(handler)))
+	}
 
-	return corsMiddleware(mux)
+	mux.HandleFunc("GET /static/", ui.Static)
+	mux.Handle("GET /login", rl.limit(rl.api, http.HandlerFunc(pc.LoginForm)))
+	mux.Handle("POST /login", rl.limit(rl.auth, http.HandlerFunc(pc.Login)))
+	mux.Handle("POST /register", rl.limit(rl.auth, http.HandlerFunc(pc.Register)))
+	page("POST /logout", pc.Logout)
 
+	page("GET /{$}", pc.Home)
+	page("GET /pantry", pc.Pantry)
+	page("POST /pantry", pc.AddIngredient)
+	page("POST /pantry/{id}", pc.UpdateIngredient)
+	page("POST /pantry/{id}/delete", pc.DeleteIngredient)
+	page("GET /recipes", pc.Recipes)
+	page("POST /recipes", pc.CreateRecipe)
+	page("GET /recipes/{id}", pc.Recipe)
+	page("POST /recipes/{id}/delete", pc.DeleteRecipe)
+	mux.Handle("GET /recommendations", rl.limit(rl.recommendations, g.page(http.HandlerFunc(pc.Recommendations))))
 }
@@ -0,0 +1,128 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"q-q-tem-pra-hoje/internal/domain/backup"
+	"q-q-tem-pra-hoje/internal/health"
+	"q-q-tem-pra-hoje/internal/server/dto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteScopes(t *testing.T) {
+	handler, session := newSpecTestMux(t)
+	serve := func(method, target, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(http.MethodPost, "/api/v1/api-keys", session, `{"name":"Barcode scanner","scopes":["pantry:write"]}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Key string `json:"key"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	key := created.Key
+
+	tests := []struct {
+		method         string
+		target         string
+		body           string
+		expectedStatus int
+	}{
+		{http.MethodPost, "/api/v1/ingredients", `{"name":"Salt","measureType":"g","quantity":10}`, http.StatusCreated},
+		{http.MethodPost, "/ingredient", `{"name":"Pepper","measureType":"g","quantity":10}`, http.StatusCreated},
+		{http.MethodGet, "/api/v1/ingredients", "", http.StatusForbidden},
+		{http.MethodGet, "/ingredient", "", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/recipes", "", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/search?q=salt", "", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/recommendations", "", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/auth/me", "", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/households", "", http.StatusForbidden},
+		{http.MethodPost, "/api/v1/api-keys", `{"name":"Escalated","scopes":["recipes:write"]}`, http.StatusForbidden},
+	}
+	for _, tc := range tests {
+		t.Run(tc.method+" "+tc.target, func(t *testing.T) {
+			w := serve(tc.method, tc.target, key, tc.body)
+
+			assert.Equal(t, tc.expectedStatus, w.Code, w.Body.String())
+		})
+	}
+
+	t.Run("it should reject a revoked key", func(t *testing.T) {
+		require.Equal(t, http.StatusNoContent, serve(http.MethodDelete, "/api/v1/api-keys/1", session, "").Code)
+
+		w := serve(http.MethodPost, "/api/v1/ingredients", key, `{"name":"Salt","measureType":"g","quantity":10}`)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestImportLimit(t *testing.T) {
+	checker := health.NewChecker(time.Second)
+	checker.SetState(health.Ready)
+	handler := newHandler(MemoryStorage(), slog.New(slog.DiscardHandler), checker)
+	serve := func(method, target, token string, body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, bytes.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	credentials := []byte(`{"email":"cook@example.com","password":"correct horse"}`)
+	require.Equal(t, http.StatusCreated, serve(http.MethodPost, "/api/v1/auth/register", "", credentials).Code)
+	w := serve(http.MethodPost, "/api/v1/auth/login", "", credentials)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var session struct {
+		Token string `json:"token"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &session))
+
+	document := dto.Backup{Version: backup.Version, Ingredients: []dto.IngredientInput{}}
+	for i := range 50 {
+		r := dto.RecipeInput{Name: fmt.Sprintf("Recipe %d", i), Visibility: "private"}
+		for j := range 300 {
+			r.Ingredients = append(r.Ingredients, dto.IngredientInput{Name: fmt.Sprintf("Ingredient %d of a recipe with a long list", j), MeasureType: "g", Quantity: j + 1})
+		}
+		document.Recipes = append(document.Recipes, r)
+	}
+	body, err := json.Marshal(document)
+	require.NoError(t, err)
+	require.Greater(t, len(body), 1<<20)
+
+	t.Run("it should import a document larger than other bodies may be", func(t *testing.T) {
+		w := serve(http.MethodPost, "/api/v1/import", session.Token, body)
+
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	})
+
+	t.Run("it should import its own export again", func(t *testing.T) {
+		exported := serve(http.MethodGet, "/api/v1/export", session.Token, nil)
+		require.Equal(t, http.StatusOK, exported.Code)
+		require.Greater(t, exported.Body.Len(), 1<<20)
+
+		w := serve(http.MethodPost, "/api/v1/import", session.Token, exported.Body.Bytes())
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), `"recipes":{"added":0,"updated":0,"unchanged":50,"deleted":0}`)
+	})
+
+	t.Run("it should keep the smaller limit on other routes", func(t *testing.T) {
+		w := serve(http.MethodPost, "/api/v1/recipes", session.Token, []byte(`{"name":"`+strings.Repeat("a", 1<<20)+`"}`))
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+}
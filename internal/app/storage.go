@@ -0,0 +1,97 @@
+package app
+
+import (
+	"database/sql"
+	"log/slog"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/apikey"
+	"q-q-tem-pra-hoje/internal/domain/household"
+	"q-q-tem-pra-hoje/internal/domain/ingredient"
+	"q-q-tem-pra-hoje/internal/domain/recipe"
+	"q-q-tem-pra-hoje/internal/domain/search"
+	"q-q-tem-pra-hoje/internal/domain/user"
+	"q-q-tem-pra-hoje/internal/health"
+	"q-q-tem-pra-hoje/internal/repository/in_memory_repository"
+	"q-q-tem-pra-hoje/internal/repository/postgres"
+	"q-q-tem-pra-hoje/internal/repository/sqlite"
+)
+
+// Storage is the set of repositories the server runs on.
+type Storage struct {
+	// Name is the storage mode, as selected by STORAGE.
+	Name        string
+	Ingredients ingredient.IngredientStorageManager
+	Recipes     recipe.RecipeManager
+	Search      search.SearchManager
+	Users       user.UserManager
+	Sessions    user.SessionManager
+	Households  household.HouseholdManager
+	APIKeys     apikey.APIKeyManager
+	// Transactor makes changes to Ingredients and Recipes atomic.
+	Transactor domain.Transactor
+
+	// DB is the database behind the repositories, if any. Its connection
+	// pool is exposed as metrics.
+	DB *sql.DB
+	// Checks are the readiness checks of what backs the repositories.
+	Checks []health.Check
+}
+
+func PostgresStorage(db *sql.DB, logger *slog.Logger) Storage {
+	ism := postgres.NewIngredientStorageManager(db, logger)
+	return Storage{
+		Name:        "postgres",
+		Ingredients: &ism,
+		Recipes:     postgres.NewRecipeManager(db, logger),
+		Search:      postgres.NewSearchManager(db, logger),
+		Users:       postgres.NewUserManager(db, logger),
+		Sessions:    postgres.NewSessionManager(db, logger),
+		Households:  postgres.NewHouseholdManager(db, logger),
+		APIKeys:     postgres.NewAPIKeyManager(db, logger),
+		Transactor:  postgres.NewTransactor(db, logger),
+		DB:          db,
+		Checks:      []health.Check{health.DatabaseCheck(db), health.MigrationCheck(db)},
+	}
+}
+
+// SQLiteStorage keeps everything in a single SQLite file, for a single
+// server without a database to run. db must have been opened with
+// database.OpenSQLite, which applies its migrations; search uses the
+// in-memory matcher over the stored names.
+func SQLiteStorage(db *sql.DB, logger *slog.Logger) Storage {
+	ism := sqlite.NewIngredientStorageManager(db, logger)
+	rm := sqlite.NewRecipeManager(db, logger)
+	return Storage{
+		Name:        "sqlite",
+		Ingredients: ism,
+		Recipes:     rm,
+		Search:      in_memory_repository.NewSearchManager(ism, rm),
+		Users:       sqlite.NewUserManager(db, logger),
+		Sessions:    sqlite.NewSessionManager(db, logger),
+		Households:  sqlite.NewHouseholdManager(db, logger),
+		APIKeys:     sqlite.NewAPIKeyManager(db, logger),
+		Transactor:  sqlite.NewTransactor(db, logger),
+		DB:          db,
+		Checks:      []health.Check{health.DatabaseCheck(db)},
+	}
+}
+
+// MemoryStorage keeps everything in process memory, for demos and frontend
+// development without any infrastructure. It starts empty and is lost when
+// the server stops.
+func MemoryStorage() Storage {
+	ism := in_memory_repository.NewIngredientStorageManager()
+	rm := in_memory_repository.NewRecipeManager(nil)
+	users := in_memory_repository.NewUserManager()
+	return Storage{
+		Name:        "memory",
+		Ingredients: ism,
+		Recipes:     rm,
+		Search:      in_memory_repository.NewSearchManager(ism, rm),
+		Users:       users,
+		Sessions:    in_memory_repository.NewSessionManager(),
+		Households:  in_memory_repository.NewHouseholdManager(users),
+		APIKeys:     in_memory_repository.NewAPIKeyManager(),
+		Transactor:  in_memory_repository.NewTransactor(),
+	}
+}
@@ -0,0 +1,190 @@
+// Package cli implements qqtem, the command-line client of the HTTP API.
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// command is one leaf of the command tree, such as "pantry add".
+type command struct {
+	name     string
+	synopsis string
+	summary  string
+	run      func(ctx context.Context, e *env, args []string) error
+}
+
+// commands are listed in the order the usage shows them.
+var commands = []command{
+	{"pantry ls", "[-q search]", "list the pantry", pantryList},
+	{"pantry add", "<quantity> <unit> <name>", "add an ingredient, e.g. pantry add 2 kg arroz", pantryAdd},
+	{"pantry rm", "<id>", "remove an ingredient", pantryRemove},
+	{"recipe ls", "[-q search]", "list recipes", recipeList},
+	{"recipe show", "<id>", "show a recipe and its ingredients", recipeShow},
+	{"recipe add", "-f <file>", "add a recipe from a YAML or JSON file, - for stdin", recipeAdd},
+	{"recipe rm", "<id>", "remove a recipe", recipeRemove},
+	{"recommend", "[-top n]", "recommend recipes for what is in the pantry", recommend},
+}
+
+// errUsage reports a command line that does not make sense, after the
+// usage has been printed.
+var errUsage = errors.New("usage")
+
+// settings are the flags every command accepts, defaulting to the
+// environment.
+type settings struct {
+	url       string
+	token     string
+	household string
+	output    string
+	timeout   time.Duration
+}
+
+func (s *settings) register(fs *flag.FlagSet) {
+	fs.StringVar(&s.url, "url", s.url, "API base URL ($QQTEM_URL)")
+	// Func keeps the token out of the usage, which shows defaults.
+	fs.Func("token", "API key or session token ($QQTEM_TOKEN)", func(token string) error {
+		s.token = token
+		return nil
+	})
+	fs.StringVar(&s.household, "household", s.household, "ID of the household to act in ($QQTEM_HOUSEHOLD)")
+	fs.StringVar(&s.output, "o", s.output, "output format: table or json")
+	fs.DurationVar(&s.timeout, "timeout", s.timeout, "how long to wait for the API")
+}
+
+// env is what a command runs with.
+type env struct {
+	settings
+	// command is the command being run.
+	command command
+	stdin   io.Reader
+	stdout  io.Writer
+	stderr  io.Writer
+}
+
+// Run executes the command line args and returns the process exit code.
+func Run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	e := &env{
+		settings: settings{
+			url:       getEnv("QQTEM_URL", "http://localhost:8080"),
+			token:     os.Getenv("QQTEM_TOKEN"),
+			household: os.Getenv("QQTEM_HOUSEHOLD"),
+			output:    "table",
+			timeout:   30 * time.Second,
+		},
+		stdin:  stdin,
+		stdout: stdout,
+		stderr: stderr,
+	}
+
+	err := e.run(ctx, args)
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		return 2
+	default:
+		fmt.Fprintf(stderr, "qqtem: %v\n", err)
+		return 1
+	}
+}
+
+func (e *env) run(ctx context.Context, args []string) error {
+	global := flag.NewFlagSet("qqtem", flag.ContinueOnError)
+	global.SetOutput(e.stderr)
+	global.Usage = e.usage(global)
+	e.settings.register(global)
+	if err := global.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+
+	args = global.Args()
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.name)
+		if len(args) >= len(words) && strings.Join(args[:len(words)], " ") == cmd.name {
+			e.command = cmd
+			return cmd.run(ctx, e, args[len(words):])
+		}
+	}
+	if len(args) > 0 {
+		fmt.Fprintf(e.stderr, "qqtem: unknown command %q\n\n", strings.Join(args, " "))
+	}
+	global.Usage()
+	return errUsage
+}
+
+func (e *env) usage(fs *flag.FlagSet) func() {
+	return func() {
+		fmt.Fprint(e.stderr, "Usage: qqtem [flags] <command> [arguments]\n\nCommands:\n")
+		tw := tabwriter.NewWriter(e.stderr, 0, 0, 3, ' ', 0)
+		for _, cmd := range commands {
+			fmt.Fprintf(tw, "  %s %s\t%s\n", cmd.name, cmd.synopsis, cmd.summary)
+		}
+		tw.Flush()
+		fmt.Fprint(e.stderr, "\nFlags, accepted before the command or after it:\n")
+		fs.PrintDefaults()
+	}
+}
+
+// flags returns the flag set of the command being run, which also accepts
+// the global flags so they can be given after the command.
+func (e *env) flags() *flag.FlagSet {
+	cmd := e.command
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "Usage: qqtem %s %s\n\n%s.\n\nFlags:\n", cmd.name, cmd.synopsis, cmd.summary)
+		fs.PrintDefaults()
+	}
+	e.settings.register(fs)
+	return fs
+}
+
+// parse parses args into fs and checks that between min and max positional
+// arguments remain; max < 0 means no upper bound.
+func (e *env) parse(fs *flag.FlagSet, args []string, min, max int) ([]string, error) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, err
+		}
+		return nil, errUsage
+	}
+	if e.output != "table" && e.output != "json" {
+		fmt.Fprintf(e.stderr, "qqtem: unknown output format %q\n\n", e.output)
+		fs.Usage()
+		return nil, errUsage
+	}
+	rest := fs.Args()
+	if len(rest) < min || (max >= 0 && len(rest) > max) {
+		fs.Usage()
+		return nil, errUsage
+	}
+	return rest, nil
+}
+
+func (e *env) client() *client {
+	return &client{
+		baseURL:   e.url,
+		token:     e.token,
+		household: e.household,
+		http:      &http.Client{Timeout: e.timeout},
+	}
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,188 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"q-q-tem-pra-hoje/internal/server/dto"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAPI records the requests it gets and answers them from routes.
+type fakeAPI struct {
+	requests []*http.Request
+	bodies   []string
+	routes   map[string]http.HandlerFunc
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	f.requests = append(f.requests, r)
+	f.bodies = append(f.bodies, string(body))
+	route, ok := f.routes[r.Method+" "+r.URL.Path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	route(w, r)
+}
+
+func respond(status int, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}
+}
+
+func run(t *testing.T, api *fakeAPI, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+	t.Setenv("QQTEM_URL", server.URL)
+	t.Setenv("QQTEM_TOKEN", "qqk_secret")
+	t.Setenv("QQTEM_HOUSEHOLD", "")
+
+	var stdout, stderr bytes.Buffer
+	code := Run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestPantry(t *testing.T) {
+	t.Run("it should list every page of the pantry as a table", func(t *testing.T) {
+		api := &fakeAPI{routes: map[string]http.HandlerFunc{
+			"GET /api/v1/ingredients": func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("cursor") == "" {
+					w.Header().Set(dto.NextCursorHeader, "next")
+					respond(http.StatusOK, `[{"id":1,"name":"arroz","measureType":"kg","quantity":2}]`)(w, r)
+					return
+				}
+				respond(http.StatusOK, `[{"id":2,"name":"feijão preto","measureType":"g","quantity":500}]`)(w, r)
+			},
+		}}
+
+		code, stdout, _ := run(t, api, "", "pantry", "ls")
+
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "ID  NAME          QUANTITY  UNIT\n1   arroz         2         kg\n2   feijão preto  500       g\n", stdout)
+		require.Len(t, api.requests, 2)
+		assert.Equal(t, "Bearer qqk_secret", api.requests[0].Header.Get("Authorization"))
+		assert.Equal(t, "next", api.requests[1].URL.Query().Get("cursor"))
+	})
+
+	t.Run("it should add an ingredient named by the remaining arguments", func(t *testing.T) {
+		api := &fakeAPI{routes: map[string]http.HandlerFunc{"POST /api/v1/ingredients": respond(http.StatusCreated, "")}}
+
+		code, stdout, _ := run(t, api, "", "--household", "3", "pantry", "add", "2", "kg", "arroz", "integral")
+
+		assert.Equal(t, 0, code)
+		assert.Empty(t, stdout)
+		require.Len(t, api.requests, 1)
+		assert.Equal(t, "3", api.requests[0].Header.Get("X-Household-ID"))
+		assert.JSONEq(t, `{"name":"arroz integral","measureType":"kg","quantity":2}`, api.bodies[0])
+	})
+
+	t.Run("it should refuse a quantity that is not a number", func(t *testing.T) {
+		api := &fakeAPI{}
+
+		code, _, stderr := run(t, api, "", "pantry", "add", "two", "kg", "arroz")
+
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stderr, `quantity "two" must be a whole number`)
+		assert.Empty(t, api.requests)
+	})
+
+	t.Run("it should report the problem the API answers with", func(t *testing.T) {
+		api := &fakeAPI{routes: map[string]http.HandlerFunc{
+			"POST /api/v1/ingredients": respond(http.StatusUnprocessableEntity, `{"type":"about:blank","title":"Unprocessable Entity","status":422,"detail":"validation failed","errors":[{"field":"quantity","message":"ingredient quantity cannot be negative"}]}`),
+		}}
+
+		code, _, stderr := run(t, api, "", "pantry", "add", "--", "-1", "kg", "arroz")
+
+		assert.Equal(t, 1, code)
+		assert.Equal(t, "qqtem: validation failed\n  quantity: ingredient quantity cannot be negative\n", stderr)
+	})
+
+	t.Run("it should remove an ingredient by id", func(t *testing.T) {
+		api := &fakeAPI{routes: map[string]http.HandlerFunc{"DELETE /api/v1/ingredients/7": respond(http.StatusNoContent, "")}}
+
+		code, _, _ := run(t, api, "", "pantry", "rm", "7")
+
+		assert.Equal(t, 0, code)
+		assert.Len(t, api.requests, 1)
+	})
+}
+
+func TestRecipe(t *testing.T) {
+	t.Run("it should add a recipe read from YAML", func(t *testing.T) {
+		api := &fakeAPI{routes: map[string]http.HandlerFunc{"POST /api/v1/recipes": respond(http.StatusCreated, "")}}
+		recipe := "name: Arroz de forno\nvisibility: household\ningredients:\n  - name: arroz\n    quantity: 2\n    measureType: kg\n"
+
+		code, _, _ := run(t, api, recipe, "recipe", "add", "-f", "-")
+
+		assert.Equal(t, 0, code)
+		require.Len(t, api.bodies, 1)
+		assert.JSONEq(t, `{"name":"Arroz de forno","visibility":"household","ingredients":[{"name":"arroz","measureType":"kg","quantity":2}]}`, api.bodies[0])
+	})
+
+	t.Run("it should reject unknown fields in the recipe file", func(t *testing.T) {
+		api := &fakeAPI{}
+
+		code, _, stderr := run(t, api, `{"name":"Arroz","ingredients":[{"name":"arroz","quantity":2,"unit":"kg"}]}`, "recipe", "add", "-f", "-")
+
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stderr, "field unit not found")
+		assert.Empty(t, api.requests)
+	})
+
+	t.Run("it should require a file", func(t *testing.T) {
+		code, _, stderr := run(t, &fakeAPI{}, "", "recipe", "add")
+
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr, "Usage: qqtem recipe add -f <file>")
+		assert.NotContains(t, stderr, "qqk_secret")
+	})
+}
+
+func TestRecommend(t *testing.T) {
+	t.Run("it should keep the top recommendations", func(t *testing.T) {
+		api := &fakeAPI{routes: map[string]http.HandlerFunc{
+			"GET /api/v1/recommendations": respond(http.StatusOK, `[
+				{"recommendation":1,"recipe":{"id":4,"name":"Arroz","ingredients":[{"id":null,"name":"arroz","measureType":"kg","quantity":1}],"visibility":"private"}},
+				{"recommendation":2,"recipe":{"id":5,"name":"Feijoada","ingredients":[],"visibility":"private"}}
+			]`),
+		}}
+
+		code, stdout, _ := run(t, api, "", "recommend", "-top", "1", "-o", "json")
+
+		assert.Equal(t, 0, code)
+		var recommendations []dto.Recommendation
+		require.NoError(t, json.Unmarshal([]byte(stdout), &recommendations))
+		require.Len(t, recommendations, 1)
+		assert.Equal(t, "Arroz", recommendations[0].Recipe.Name)
+	})
+}
+
+func TestRun(t *testing.T) {
+	t.Run("it should print the usage for an unknown command", func(t *testing.T) {
+		code, _, stderr := run(t, &fakeAPI{}, "", "pantry", "eat")
+
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr, `unknown command "pantry eat"`)
+		assert.Contains(t, stderr, "pantry add <quantity> <unit> <name>")
+	})
+
+	t.Run("it should refuse an unknown output format", func(t *testing.T) {
+		code, _, stderr := run(t, &fakeAPI{}, "", "-o", "yaml", "pantry", "ls")
+
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr, `unknown output format "yaml"`)
+	})
+}
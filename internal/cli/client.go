@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"q-q-tem-pra-hoje/internal/domain"
+	householdController "q-q-tem-pra-hoje/internal/server/controller/household"
+	"q-q-tem-pra-hoje/internal/server/dto"
+	"q-q-tem-pra-hoje/internal/server/problem"
+	"strconv"
+	"strings"
+)
+
+// client calls the HTTP API on behalf of one token and household.
+type client struct {
+	baseURL   string
+	token     string
+	household string
+	http      *http.Client
+}
+
+// apiError is a failure the API reported as a problem document.
+type apiError struct {
+	problem.Problem
+}
+
+func (e *apiError) Error() string {
+	message := e.Detail
+	if message == "" {
+		message = strings.ToLower(e.Title)
+	}
+	for _, field := range e.Errors {
+		message += fmt.Sprintf("\n  %s: %s", field.Field, field.Message)
+	}
+	return message
+}
+
+// do sends body, if any, as JSON and decodes the response into out, if
+// any. It returns the response headers for callers that page.
+func (c *client) do(ctx context.Context, method, path string, query url.Values, body, out any) (http.Header, error) {
+	target := strings.TrimSuffix(c.baseURL, "/") + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if c.household != "" {
+		req.Header.Set(householdController.HouseholdHeader, c.household)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		failure := &apiError{}
+		if err := json.NewDecoder(resp.Body).Decode(&failure.Problem); err != nil || failure.Status == 0 {
+			return nil, fmt.Errorf("%s %s: %s", method, path, resp.Status)
+		}
+		return nil, failure
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return nil, fmt.Errorf("%s %s: decoding response: %w", method, path, err)
+		}
+	}
+	return resp.Header, nil
+}
+
+// list fetches every page of a collection.
+func list[T any](ctx context.Context, c *client, path string, query url.Values) ([]T, error) {
+	query.Set("limit", strconv.Itoa(domain.MaxPageSize))
+	all := []T{}
+	for {
+		var page []T
+		header, err := c.do(ctx, http.MethodGet, path, query, nil, &page)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		cursor := header.Get(dto.NextCursorHeader)
+		if cursor == "" {
+			return all, nil
+		}
+		query.Set("cursor", cursor)
+	}
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"q-q-tem-pra-hoje/internal/server/dto"
+	"strconv"
+	"strings"
+)
+
+func pantryList(ctx context.Context, e *env, args []string) error {
+	fs := e.flags()
+	search := fs.String("q", "", "only list ingredients matching this search")
+	if _, err := e.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	query := url.Values{"sort": {"name"}}
+	if *search != "" {
+		query.Set("q", *search)
+	}
+	ingredients, err := list[dto.Ingredient](ctx, e.client(), "/api/v1/ingredients", query)
+	if err != nil {
+		return err
+	}
+	return e.print(ingredients, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tNAME\tQUANTITY\tUNIT")
+		for _, ing := range ingredients {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", id(ing.ID), ing.Name, ing.Quantity, ing.MeasureType)
+		}
+	})
+}
+
+func pantryAdd(ctx context.Context, e *env, args []string) error {
+	fs := e.flags()
+	args, err := e.parse(fs, args, 3, -1)
+	if err != nil {
+		return err
+	}
+
+	quantity, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("quantity %q must be a whole number", args[0])
+	}
+	input := dto.IngredientInput{Quantity: quantity, MeasureType: args[1], Name: strings.Join(args[2:], " ")}
+	_, err = e.client().do(ctx, http.MethodPost, "/api/v1/ingredients", nil, input, nil)
+	return err
+}
+
+func pantryRemove(ctx context.Context, e *env, args []string) error {
+	fs := e.flags()
+	args, err := e.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	ingredientID, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+	_, err = e.client().do(ctx, http.MethodDelete, fmt.Sprintf("/api/v1/ingredients/%d", ingredientID), nil, nil, nil)
+	return err
+}
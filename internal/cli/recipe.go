@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"q-q-tem-pra-hoje/internal/server/dto"
+
+	"gopkg.in/yaml.v3"
+)
+
+// recipeFile is what recipe add reads. JSON is valid YAML, so recipes
+// exported from the API can be added back as they are.
+type recipeFile struct {
+	Name        string `yaml:"name"`
+	Visibility  string `yaml:"visibility"`
+	Ingredients []struct {
+		Name        string `yaml:"name"`
+		Quantity    int    `yaml:"quantity"`
+		MeasureType string `yaml:"measureType"`
+	} `yaml:"ingredients"`
+}
+
+func recipeList(ctx context.Context, e *env, args []string) error {
+	fs := e.flags()
+	search := fs.String("q", "", "only list recipes matching this search")
+	if _, err := e.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	query := url.Values{"sort": {"name"}}
+	if *search != "" {
+		query.Set("q", *search)
+	}
+	recipes, err := list[dto.Recipe](ctx, e.client(), "/api/v1/recipes", query)
+	if err != nil {
+		return err
+	}
+	return e.print(recipes, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tNAME\tVISIBILITY\tINGREDIENTS")
+		for _, r := range recipes {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", id(r.ID), r.Name, r.Visibility, len(r.Ingredients))
+		}
+	})
+}
+
+func recipeShow(ctx context.Context, e *env, args []string) error {
+	fs := e.flags()
+	args, err := e.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	recipeID, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+	var r dto.Recipe
+	if _, err := e.client().do(ctx, http.MethodGet, fmt.Sprintf("/api/v1/recipes/%d", recipeID), nil, nil, &r); err != nil {
+		return err
+	}
+	return e.print(r, func(w io.Writer) {
+		fmt.Fprintf(w, "ID\t%s\nNAME\t%s\nVISIBILITY\t%s\n\n", id(r.ID), r.Name, r.Visibility)
+		fmt.Fprintln(w, "QUANTITY\tUNIT\tINGREDIENT")
+		for _, ing := range r.Ingredients {
+			fmt.Fprintf(w, "%d\t%s\t%s\n", ing.Quantity, ing.MeasureType, ing.Name)
+		}
+	})
+}
+
+func recipeAdd(ctx context.Context, e *env, args []string) error {
+	fs := e.flags()
+	file := fs.String("f", "", "YAML or JSON file with the recipe, - for stdin")
+	if _, err := e.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	if *file == "" {
+		fs.Usage()
+		return errUsage
+	}
+
+	input, err := e.readRecipe(*file)
+	if err != nil {
+		return err
+	}
+	_, err = e.client().do(ctx, http.MethodPost, "/api/v1/recipes", nil, input, nil)
+	return err
+}
+
+func (e *env) readRecipe(name string) (dto.RecipeInput, error) {
+	in := e.stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return dto.RecipeInput{}, err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var file recipeFile
+	decoder := yaml.NewDecoder(in)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil {
+		if errors.Is(err, io.EOF) {
+			return dto.RecipeInput{}, fmt.Errorf("%s: no recipe found", name)
+		}
+		return dto.RecipeInput{}, fmt.Errorf("%s: %w", name, err)
+	}
+
+	input := dto.RecipeInput{Name: file.Name, Visibility: file.Visibility, Ingredients: []dto.IngredientInput{}}
+	for _, ing := range file.Ingredients {
+		input.Ingredients = append(input.Ingredients, dto.IngredientInput{Name: ing.Name, Quantity: ing.Quantity, MeasureType: ing.MeasureType})
+	}
+	return input, nil
+}
+
+func recipeRemove(ctx context.Context, e *env, args []string) error {
+	fs := e.flags()
+	args, err := e.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	recipeID, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+	_, err = e.client().do(ctx, http.MethodDelete, fmt.Sprintf("/api/v1/recipes/%d", recipeID), nil, nil, nil)
+	return err
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"q-q-tem-pra-hoje/internal/server/dto"
+	"strings"
+)
+
+func recommend(ctx context.Context, e *env, args []string) error {
+	fs := e.flags()
+	top := fs.Int("top", 0, "only show the n best recipes; 0 shows all")
+	if _, err := e.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	if *top < 0 {
+		return fmt.Errorf("top must not be negative")
+	}
+
+	recommendations := []dto.Recommendation{}
+	if _, err := e.client().do(ctx, http.MethodGet, "/api/v1/recommendations", nil, nil, &recommendations); err != nil {
+		return err
+	}
+	if *top > 0 && len(recommendations) > *top {
+		recommendations = recommendations[:*top]
+	}
+	return e.print(recommendations, func(w io.Writer) {
+		fmt.Fprintln(w, "RANK\tID\tRECIPE\tINGREDIENTS")
+		for _, rec := range recommendations {
+			names := make([]string, len(rec.Recipe.Ingredients))
+			for i, ing := range rec.Recipe.Ingredients {
+				names[i] = ing.Name
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", rec.Recommendation, id(rec.Recipe.ID), rec.Recipe.Name, strings.Join(names, ", "))
+		}
+	})
+}
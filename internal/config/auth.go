@@ -0,0 +1,20 @@
+package config
+
+import "time"
+
+type authConfig struct {
+	SessionTTL    time.Duration
+	InvitationTTL time.Duration
+	// SecureCookies marks the cookies of the HTML pages Secure even on
+	// requests that reach the server over plain HTTP, as they do behind a
+	// proxy that terminates TLS.
+	SecureCookies bool
+}
+
+func LoadAuthConfig() authConfig {
+	return authConfig{
+		SessionTTL:    getDurationEnv("SESSION_TTL", 7*24*time.Hour),
+		InvitationTTL: getDurationEnv("INVITATION_TTL", 72*time.Hour),
+		SecureCookies: getBoolEnv("SECURE_COOKIES", false),
+	}
+}
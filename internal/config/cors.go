@@ -0,0 +1,21 @@
+package config
+
+import "time"
+
+type corsConfig struct {
+	AllowedOrigins   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// LoadCORSConfig reads the origins allowed to call the API from a browser.
+// CORS_ALLOWED_ORIGINS is a comma-separated list such as
+// "https://app.example.com,https://*.example.com"; cross-origin requests are
+// refused when it is empty.
+func LoadCORSConfig() corsConfig {
+	return corsConfig{
+		AllowedOrigins:   getListEnv("CORS_ALLOWED_ORIGINS"),
+		AllowCredentials: getBoolEnv("CORS_ALLOW_CREDENTIALS", false),
+		MaxAge:           getDurationEnv("CORS_MAX_AGE", 10*time.Minute),
+	}
+}
@@ -8,6 +8,13 @@ type databaseConfig struct {
 	DBUser     string
 	DBPassword string
 	DBName     string
+	// MigrateOnStart makes serve apply pending migrations before it reports
+	// ready. Otherwise they are applied with the migrate command.
+	MigrateOnStart bool
+	// Storage is where serve keeps its data: postgres, sqlite to keep it in
+	// the SQLitePath file, or memory to run without a database.
+	Storage    string
+	SQLitePath string
 }
 
 func LoadDatabaseConfig() databaseConfig {
@@ -17,5 +24,9 @@ func LoadDatabaseConfig() databaseConfig {
 		DBUser:     os.Getenv("DB_USER"),
 		DBPassword: os.Getenv("DB_PASSWORD"),
 		DBName:     os.Getenv("DB_NAME"),
+
+		MigrateOnStart: getBoolEnv("MIGRATE_ON_START", false),
+		Storage:        getEnv("STORAGE", "postgres"),
+		SQLitePath:     getEnv("SQLITE_PATH", "q-q-tem-pra-hoje.db"),
 	}
 }
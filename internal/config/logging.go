@@ -0,0 +1,15 @@
+package config
+
+import "os"
+
+type loggingConfig struct {
+	LogLevel  string
+	LogFormat string
+}
+
+func LoadLoggingConfig() loggingConfig {
+	return loggingConfig{
+		LogLevel:  os.Getenv("LOG_LEVEL"),
+		LogFormat: os.Getenv("LOG_FORMAT"),
+	}
+}
@@ -0,0 +1,34 @@
+package config
+
+// rateLimitConfig holds the token bucket of each route group, as requests
+// per second and burst size, per client. A rate of zero disables the limit.
+type rateLimitConfig struct {
+	APIRate              float64
+	APIBurst             int
+	AuthRate             float64
+	AuthBurst            int
+	RecommendationsRate  float64
+	RecommendationsBurst int
+	// ClientIPHeader names a header set by a trusted proxy, such as
+	// X-Forwarded-For, to take the client address from instead of the
+	// connection.
+	ClientIPHeader string
+	MaxBodyBytes   int64
+	// MaxImportBytes caps the body of imports instead, whose documents hold
+	// a whole household.
+	MaxImportBytes int64
+}
+
+func LoadRateLimitConfig() rateLimitConfig {
+	return rateLimitConfig{
+		APIRate:              getFloatEnv("RATE_LIMIT_API_RATE", 10),
+		APIBurst:             getIntEnv("RATE_LIMIT_API_BURST", 40),
+		AuthRate:             getFloatEnv("RATE_LIMIT_AUTH_RATE", 0.2),
+		AuthBurst:            getIntEnv("RATE_LIMIT_AUTH_BURST", 10),
+		RecommendationsRate:  getFloatEnv("RATE_LIMIT_RECOMMENDATIONS_RATE", 0.2),
+		RecommendationsBurst: getIntEnv("RATE_LIMIT_RECOMMENDATIONS_BURST", 5),
+		ClientIPHeader:       getEnv("CLIENT_IP_HEADER", ""),
+		MaxBodyBytes:         int64(getIntEnv("MAX_BODY_BYTES", 1<<20)),
+		MaxImportBytes:       int64(getIntEnv("MAX_IMPORT_BYTES", 32<<20)),
+	}
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type serverConfig struct {
+	Addr               string
+	ShutdownDrainDelay time.Duration
+	ShutdownTimeout    time.Duration
+	HealthCheckTimeout time.Duration
+}
+
+func LoadServerConfig() serverConfig {
+	return serverConfig{
+		Addr:               getEnv("SERVER_ADDR", ":8080"),
+		ShutdownDrainDelay: getDurationEnv("SHUTDOWN_DRAIN_DELAY", 5*time.Second),
+		ShutdownTimeout:    getDurationEnv("SHUTDOWN_TIMEOUT", 15*time.Second),
+		HealthCheckTimeout: getDurationEnv("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+	}
+}
+
+func getEnv(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getDurationEnv(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func getIntEnv(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func getFloatEnv(key string, fallback float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func getBoolEnv(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// getListEnv splits a comma-separated variable, dropping empty items.
+func getListEnv(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+type tracingConfig struct {
+	Exporter    string
+	SampleRatio float64
+	ServiceName string
+}
+
+func LoadTracingConfig() tracingConfig {
+	sampleRatio, err := strconv.ParseFloat(os.Getenv("TRACING_SAMPLE_RATIO"), 64)
+	if err != nil {
+		sampleRatio = 1
+	}
+
+	return tracingConfig{
+		Exporter:    getEnv("TRACING_EXPORTER", "none"),
+		SampleRatio: sampleRatio,
+		ServiceName: getEnv("OTEL_SERVICE_NAME", "q-q-tem-pra-hoje"),
+	}
+}
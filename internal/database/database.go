@@ -1,13 +1,18 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"io/fs"
 	"q-q-tem-pra-hoje/internal/config"
+	"q-q-tem-pra-hoje/migrations"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 	_ "github.com/lib/pq"
 )
 
@@ -25,19 +30,177 @@ func Connect(connStr string) (*sql.DB, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to the database: %v\n", err)
 	}
+	return db, nil
+}
+
+// Migrate applies every pending migration.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	return withMigrator(ctx, db, up)
+}
+
+// MigrateDown rolls back the last steps migrations, or every one of them
+// when steps is 0.
+func MigrateDown(ctx context.Context, db *sql.DB, steps int) error {
+	return withMigrator(ctx, db, func(m *migrate.Migrate) error { return down(m, steps) })
+}
+
+// ForceMigration records version as applied and clean without running
+// anything, to recover after a migration failed halfway and the schema was
+// repaired by hand. A version of -1 records that nothing is applied.
+func ForceMigration(ctx context.Context, db *sql.DB, version int) error {
+	return withMigrator(ctx, db, func(m *migrate.Migrate) error { return force(m, version) })
+}
 
-	driver, err := postgres.WithInstance(db, &postgres.Config{})
+func up(m *migrate.Migrate) error {
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		return fmt.Errorf("failed to run migrations: %v", err)
+	}
+	return nil
+}
+
+func down(m *migrate.Migrate, steps int) error {
+	var err error
+	if steps == 0 {
+		err = m.Down()
+	} else {
+		err = m.Steps(-steps)
+	}
+	if err != nil && err != migrate.ErrNoChange {
+		return fmt.Errorf("failed to roll back migrations: %v", err)
+	}
+	return nil
+}
+
+func force(m *migrate.Migrate, version int) error {
+	if err := m.Force(version); err != nil {
+		return fmt.Errorf("failed to force migration version: %v", err)
+	}
+	return nil
+}
+
+// withMigrator runs fn with a migrator holding a dedicated connection, so
+// closing the migrator does not close db. golang-migrate takes an advisory
+// lock around every change, so concurrent runs wait for each other.
+func withMigrator(ctx context.Context, db *sql.DB, fn func(m *migrate.Migrate) error) error {
+	conn, err := db.Conn(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create migrate driver: %v", err)
+		return fmt.Errorf("failed to get a connection: %v", err)
 	}
 
-	m, err := migrate.NewWithDatabaseInstance("file://migrations", "postgres", driver)
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create migrator: %v", err)
+		conn.Close()
+		return fmt.Errorf("failed to create migrate driver: %v", err)
 	}
 
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		return nil, fmt.Errorf("failed to run migrations: %v", err)
+	src, err := newSource()
+	if err != nil {
+		driver.Close()
+		return err
 	}
-	return db, nil
+
+	m, err := migrate.NewWithInstance("iofs", src, "postgres", driver)
+	if err != nil {
+		src.Close()
+		driver.Close()
+		return fmt.Errorf("failed to create migrator: %v", err)
+	}
+	defer m.Close()
+
+	return fn(m)
+}
+
+// MigrationStatus is where the schema stands against the known migrations.
+type MigrationStatus struct {
+	// Version is the last applied migration; 0 with Applied false means
+	// none is.
+	Version uint
+	Applied bool
+	Dirty   bool
+	// Latest is the newest known migration and Pending how many known
+	// migrations come after Version.
+	Latest  uint
+	Pending int
+}
+
+// CurrentMigrationStatus compares the version recorded in db with the
+// known migrations.
+func CurrentMigrationStatus(ctx context.Context, db *sql.DB) (MigrationStatus, error) {
+	return migrationStatus(ctx, db, MigrationVersion, newSource)
+}
+
+// migrationStatus compares the version readVersion finds in db with the
+// migrations of the set openSource reads.
+func migrationStatus(ctx context.Context, db *sql.DB, readVersion func(ctx context.Context, db *sql.DB) (uint, bool, error), openSource func() (source.Driver, error)) (MigrationStatus, error) {
+	var status MigrationStatus
+	version, dirty, err := readVersion(ctx, db)
+	switch {
+	case err == nil:
+		status.Version, status.Dirty, status.Applied = version, dirty, true
+	case errors.Is(err, ErrNoMigrations):
+	default:
+		return status, err
+	}
+
+	versions, err := knownMigrations(openSource)
+	if err != nil {
+		return status, err
+	}
+	for _, v := range versions {
+		status.Latest = v
+		if !status.Applied || v > status.Version {
+			status.Pending++
+		}
+	}
+	return status, nil
+}
+
+// knownMigrations lists the versions of the migrations of the set
+// openSource reads, in order.
+func knownMigrations(openSource func() (source.Driver, error)) ([]uint, error) {
+	src, err := openSource()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	var versions []uint
+	version, err := src.First()
+	for err == nil {
+		versions = append(versions, version)
+		version, err = src.Next(version)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to list migrations: %v", err)
+	}
+	return versions, nil
+}
+
+// newSource reads the migrations embedded into the binary.
+func newSource() (source.Driver, error) {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open migrations: %v", err)
+	}
+	return src, nil
+}
+
+var ErrNoMigrations = errors.New("no migrations have been applied")
+
+// MigrationVersion reads the version golang-migrate recorded in its
+// bookkeeping table without taking the migration lock. The Postgres and
+// SQLite drivers name the table alike.
+func MigrationVersion(ctx context.Context, db *sql.DB) (uint, bool, error) {
+	var version int64
+	var dirty bool
+
+	query := fmt.Sprintf("SELECT version, dirty FROM %s LIMIT 1", postgres.DefaultMigrationsTable)
+	err := db.QueryRowContext(ctx, query).Scan(&version, &dirty)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, false, ErrNoMigrations
+		}
+		return 0, false, fmt.Errorf("failed to read migration version: %v", err)
+	}
+	return uint(version), dirty, nil
 }
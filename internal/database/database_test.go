@@ -0,0 +1,41 @@
+package database
+
+import (
+	"io/fs"
+	"q-q-tem-pra-hoje/migrations"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKnownMigrations(t *testing.T) {
+	t.Run("it should list the embedded migrations in order, without gaps", func(t *testing.T) {
+		versions, err := knownMigrations(newSource)
+
+		require.NoError(t, err)
+		require.NotEmpty(t, versions)
+		for i, version := range versions {
+			assert.Equal(t, uint(i+1), version)
+		}
+	})
+
+	t.Run("it should list the embedded SQLite migrations in order, without gaps", func(t *testing.T) {
+		versions, err := knownMigrations(newSQLiteSource)
+
+		require.NoError(t, err)
+		require.NotEmpty(t, versions)
+		for i, version := range versions {
+			assert.Equal(t, uint(i+1), version)
+		}
+	})
+
+	t.Run("it should embed a down migration for every up migration", func(t *testing.T) {
+		ups, err := fs.Glob(migrations.FS, "*.up.sql")
+		require.NoError(t, err)
+		downs, err := fs.Glob(migrations.FS, "*.down.sql")
+		require.NoError(t, err)
+
+		assert.Len(t, downs, len(ups))
+	})
+}
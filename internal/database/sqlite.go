@@ -0,0 +1,127 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"q-q-tem-pra-hoje/migrations"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// sqliteDSN enables foreign keys on every connection, lets writers wait for
+// each other instead of failing with SQLITE_BUSY, and takes the write lock
+// when a transaction begins so two of them cannot deadlock upgrading theirs.
+func sqliteDSN(path string) string {
+	query := url.Values{}
+	query.Add("_pragma", "foreign_keys(1)")
+	query.Add("_pragma", "busy_timeout(5000)")
+	query.Add("_pragma", "journal_mode(WAL)")
+	query.Set("_txlock", "immediate")
+	return "file:" + path + "?" + query.Encode()
+}
+
+// OpenSQLite opens the SQLite database file at path, creating it if needed,
+// and applies the pending migrations of the SQLite set. A single file
+// serves a single server, so there is no separate migrate step to wait for.
+func OpenSQLite(ctx context.Context, path string) (*sql.DB, error) {
+	if err := MigrateSQLite(ctx, path); err != nil {
+		return nil, err
+	}
+	return ConnectSQLite(ctx, path)
+}
+
+// ConnectSQLite opens the SQLite database file at path, creating it if
+// needed, and leaves its schema as it is.
+func ConnectSQLite(ctx context.Context, path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", sqliteDSN(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the SQLite database: %v", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open the SQLite database: %v", err)
+	}
+	return db, nil
+}
+
+// MigrateSQLite applies every pending migration of the SQLite set to the
+// database file at path.
+func MigrateSQLite(ctx context.Context, path string) error {
+	return withSQLiteMigrator(path, up)
+}
+
+// MigrateSQLiteDown is MigrateDown for the database file at path.
+func MigrateSQLiteDown(ctx context.Context, path string, steps int) error {
+	return withSQLiteMigrator(path, func(m *migrate.Migrate) error { return down(m, steps) })
+}
+
+// ForceSQLiteMigration is ForceMigration for the database file at path.
+func ForceSQLiteMigration(ctx context.Context, path string, version int) error {
+	return withSQLiteMigrator(path, func(m *migrate.Migrate) error { return force(m, version) })
+}
+
+// CurrentSQLiteMigrationStatus compares the version recorded in db with the
+// migrations of the SQLite set.
+func CurrentSQLiteMigrationStatus(ctx context.Context, db *sql.DB) (MigrationStatus, error) {
+	return migrationStatus(ctx, db, sqliteMigrationVersion, newSQLiteSource)
+}
+
+// sqliteMigrationVersion is MigrationVersion for a file that may be new,
+// which lacks even the bookkeeping table until it is first migrated.
+func sqliteMigrationVersion(ctx context.Context, db *sql.DB) (uint, bool, error) {
+	var tables int
+	query := "SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?"
+	if err := db.QueryRowContext(ctx, query, sqlite.DefaultMigrationsTable).Scan(&tables); err != nil {
+		return 0, false, fmt.Errorf("failed to read migration version: %v", err)
+	}
+	if tables == 0 {
+		return 0, false, ErrNoMigrations
+	}
+	return MigrationVersion(ctx, db)
+}
+
+// withSQLiteMigrator runs fn with a migrator over the database file at
+// path. The migrator closes the database it is given, so it gets a handle
+// of its own.
+func withSQLiteMigrator(path string, fn func(m *migrate.Migrate) error) error {
+	db, err := sql.Open("sqlite", sqliteDSN(path))
+	if err != nil {
+		return fmt.Errorf("failed to open the SQLite database: %v", err)
+	}
+
+	driver, err := sqlite.WithInstance(db, &sqlite.Config{})
+	if err != nil {
+		db.Close()
+		return fmt.Errorf("failed to create migrate driver: %v", err)
+	}
+
+	src, err := newSQLiteSource()
+	if err != nil {
+		driver.Close()
+		return err
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, "sqlite", driver)
+	if err != nil {
+		src.Close()
+		driver.Close()
+		return fmt.Errorf("failed to create migrator: %v", err)
+	}
+	defer m.Close()
+
+	return fn(m)
+}
+
+// newSQLiteSource reads the SQLite migrations embedded into the binary.
+func newSQLiteSource() (source.Driver, error) {
+	src, err := iofs.New(migrations.SQLiteFS, "sqlite")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open migrations: %v", err)
+	}
+	return src, nil
+}
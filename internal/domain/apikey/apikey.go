@@ -0,0 +1,82 @@
+package apikey
+
+import (
+	"fmt"
+	"q-q-tem-pra-hoje/internal/domain"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Scope is a permission granted to an API key. Sessions are granted every
+// scope.
+type Scope string
+
+const (
+	ScopePantryRead          Scope = "pantry:read"
+	ScopePantryWrite         Scope = "pantry:write"
+	ScopeRecipesRead         Scope = "recipes:read"
+	ScopeRecipesWrite        Scope = "recipes:write"
+	ScopeRecommendationsRead Scope = "recommendations:read"
+)
+
+// Scopes lists every scope a key can be granted.
+var Scopes = []Scope{ScopePantryRead, ScopePantryWrite, ScopeRecipesRead, ScopeRecipesWrite, ScopeRecommendationsRead}
+
+func (s Scope) Valid() bool {
+	return slices.Contains(Scopes, s)
+}
+
+// TokenPrefix starts every API key, telling them apart from session tokens.
+const TokenPrefix = "qqk_"
+
+// IsKey reports whether a bearer token is an API key.
+func IsKey(token string) bool {
+	return strings.HasPrefix(token, TokenPrefix)
+}
+
+// MaxNameLength caps key names.
+const MaxNameLength = 100
+
+// Key lets a script act as UserId within HouseholdId, limited to Scopes.
+// Only the hash of the key is stored; Prefix is its first characters, kept
+// so people can tell their keys apart.
+type Key struct {
+	Id          int
+	UserId      int
+	HouseholdId int
+	Name        string
+	Prefix      string
+	Hash        string
+	Scopes      []Scope
+	CreatedAt   time.Time
+	LastUsedAt  *time.Time
+}
+
+func (k Key) Validate() error {
+	err := domain.NewValidation()
+	if strings.TrimSpace(k.Name) == "" {
+		err.Add("name", "key name cannot be empty")
+	} else if len(k.Name) > MaxNameLength {
+		err.Add("name", "key name must have at most 100 characters")
+	}
+	if len(k.Scopes) == 0 {
+		err.Add("scopes", "key must have at least one scope")
+	}
+	for i, scope := range k.Scopes {
+		if !scope.Valid() {
+			err.Add(fmt.Sprintf("scopes[%d]", i), fmt.Sprintf("unknown scope %q", scope))
+		}
+	}
+	return err.OrNil()
+}
+
+// Allows reports whether the key was granted every one of scopes.
+func (k Key) Allows(scopes ...Scope) bool {
+	for _, scope := range scopes {
+		if !slices.Contains(k.Scopes, scope) {
+			return false
+		}
+	}
+	return true
+}
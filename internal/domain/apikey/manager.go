@@ -0,0 +1,17 @@
+package apikey
+
+import (
+	"context"
+	"time"
+)
+
+type APIKeyManager interface {
+	AddKey(ctx context.Context, key Key) (Key, error)
+	// FindKeys lists the keys of a user, oldest first.
+	FindKeys(ctx context.Context, userID int) ([]Key, error)
+	FindKeyByHash(ctx context.Context, hash string) (Key, error)
+	// ReplaceHash swaps the secret of a key of userID, keeping everything else.
+	ReplaceHash(ctx context.Context, id int, userID int, prefix string, hash string) (Key, error)
+	DeleteKey(ctx context.Context, id int, userID int) error
+	TouchKey(ctx context.Context, id int, usedAt time.Time) error
+}
@@ -0,0 +1,13 @@
+package apikey
+
+import "context"
+
+type APIKeyProvider interface {
+	// Create issues a key for the user and household ctx acts for. The token
+	// is only ever returned here and by Rotate.
+	Create(ctx context.Context, key Key) (created Key, token string, err error)
+	FindKeys(ctx context.Context) ([]Key, error)
+	Rotate(ctx context.Context, id int) (rotated Key, token string, err error)
+	Revoke(ctx context.Context, id int) error
+	Authenticate(ctx context.Context, token string) (Key, error)
+}
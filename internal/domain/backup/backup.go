@@ -0,0 +1,96 @@
+package backup
+
+import (
+	"fmt"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/ingredient"
+	"q-q-tem-pra-hoje/internal/domain/recipe"
+	"time"
+)
+
+// Version is the version of the documents Export produces. Import rejects
+// any other, so the format can change without old files being misread.
+const Version = 1
+
+// Document is a household's pantry and the recipes its exporter wrote,
+// without ids, so it can be loaded into any instance.
+type Document struct {
+	Version    int
+	ExportedAt time.Time
+	// Household is the name of the household the pantry came from.
+	Household   string
+	Ingredients []ingredient.Ingredient
+	Recipes     []recipe.Recipe
+}
+
+// Validate reports every invalid field, pointing at the offending
+// ingredient or recipe. Names must be unique within each list, since
+// imports match items by name.
+func (d *Document) Validate() error {
+	err := domain.NewValidation()
+	if d.Version != Version {
+		err.Add("version", fmt.Sprintf("unsupported version %d, want %d", d.Version, Version))
+	}
+
+	ingredients := map[string]bool{}
+	for i, ing := range d.Ingredients {
+		path := fmt.Sprintf("ingredients[%d].", i)
+		err.Fields = append(err.Fields, ing.ValidateAt(path)...)
+		if ingredients[ing.Name] {
+			err.Add(path+"name", fmt.Sprintf("ingredient %q appears more than once", ing.Name))
+		}
+		ingredients[ing.Name] = true
+	}
+
+	recipes := map[string]bool{}
+	for i, r := range d.Recipes {
+		path := fmt.Sprintf("recipes[%d].", i)
+		if verr, ok := r.Validate().(*domain.ValidationError); ok {
+			for _, field := range verr.Fields {
+				err.Add(path+field.Field, field.Message)
+			}
+		}
+		if recipes[r.Name] {
+			err.Add(path+"name", fmt.Sprintf("recipe %q appears more than once", r.Name))
+		}
+		recipes[r.Name] = true
+	}
+	return err.OrNil()
+}
+
+// Mode decides what an import does with what the document does not hold.
+type Mode string
+
+const (
+	// ModeMerge adds and updates the items of the document, leaving the
+	// others alone.
+	ModeMerge Mode = "merge"
+	// ModeReplace also deletes the pantry items and the importer's own
+	// recipes the document does not hold.
+	ModeReplace Mode = "replace"
+)
+
+func (m Mode) Valid() bool {
+	return m == ModeMerge || m == ModeReplace
+}
+
+type ImportOptions struct {
+	Mode Mode
+	// DryRun reports what the import would do without keeping any of it.
+	DryRun bool
+}
+
+// Counts tells what an import did, or would do, to one kind of item.
+type Counts struct {
+	Added     int
+	Updated   int
+	Unchanged int
+	Deleted   int
+}
+
+type Report struct {
+	Mode        Mode
+	DryRun      bool
+	Ingredients Counts
+	Recipes     Counts
+}
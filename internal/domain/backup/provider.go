@@ -0,0 +1,8 @@
+package backup
+
+import "context"
+
+type BackupProvider interface {
+	Export(ctx context.Context) (Document, error)
+	Import(ctx context.Context, document Document, opts ImportOptions) (Report, error)
+}
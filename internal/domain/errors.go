@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NotFoundError reports that the requested resource does not exist.
+type NotFoundError struct {
+	Resource string
+	ID       any
+}
+
+func NewNotFound(resource string, id any) *NotFoundError {
+	return &NotFoundError{Resource: resource, ID: id}
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s %v not found", e.Resource, e.ID)
+}
+
+// ConflictError reports that the operation clashes with the current state,
+// such as a duplicated unique name.
+type ConflictError struct {
+	Resource string
+	Message  string
+}
+
+func NewConflict(resource string, message string) *ConflictError {
+	return &ConflictError{Resource: resource, Message: message}
+}
+
+func (e *ConflictError) Error() string {
+	return e.Message
+}
+
+// UnauthenticatedError reports missing, expired or wrong credentials.
+type UnauthenticatedError struct {
+	Message string
+}
+
+func NewUnauthenticated(message string) *UnauthenticatedError {
+	return &UnauthenticatedError{Message: message}
+}
+
+func (e *UnauthenticatedError) Error() string {
+	return e.Message
+}
+
+// ForbiddenError reports that the user is known but may not do this, such as
+// a household viewer changing the pantry.
+type ForbiddenError struct {
+	Message string
+}
+
+func NewForbidden(message string) *ForbiddenError {
+	return &ForbiddenError{Message: message}
+}
+
+func (e *ForbiddenError) Error() string {
+	return e.Message
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every invalid field of an input.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func NewValidation(fields ...FieldError) *ValidationError {
+	return &ValidationError{Fields: fields}
+}
+
+func (e *ValidationError) Add(field string, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+// OrNil returns e as an error only when it holds at least one field, so
+// validators can build it up unconditionally.
+func (e *ValidationError) OrNil() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Message
+	}
+	return strings.Join(messages, "; ")
+}
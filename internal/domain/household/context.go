@@ -0,0 +1,37 @@
+package household
+
+import (
+	"context"
+	"q-q-tem-pra-hoje/internal/domain"
+)
+
+type contextKey struct{}
+
+var membershipKey = contextKey{}
+
+// WithMembership returns a copy of ctx acting within the household of m.
+// Repositories scope the pantry to that household and also show the recipes
+// shared with it.
+func WithMembership(ctx context.Context, m Membership) context.Context {
+	return context.WithValue(ctx, membershipKey, m)
+}
+
+// MembershipFromContext returns the membership ctx acts with. Without one,
+// repositories run in system scope like they do without a user.
+func MembershipFromContext(ctx context.Context) (Membership, bool) {
+	if ctx == nil {
+		return Membership{}, false
+	}
+	m, ok := ctx.Value(membershipKey).(Membership)
+	return m, ok
+}
+
+// RequireEditor fails with a ForbiddenError when ctx acts as a viewer of its
+// household. System scope may always edit.
+func RequireEditor(ctx context.Context) error {
+	m, ok := MembershipFromContext(ctx)
+	if ok && !m.Role.CanEdit() {
+		return domain.NewForbidden("viewers cannot change the household")
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package household
+
+import (
+	"q-q-tem-pra-hoje/internal/domain"
+	"strings"
+	"time"
+)
+
+// Role is what a member may do in a household. Owners manage members and
+// invitations, members change the pantry and viewers only read.
+type Role string
+
+const (
+	RoleOwner  Role = "owner"
+	RoleMember Role = "member"
+	RoleViewer Role = "viewer"
+)
+
+func (r Role) Valid() bool {
+	return r == RoleOwner || r == RoleMember || r == RoleViewer
+}
+
+// CanEdit reports whether the role may change the household pantry and share
+// recipes with the household.
+func (r Role) CanEdit() bool {
+	return r == RoleOwner || r == RoleMember
+}
+
+// PersonalName names the household created for a user who belongs to none.
+const PersonalName = "Personal"
+
+// MaxNameLength caps household names.
+const MaxNameLength = 100
+
+// Household owns a pantry and the recipes its members share with it.
+type Household struct {
+	Id   int
+	Name string
+}
+
+// Normalize trims the name.
+func (h Household) Normalize() Household {
+	h.Name = strings.TrimSpace(h.Name)
+	return h
+}
+
+func (h Household) Validate() error {
+	err := domain.NewValidation()
+	if h.Name == "" {
+		err.Add("name", "household name cannot be empty")
+	} else if len(h.Name) > MaxNameLength {
+		err.Add("name", "household name must have at most 100 characters")
+	}
+	return err.OrNil()
+}
+
+// Membership is the place of a user in a household.
+type Membership struct {
+	Household Household
+	UserId    int
+	Role      Role
+}
+
+// Member is a membership seen from the household.
+type Member struct {
+	UserId int
+	Email  string
+	Role   Role
+}
+
+// Invitation lets whoever holds its code join HouseholdId as Role until
+// ExpiresAt. Only the hash of the code is stored, and joining consumes it.
+type Invitation struct {
+	CodeHash    string
+	HouseholdId int
+	Role        Role
+	ExpiresAt   time.Time
+}
@@ -0,0 +1,28 @@
+package household
+
+import (
+	"context"
+	"time"
+)
+
+type HouseholdManager interface {
+	// AddHousehold creates a household with ownerID as its first owner.
+	AddHousehold(ctx context.Context, household Household, ownerID int) (Household, error)
+	// EnsureMembership returns the oldest membership of userID, adding a
+	// household named name that they own when they belong to none. Concurrent
+	// calls for one user add a single household.
+	EnsureMembership(ctx context.Context, userID int, name string) (m Membership, added bool, err error)
+	// FindMemberships lists the households of a user, oldest membership first.
+	FindMemberships(ctx context.Context, userID int) ([]Membership, error)
+	FindMembership(ctx context.Context, householdID int, userID int) (Membership, error)
+	FindMembers(ctx context.Context, householdID int) ([]Member, error)
+	AddMember(ctx context.Context, householdID int, userID int, role Role) error
+	// RemoveMember reports a conflict instead of removing the last owner of
+	// a household, checking and removing at once.
+	RemoveMember(ctx context.Context, householdID int, userID int) error
+	AddInvitation(ctx context.Context, invitation Invitation) error
+	// AcceptInvitation deletes an invitation so it is used once and adds
+	// userID to its household with its role, both or neither. Unknown
+	// invitations and those expired by now are not found.
+	AcceptInvitation(ctx context.Context, codeHash string, userID int, now time.Time) (Invitation, error)
+}
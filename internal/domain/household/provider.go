@@ -0,0 +1,18 @@
+package household
+
+import (
+	"context"
+	"time"
+)
+
+type HouseholdProvider interface {
+	// Resolve returns the membership a request of userID acts with: the
+	// household it asks for, or else the user's oldest one.
+	Resolve(ctx context.Context, userID int, householdID *int) (Membership, error)
+	Create(ctx context.Context, household Household) (Membership, error)
+	FindMemberships(ctx context.Context) ([]Membership, error)
+	FindMembers(ctx context.Context, householdID int) ([]Member, error)
+	Invite(ctx context.Context, householdID int, role Role) (code string, expiresAt time.Time, err error)
+	Join(ctx context.Context, code string) (Membership, error)
+	RemoveMember(ctx context.Context, householdID int, userID int) error
+}
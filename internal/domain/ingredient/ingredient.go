@@ -1,5 +1,7 @@
 package ingredient
 
+import "q-q-tem-pra-hoje/internal/domain"
+
 type Ingredient struct {
 	Id          *int
 	Name        string
@@ -8,6 +10,32 @@ type Ingredient struct {
 }
 
 func NewIngredient(id *int, name string, measureType string, quantity int) Ingredient {
-  ingredient := Ingredient{Id: id, Name: name, MeasureType: measureType, Quantity: quantity}
+	ingredient := Ingredient{Id: id, Name: name, MeasureType: measureType, Quantity: quantity}
 	return ingredient
 }
+
+func (i *Ingredient) Validate() error {
+	return i.validate("").OrNil()
+}
+
+// validate reports field errors prefixed with path, so recipes can point at
+// the offending ingredient.
+func (i *Ingredient) validate(path string) *domain.ValidationError {
+	err := domain.NewValidation()
+	if i.Name == "" {
+		err.Add(path+"name", "ingredient name cannot be empty")
+	}
+	if i.MeasureType == "" {
+		err.Add(path+"measureType", "ingredient measure type cannot be empty")
+	}
+	if i.Quantity < 0 {
+		err.Add(path+"quantity", "ingredient quantity cannot be negative")
+	}
+	return err
+}
+
+// ValidateAt validates the ingredient as an element of a larger input,
+// prefixing every field with path (e.g. "ingredients[0].").
+func (i *Ingredient) ValidateAt(path string) []domain.FieldError {
+	return i.validate(path).Fields
+}
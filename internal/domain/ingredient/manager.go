@@ -1,8 +1,16 @@
 package ingredient
 
+import (
+	"context"
+	"q-q-tem-pra-hoje/internal/domain"
+)
+
 type IngredientStorageManager interface {
-	AddIngredient(Ingredient) error
-	FindIngredients() ([]Ingredient, error)
-	Update(Ingredient) error
-	Delete(id uint) error
+	AddIngredient(ctx context.Context, ingredient Ingredient) error
+	FindIngredients(ctx context.Context) ([]Ingredient, error)
+	// CountIngredients counts what FindIngredients would find.
+	CountIngredients(ctx context.Context) (int, error)
+	ListIngredients(ctx context.Context, opts domain.ListOptions) (domain.Page[Ingredient], error)
+	Update(ctx context.Context, ingredient Ingredient) error
+	Delete(ctx context.Context, id uint) error
 }
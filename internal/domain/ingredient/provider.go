@@ -1,8 +1,14 @@
 package ingredient
 
+import (
+	"context"
+	"q-q-tem-pra-hoje/internal/domain"
+)
+
 type IngredientStorageProvider interface {
-	Add(Ingredient) error
-	FindIngredients() ([]Ingredient, error)
-  Update(Ingredient) error
-  Delete (id uint) error
+	Add(ctx context.Context, ingredient Ingredient) error
+	FindIngredients(ctx context.Context) ([]Ingredient, error)
+	ListIngredients(ctx context.Context, opts domain.ListOptions) (domain.Page[Ingredient], error)
+	Update(ctx context.Context, ingredient Ingredient) error
+	Delete(ctx context.Context, id uint) error
 }
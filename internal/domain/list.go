@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type SortField string
+
+const (
+	SortByName     SortField = "name"
+	SortByCreated  SortField = "created"
+	SortByQuantity SortField = "quantity"
+)
+
+// MaxPageSize caps ListOptions.Limit.
+const MaxPageSize = 200
+
+// DefaultPageSize is the limit of list requests that do not set one.
+const DefaultPageSize = 50
+
+// ListOptions narrows and orders a list query. A zero Limit returns every
+// match, which only callers inside the server may ask for; otherwise After
+// resumes from the cursor of a previous page.
+type ListOptions struct {
+	Search     string
+	Sort       SortField
+	Descending bool
+	Limit      int
+	After      *Cursor
+}
+
+// SortOrDefault returns the sort field, falling back to creation order.
+func (o ListOptions) SortOrDefault() SortField {
+	if o.Sort == "" {
+		return SortByCreated
+	}
+	return o.Sort
+}
+
+// Cursor is the position of the last item of a page: its value for the sort
+// field and its id, which breaks ties. Repositories choose the Key format.
+type Cursor struct {
+	Key string `json:"k"`
+	ID  int    `json:"i"`
+}
+
+// Page is one page of a list. Next is nil on the last page.
+type Page[T any] struct {
+	Items []T
+	Next  *Cursor
+}
+
+func (c Cursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func DecodeCursor(s string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, NewValidation(FieldError{Field: "cursor", Message: "cursor is malformed"})
+	}
+	var c Cursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, NewValidation(FieldError{Field: "cursor", Message: "cursor is malformed"})
+	}
+	return &c, nil
+}
+
+// ParseSort reads a sort parameter such as "name" or "-created". The field
+// must be one of allowed.
+func ParseSort(s string, allowed ...SortField) (SortField, bool, error) {
+	if s == "" {
+		return "", false, nil
+	}
+	descending := strings.HasPrefix(s, "-")
+	field := SortField(strings.TrimPrefix(s, "-"))
+	for _, a := range allowed {
+		if field == a {
+			return field, descending, nil
+		}
+	}
+	names := make([]string, len(allowed))
+	for i, a := range allowed {
+		names[i] = string(a)
+	}
+	return "", false, NewValidation(FieldError{Field: "sort", Message: fmt.Sprintf("sort must be one of %s", strings.Join(names, ", "))})
+}
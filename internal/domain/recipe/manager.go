@@ -1,7 +1,20 @@
 package recipe
 
+import (
+	"context"
+	"q-q-tem-pra-hoje/internal/domain"
+)
+
 type RecipeManager interface {
-	AddRecipe(recipe Recipe) error
-  GetAllRecipes() ([]Recipe, error)
-  DeleteRecipe(id uint) error
+	AddRecipe(ctx context.Context, recipe Recipe) error
+	GetAllRecipes(ctx context.Context) ([]Recipe, error)
+	// CountRecipes counts what GetAllRecipes would get.
+	CountRecipes(ctx context.Context) (int, error)
+	ListRecipes(ctx context.Context, opts domain.ListOptions) (domain.Page[Recipe], error)
+	GetRecipe(ctx context.Context, id uint) (Recipe, error)
+	DeleteRecipe(ctx context.Context, id uint) error
+	// UpsertRecipe adds the recipe or, when its owner already has one of the
+	// same name, replaces that one's ingredients and visibility. It reports
+	// whether the recipe was added.
+	UpsertRecipe(ctx context.Context, recipe Recipe) (added bool, err error)
 }
@@ -1,7 +1,14 @@
 package recipe
 
+import (
+	"context"
+	"q-q-tem-pra-hoje/internal/domain"
+)
+
 type RecipeProvider interface {
-	Create(Recipe) error
-	FindRecipes() ([]Recipe, error)
-  Delete(id uint) error
+	Create(ctx context.Context, recipe Recipe) error
+	FindRecipes(ctx context.Context) ([]Recipe, error)
+	ListRecipes(ctx context.Context, opts domain.ListOptions) (domain.Page[Recipe], error)
+	FindRecipe(ctx context.Context, id uint) (Recipe, error)
+	Delete(ctx context.Context, id uint) error
 }
@@ -1,24 +1,60 @@
 package recipe
 
 import (
-	"errors"
+	"fmt"
+	"q-q-tem-pra-hoje/internal/domain"
 	"q-q-tem-pra-hoje/internal/domain/ingredient"
 )
 
+// Visibility decides who besides its author may read a recipe.
+type Visibility string
+
+const (
+	VisibilityPrivate   Visibility = "private"
+	VisibilityHousehold Visibility = "household"
+	VisibilityPublic    Visibility = "public"
+)
+
+func (v Visibility) Valid() bool {
+	return v == VisibilityPrivate || v == VisibilityHousehold || v == VisibilityPublic
+}
+
 type Recipe struct {
 	Id          *int
 	Name        string
 	Ingredients []ingredient.Ingredient
+	// Visibility is left out of the deprecated X-API-Version 1 shape, which
+	// is this struct encoded as is.
+	Visibility Visibility `json:"-"`
+	// UserId is the author, filled in by the repositories; nil for recipes
+	// nobody owns, such as seeded ones. It is ignored when storing, where
+	// the author is whoever the context acts for.
+	UserId *int `json:"-"`
+}
+
+// VisibilityOrDefault returns the visibility, falling back to private.
+func (r *Recipe) VisibilityOrDefault() Visibility {
+	if r.Visibility == "" {
+		return VisibilityPrivate
+	}
+	return r.Visibility
 }
 
 func (r *Recipe) Validate() error {
+	err := domain.NewValidation()
 	if r.Name == "" {
-		return errors.New("recipe name cannot be empty")
+		err.Add("name", "recipe name cannot be empty")
 	}
 	if len(r.Ingredients) == 0 {
-		return errors.New("recipe must have at least one ingredient")
+		err.Add("ingredients", "recipe must have at least one ingredient")
+	}
+	if r.Visibility != "" && !r.Visibility.Valid() {
+		err.Add("visibility", "visibility must be one of private, household, public")
+	}
+	for i, ing := range r.Ingredients {
+		err.Fields = append(err.Fields, ing.ValidateAt(fmt.Sprintf("ingredients[%d].", i))...)
 	}
-	return nil
+	return err.OrNil()
 }
 
 func NewRecipe(id int, name string, ingredients []ingredient.Ingredient) (Recipe, error) {
@@ -1,8 +1,10 @@
 package recommendation
 
-import "q-q-tem-pra-hoje/internal/domain/ingredient"
-
-type RecommendationProvider interface{
-	GetRecommendations(ingredients *[]ingredient.Ingredient) ([]Recommendation, error)
+import (
+	"context"
+	"q-q-tem-pra-hoje/internal/domain/ingredient"
+)
 
+type RecommendationProvider interface {
+	GetRecommendations(ctx context.Context, ingredients *[]ingredient.Ingredient) ([]Recommendation, error)
 }
@@ -0,0 +1,7 @@
+package search
+
+import "context"
+
+type SearchProvider interface {
+	Search(ctx context.Context, query string, limit int) ([]Result, error)
+}
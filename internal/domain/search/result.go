@@ -0,0 +1,17 @@
+package search
+
+type Kind string
+
+const (
+	KindRecipe     Kind = "recipe"
+	KindIngredient Kind = "ingredient"
+)
+
+// Result is a recipe or pantry ingredient whose name matched a search. Rank
+// only orders results of the same search; higher is more relevant.
+type Result struct {
+	Kind Kind
+	Id   int
+	Name string
+	Rank float64
+}
@@ -0,0 +1,12 @@
+package domain
+
+import "context"
+
+// Transactor makes several repository calls succeed or fail together.
+type Transactor interface {
+	// Transaction calls fn with a context under which the repositories of
+	// the same storage work within one transaction. It is committed when fn
+	// returns nil and rolled back otherwise, returning fn's error. Nested
+	// calls join the transaction already under way.
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,24 @@
+package user
+
+import "context"
+
+type contextKey struct{}
+
+var userIDKey = contextKey{}
+
+// WithUserID returns a copy of ctx acting on behalf of the given user.
+// Repositories only read and write that user's rows.
+func WithUserID(ctx context.Context, id int) context.Context {
+	return context.WithValue(ctx, userIDKey, id)
+}
+
+// UserIDFromContext returns the user ctx acts for. Without one, repositories
+// run in system scope and see every row; the HTTP layer never lets such a
+// context through to them.
+func UserIDFromContext(ctx context.Context) (int, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	id, ok := ctx.Value(userIDKey).(int)
+	return id, ok
+}
@@ -0,0 +1,15 @@
+package user
+
+import "context"
+
+type UserManager interface {
+	AddUser(ctx context.Context, user User) (User, error)
+	FindUser(ctx context.Context, id int) (User, error)
+	FindUserByEmail(ctx context.Context, email string) (User, error)
+}
+
+type SessionManager interface {
+	AddSession(ctx context.Context, session Session) error
+	FindSession(ctx context.Context, tokenHash string) (Session, error)
+	DeleteSession(ctx context.Context, tokenHash string) error
+}
@@ -0,0 +1,14 @@
+package user
+
+import (
+	"context"
+	"time"
+)
+
+type AuthProvider interface {
+	Register(ctx context.Context, credentials Credentials) (User, error)
+	Login(ctx context.Context, credentials Credentials) (token string, expiresAt time.Time, err error)
+	Logout(ctx context.Context, token string) error
+	Authenticate(ctx context.Context, token string) (userID int, err error)
+	CurrentUser(ctx context.Context) (User, error)
+}
@@ -0,0 +1,55 @@
+package user
+
+import (
+	"q-q-tem-pra-hoje/internal/domain"
+	"strings"
+	"time"
+)
+
+// MinPasswordLength is the shortest password accepted at registration.
+const MinPasswordLength = 8
+
+// MaxPasswordLength is the longest password accepted at registration, in
+// bytes: bcrypt refuses to hash longer ones.
+const MaxPasswordLength = 72
+
+type User struct {
+	Id           int
+	Email        string
+	PasswordHash string
+}
+
+// Credentials is what a person types to register or log in.
+type Credentials struct {
+	Email    string
+	Password string
+}
+
+// Normalize trims the email and lowercases it so lookups are case-insensitive.
+func (c Credentials) Normalize() Credentials {
+	c.Email = strings.ToLower(strings.TrimSpace(c.Email))
+	return c
+}
+
+func (c Credentials) Validate() error {
+	err := domain.NewValidation()
+	if c.Email == "" {
+		err.Add("email", "email cannot be empty")
+	} else if at := strings.Index(c.Email, "@"); at < 1 || at == len(c.Email)-1 {
+		err.Add("email", "email must be a valid address")
+	}
+	if len(c.Password) < MinPasswordLength {
+		err.Add("password", "password must have at least 8 characters")
+	} else if len(c.Password) > MaxPasswordLength {
+		err.Add("password", "password must have at most 72 bytes")
+	}
+	return err.OrNil()
+}
+
+// Session grants the bearer of a token access as UserId until ExpiresAt.
+// Only the hash of the token is stored.
+type Session struct {
+	TokenHash string
+	UserId    int
+	ExpiresAt time.Time
+}
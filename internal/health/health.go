@@ -0,0 +1,182 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"q-q-tem-pra-hoje/internal/database"
+	"sync/atomic"
+	"time"
+)
+
+type State int32
+
+const (
+	Starting State = iota
+	Ready
+	Draining
+)
+
+func (s State) String() string {
+	switch s {
+	case Ready:
+		return "ready"
+	case Draining:
+		return "draining"
+	default:
+		return "starting"
+	}
+}
+
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+// Check is a single readiness dependency. Run returns optional details that
+// are reported alongside the check status.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) (map[string]any, error)
+}
+
+type CheckResult struct {
+	Status   string         `json:"status"`
+	Duration string         `json:"duration"`
+	Error    string         `json:"error,omitempty"`
+	Details  map[string]any `json:"details,omitempty"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	State  string                 `json:"state"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+type Checker struct {
+	checks  []Check
+	timeout time.Duration
+	state   atomic.Int32
+}
+
+// NewChecker returns a checker in the Starting state; readiness fails until
+// SetState(Ready) is called.
+func NewChecker(timeout time.Duration, checks ...Check) *Checker {
+	return &Checker{checks: checks, timeout: timeout}
+}
+
+func (c *Checker) SetState(state State) {
+	c.state.Store(int32(state))
+}
+
+func (c *Checker) State() State {
+	return State(c.state.Load())
+}
+
+// Liveness only reports that the process is serving requests. It does not
+// look at dependencies, so a database outage never restarts the container.
+func (c *Checker) Liveness(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, Report{Status: StatusOK, State: c.State().String()})
+}
+
+// Readiness runs every check concurrently within the configured timeout and
+// fails while the server is starting up or draining.
+func (c *Checker) Readiness(w http.ResponseWriter, r *http.Request) {
+	report := c.Check(r.Context())
+
+	code := http.StatusOK
+	if report.Status != StatusOK {
+		code = http.StatusServiceUnavailable
+	}
+	respondWithJSON(w, code, report)
+}
+
+func (c *Checker) Check(ctx context.Context) Report {
+	state := c.State()
+	report := Report{Status: StatusOK, State: state.String(), Checks: make(map[string]CheckResult, len(c.checks))}
+	if state != Ready {
+		report.Status = StatusUnavailable
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	type namedResult struct {
+		name   string
+		result CheckResult
+	}
+	results := make(chan namedResult, len(c.checks))
+
+	for _, check := range c.checks {
+		go func(check Check) {
+			start := time.Now()
+			details, err := check.Run(ctx)
+			result := CheckResult{Status: StatusOK, Duration: time.Since(start).String(), Details: details}
+			if err != nil {
+				result.Status = StatusUnavailable
+				result.Error = err.Error()
+			}
+			results <- namedResult{check.Name, result}
+		}(check)
+	}
+
+	for range c.checks {
+		nr := <-results
+		if nr.result.Status != StatusOK {
+			report.Status = StatusUnavailable
+		}
+		report.Checks[nr.name] = nr.result
+	}
+	return report
+}
+
+func DatabaseCheck(db *sql.DB) Check {
+	return Check{
+		Name: "database",
+		Run: func(ctx context.Context) (map[string]any, error) {
+			if err := db.PingContext(ctx); err != nil {
+				return nil, err
+			}
+			stats := db.Stats()
+			return map[string]any{"openConnections": stats.OpenConnections, "inUse": stats.InUse}, nil
+		},
+	}
+}
+
+var ErrDirtyMigration = errors.New("last migration failed and left the schema dirty")
+
+var ErrPendingMigrations = errors.New("migrations are pending")
+
+// MigrationCheck fails until every migration this binary knows is applied,
+// so replicas of a new release take no traffic before the schema is ready.
+func MigrationCheck(db *sql.DB) Check {
+	return Check{
+		Name: "migrations",
+		Run: func(ctx context.Context) (map[string]any, error) {
+			status, err := database.CurrentMigrationStatus(ctx, db)
+			if err != nil {
+				return nil, err
+			}
+			if !status.Applied {
+				return nil, database.ErrNoMigrations
+			}
+			details := map[string]any{"version": status.Version, "dirty": status.Dirty, "pending": status.Pending}
+			if status.Dirty {
+				return details, ErrDirtyMigration
+			}
+			if status.Pending > 0 {
+				return details, ErrPendingMigrations
+			}
+			return details, nil
+		},
+	}
+}
+
+func respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,89 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"q-q-tem-pra-hoje/internal/health"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func okCheck(name string) health.Check {
+	return health.Check{Name: name, Run: func(ctx context.Context) (map[string]any, error) {
+		return map[string]any{"version": 2}, nil
+	}}
+}
+
+func readiness(t *testing.T, checker *health.Checker) (int, health.Report) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	checker.Readiness(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var report health.Report
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("failed to unmarshal report: %v", err)
+	}
+	return w.Code, report
+}
+
+func TestChecker_Liveness(t *testing.T) {
+	t.Run("it should be alive regardless of dependencies", func(t *testing.T) {
+		checker := health.NewChecker(time.Second, health.Check{Name: "database", Run: func(ctx context.Context) (map[string]any, error) {
+			return nil, errors.New("connection refused")
+		}})
+
+		w := httptest.NewRecorder()
+		checker.Liveness(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"status":"ok","state":"starting"}`, w.Body.String())
+	})
+}
+
+func TestChecker_Readiness(t *testing.T) {
+	t.Run("it should be ready when every check passes", func(t *testing.T) {
+		checker := health.NewChecker(time.Second, okCheck("database"), okCheck("migrations"))
+		checker.SetState(health.Ready)
+
+		code, report := readiness(t, checker)
+
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, health.StatusOK, report.Status)
+		assert.Equal(t, health.StatusOK, report.Checks["database"].Status)
+		assert.Equal(t, float64(2), report.Checks["migrations"].Details["version"])
+	})
+
+	t.Run("it should not be ready while starting or draining", func(t *testing.T) {
+		checker := health.NewChecker(time.Second, okCheck("database"))
+
+		code, report := readiness(t, checker)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "starting", report.State)
+
+		checker.SetState(health.Draining)
+		code, report = readiness(t, checker)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "draining", report.State)
+	})
+
+	t.Run("it should report a failing check and respect the timeout", func(t *testing.T) {
+		slow := health.Check{Name: "database", Run: func(ctx context.Context) (map[string]any, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}}
+		checker := health.NewChecker(10*time.Millisecond, slow, okCheck("migrations"))
+		checker.SetState(health.Ready)
+
+		code, report := readiness(t, checker)
+
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, health.StatusUnavailable, report.Checks["database"].Status)
+		assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["database"].Error)
+		assert.Equal(t, health.StatusOK, report.Checks["migrations"].Status)
+	})
+}
@@ -0,0 +1,150 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"q-q-tem-pra-hoje/internal/domain/ingredient"
+	"q-q-tem-pra-hoje/internal/domain/recipe"
+	"q-q-tem-pra-hoje/internal/domain/recommendation"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "qqtem"
+
+// countTimeout bounds the queries issued while a scrape computes the domain
+// gauges so a slow database cannot hang the /metrics endpoint.
+const countTimeout = 2 * time.Second
+
+type Metrics struct {
+	registry                *prometheus.Registry
+	httpRequests            *prometheus.CounterVec
+	httpRequestDuration     *prometheus.HistogramVec
+	recommendationDuration  prometheus.Histogram
+	recommendationsComputed prometheus.Counter
+}
+
+// New creates a registry with the process, Go runtime and sql.DBStats
+// collectors plus the HTTP and recommendation metrics. The pool of db is
+// labelled with dbName, the storage it backs; db may be nil when the server
+// runs without a database. Every handler gets its own registry so
+// tests can build several of them side by side.
+func New(db *sql.DB, dbName string) *Metrics {
+	registry := prometheus.NewRegistry()
+
+	m := &Metrics{
+		registry: registry,
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests handled, by route, method and status.",
+		}, []string{"route", "method", "status"}),
+		httpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency, by route, method and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		recommendationDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "recommendation_scoring_duration_seconds",
+			Help:      "Time spent loading and scoring recipes for a recommendation request.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2, 14),
+		}),
+		recommendationsComputed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "recommendation_recipes_scored_total",
+			Help:      "Number of recipes scored across all recommendation requests.",
+		}),
+	}
+
+	registry.MustRegister(
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewGoCollector(),
+		m.httpRequests,
+		m.httpRequestDuration,
+		m.recommendationDuration,
+		m.recommendationsComputed,
+	)
+	if db != nil {
+		registry.MustRegister(collectors.NewDBStatsCollector(db, dbName))
+	}
+
+	return m
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// RegisterDomainGauges exposes the pantry item and recipe counts of every
+// household. They are counted by the repositories on scrape, so they are
+// never stale and no rows are loaded.
+func (m *Metrics) RegisterDomainGauges(ism ingredient.IngredientStorageManager, rm recipe.RecipeManager) {
+	m.registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "pantry_items",
+			Help:      "Number of distinct ingredients in the pantry.",
+		}, func() float64 {
+			ctx, cancel := context.WithTimeout(context.Background(), countTimeout)
+			defer cancel()
+			count, err := ism.CountIngredients(ctx)
+			if err != nil {
+				return -1
+			}
+			return float64(count)
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "recipes",
+			Help:      "Number of registered recipes.",
+		}, func() float64 {
+			ctx, cancel := context.WithTimeout(context.Background(), countTimeout)
+			defer cancel()
+			count, err := rm.CountRecipes(ctx)
+			if err != nil {
+				return -1
+			}
+			return float64(count)
+		}),
+	)
+}
+
+// ObserveHTTPRequest records one handled request. route should be the
+// ServeMux pattern that matched, which keeps the label cardinality bounded
+// regardless of ids in the path.
+func (m *Metrics) ObserveHTTPRequest(route string, method string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	m.httpRequests.WithLabelValues(route, method, code).Inc()
+	m.httpRequestDuration.WithLabelValues(route, method, code).Observe(duration.Seconds())
+}
+
+// InstrumentRecommendations wraps a RecommendationProvider so that every call,
+// failed ones included, is timed and the number of scored recipes is counted.
+func (m *Metrics) InstrumentRecommendations(rp recommendation.RecommendationProvider) recommendation.RecommendationProvider {
+	return instrumentedRecommendationProvider{rp, m}
+}
+
+type instrumentedRecommendationProvider struct {
+	recommendation.RecommendationProvider
+	metrics *Metrics
+}
+
+func (irp instrumentedRecommendationProvider) GetRecommendations(ctx context.Context, ingredients *[]ingredient.Ingredient) ([]recommendation.Recommendation, error) {
+	start := time.Now()
+	recommendations, err := irp.RecommendationProvider.GetRecommendations(ctx, ingredients)
+	irp.metrics.recommendationDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		return nil, err
+	}
+
+	irp.metrics.recommendationsComputed.Add(float64(len(recommendations)))
+	return recommendations, nil
+}
@@ -0,0 +1,110 @@
+package metrics_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"q-q-tem-pra-hoje/internal/domain/ingredient"
+	"q-q-tem-pra-hoje/internal/domain/recipe"
+	"q-q-tem-pra-hoje/internal/domain/recommendation"
+	"q-q-tem-pra-hoje/internal/metrics"
+	"q-q-tem-pra-hoje/internal/repository/in_memory_repository"
+	ingredientService "q-q-tem-pra-hoje/internal/service/ingredient"
+	recommendationService "q-q-tem-pra-hoje/internal/service/recommendation"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body, err := io.ReadAll(w.Body)
+	if err != nil {
+		t.Fatalf("failed to read metrics: %v", err)
+	}
+	return string(body)
+}
+
+type failingRecommendationProvider struct{}
+
+func (failingRecommendationProvider) GetRecommendations(context.Context, *[]ingredient.Ingredient) ([]recommendation.Recommendation, error) {
+	return nil, errors.New("storage unavailable")
+}
+
+func TestMetrics(t *testing.T) {
+	// sql.Open does not connect, which is enough for the DBStats collector.
+	db, err := sql.Open("postgres", "host=localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	t.Run("it should expose http request metrics by route and status", func(t *testing.T) {
+		m := metrics.New(db, "postgres")
+
+		m.ObserveHTTPRequest("/ingredient/{id}", http.MethodPatch, http.StatusOK, 20*time.Millisecond)
+
+		body := scrape(t, m)
+
+		assert.Contains(t, body, `qqtem_http_requests_total{method="PATCH",route="/ingredient/{id}",status="200"} 1`)
+		assert.Contains(t, body, `qqtem_http_request_duration_seconds_count{method="PATCH",route="/ingredient/{id}",status="200"} 1`)
+		assert.Contains(t, body, `go_sql_open_connections{db_name="postgres"}`)
+	})
+
+	t.Run("it should label the pool with the storage it backs", func(t *testing.T) {
+		body := scrape(t, metrics.New(db, "sqlite"))
+
+		assert.Contains(t, body, `go_sql_open_connections{db_name="sqlite"}`)
+		assert.NotContains(t, body, `db_name="postgres"`)
+	})
+
+	t.Run("it should expose domain gauges and recommendation timings", func(t *testing.T) {
+		m := metrics.New(db, "postgres")
+
+		ingredientRepository := in_memory_repository.NewIngredientStorageManager()
+		recipeRepository := in_memory_repository.NewRecipeManager([]recipe.Recipe{
+			{Name: "Rice", Ingredients: []ingredient.Ingredient{{Name: "Rice", MeasureType: "mg", Quantity: 500}}},
+			{Name: "Fries", Ingredients: []ingredient.Ingredient{{Name: "Potato", MeasureType: "unit", Quantity: 2}}},
+		})
+		is := ingredientService.NewService(ingredientRepository, discardLogger)
+		res := recommendationService.NewRecommendationService(recipeRepository, discardLogger)
+
+		is.Add(context.Background(), ingredient.Ingredient{Name: "Rice", MeasureType: "mg", Quantity: 500})
+		m.RegisterDomainGauges(ingredientRepository, recipeRepository)
+
+		provider := m.InstrumentRecommendations(res)
+		ingredients, _ := is.FindIngredients(context.Background())
+		_, err := provider.GetRecommendations(context.Background(), &ingredients)
+		assert.NoError(t, err)
+
+		body := scrape(t, m)
+
+		assert.Contains(t, body, "qqtem_pantry_items 1")
+		assert.Contains(t, body, "qqtem_recipes 2")
+		assert.Contains(t, body, "qqtem_recommendation_scoring_duration_seconds_count 1")
+		assert.Contains(t, body, "qqtem_recommendation_recipes_scored_total 2")
+	})
+	t.Run("it should time failed recommendation calls without counting recipes", func(t *testing.T) {
+		m := metrics.New(db, "postgres")
+
+		provider := m.InstrumentRecommendations(failingRecommendationProvider{})
+		_, err := provider.GetRecommendations(context.Background(), &[]ingredient.Ingredient{})
+		assert.Error(t, err)
+
+		body := scrape(t, m)
+
+		assert.Contains(t, body, "qqtem_recommendation_scoring_duration_seconds_count 1")
+		assert.Contains(t, body, "qqtem_recommendation_recipes_scored_total 0")
+	})
+}
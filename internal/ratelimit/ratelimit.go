@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often buckets that have refilled are forgotten, so
+// one-off clients do not accumulate.
+const sweepInterval = time.Minute
+
+// Limit is a token bucket: Burst requests at once, refilled at Rate requests
+// per second.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Result describes a client's bucket after a request was counted.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until the next request would be allowed. It is
+	// zero when the request was allowed.
+	RetryAfter time.Duration
+}
+
+// Limiter keeps one bucket per client key.
+type Limiter struct {
+	limit Limit
+	now   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func New(limit Limit) *Limiter {
+	return newLimiter(limit, time.Now)
+}
+
+func newLimiter(limit Limit, now func() time.Time) *Limiter {
+	return &Limiter{limit: limit, now: now, buckets: map[string]*bucket{}, lastSweep: now()}
+}
+
+// Allow takes a token from the bucket of key, if there is one.
+func (l *Limiter) Allow(key string) Result {
+	now := l.now()
+	burst := float64(l.limit.Burst)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = l.refill(b, now)
+	b.updated = now
+
+	result := Result{Limit: l.limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = l.duration(1 - b.tokens)
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = l.duration(burst - b.tokens)
+	return result
+}
+
+func (l *Limiter) refill(b *bucket, now time.Time) float64 {
+	return min(float64(l.limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*l.limit.Rate)
+}
+
+// duration is how long refilling tokens takes.
+func (l *Limiter) duration(tokens float64) time.Duration {
+	return time.Duration(tokens / l.limit.Rate * float64(time.Second))
+}
+
+// sweep forgets full buckets, which are the same as new ones.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if l.refill(b, now) >= float64(l.limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
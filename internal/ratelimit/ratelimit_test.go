@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func TestLimiter_Allow(t *testing.T) {
+	t.Run("it should allow a burst and then refill at the rate", func(t *testing.T) {
+		c := &clock{t: time.Unix(0, 0)}
+		limiter := newLimiter(Limit{Rate: 2, Burst: 3}, c.now)
+
+		for remaining := 2; remaining >= 0; remaining-- {
+			result := limiter.Allow("client")
+			assert.True(t, result.Allowed)
+			assert.Equal(t, remaining, result.Remaining)
+		}
+
+		denied := limiter.Allow("client")
+		assert.False(t, denied.Allowed)
+		assert.Equal(t, 3, denied.Limit)
+		assert.Equal(t, 500*time.Millisecond, denied.RetryAfter)
+		assert.Equal(t, 1500*time.Millisecond, denied.Reset)
+
+		c.advance(500 * time.Millisecond)
+		assert.True(t, limiter.Allow("client").Allowed)
+		assert.False(t, limiter.Allow("client").Allowed)
+	})
+
+	t.Run("it should keep a bucket per client", func(t *testing.T) {
+		c := &clock{t: time.Unix(0, 0)}
+		limiter := newLimiter(Limit{Rate: 1, Burst: 1}, c.now)
+
+		assert.True(t, limiter.Allow("alice").Allowed)
+		assert.False(t, limiter.Allow("alice").Allowed)
+		assert.True(t, limiter.Allow("bob").Allowed)
+	})
+
+	t.Run("it should not refill past the burst", func(t *testing.T) {
+		c := &clock{t: time.Unix(0, 0)}
+		limiter := newLimiter(Limit{Rate: 1, Burst: 2}, c.now)
+		limiter.Allow("client")
+
+		c.advance(time.Hour)
+
+		assert.Equal(t, 1, limiter.Allow("client").Remaining)
+	})
+
+	t.Run("it should forget clients whose bucket refilled", func(t *testing.T) {
+		c := &clock{t: time.Unix(0, 0)}
+		limiter := newLimiter(Limit{Rate: 1, Burst: 2}, c.now)
+		limiter.Allow("idle")
+
+		c.advance(2 * sweepInterval)
+		limiter.Allow("active")
+
+		assert.NotContains(t, limiter.buckets, "idle")
+		assert.Contains(t, limiter.buckets, "active")
+	})
+}
@@ -0,0 +1,93 @@
+package in_memory_repository
+
+import (
+	"context"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/apikey"
+	"slices"
+	"sync"
+	"time"
+)
+
+type apiKeyManager struct {
+	mu     sync.Mutex
+	Keys   []apikey.Key
+	nextID int
+}
+
+func NewAPIKeyManager() *apiKeyManager {
+	return &apiKeyManager{}
+}
+
+func (km *apiKeyManager) AddKey(ctx context.Context, key apikey.Key) (apikey.Key, error) {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+	km.nextID++
+	key.Id = km.nextID
+	key.CreatedAt = time.Now()
+	km.Keys = append(km.Keys, key)
+	return key, nil
+}
+
+func (km *apiKeyManager) FindKeys(ctx context.Context, userID int) ([]apikey.Key, error) {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+	keys := []apikey.Key{}
+	for _, key := range km.Keys {
+		if key.UserId == userID {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (km *apiKeyManager) FindKeyByHash(ctx context.Context, hash string) (apikey.Key, error) {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+	for _, key := range km.Keys {
+		if key.Hash == hash {
+			return key, nil
+		}
+	}
+	return apikey.Key{}, domain.NewNotFound("api key", "")
+}
+
+func (km *apiKeyManager) ReplaceHash(ctx context.Context, id int, userID int, prefix string, hash string) (apikey.Key, error) {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+	i := km.index(id, userID)
+	if i < 0 {
+		return apikey.Key{}, domain.NewNotFound("api key", id)
+	}
+	km.Keys[i].Prefix = prefix
+	km.Keys[i].Hash = hash
+	return km.Keys[i], nil
+}
+
+func (km *apiKeyManager) DeleteKey(ctx context.Context, id int, userID int) error {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+	i := km.index(id, userID)
+	if i < 0 {
+		return domain.NewNotFound("api key", id)
+	}
+	km.Keys = slices.Delete(km.Keys, i, i+1)
+	return nil
+}
+
+func (km *apiKeyManager) TouchKey(ctx context.Context, id int, usedAt time.Time) error {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+	for i := range km.Keys {
+		if km.Keys[i].Id == id {
+			km.Keys[i].LastUsedAt = &usedAt
+		}
+	}
+	return nil
+}
+
+func (km *apiKeyManager) index(id int, userID int) int {
+	return slices.IndexFunc(km.Keys, func(key apikey.Key) bool {
+		return key.Id == id && key.UserId == userID
+	})
+}
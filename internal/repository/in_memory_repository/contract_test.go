@@ -0,0 +1,51 @@
+package in_memory_repository_test
+
+import (
+	"q-q-tem-pra-hoje/internal/repository/in_memory_repository"
+	"q-q-tem-pra-hoje/internal/testutil"
+	"testing"
+)
+
+func newBackend(t *testing.T) testutil.Backend {
+	users := in_memory_repository.NewUserManager()
+	ism := in_memory_repository.NewIngredientStorageManager()
+	rm := in_memory_repository.NewRecipeManager(nil)
+	return testutil.Backend{
+		Ingredients: ism,
+		Recipes:     rm,
+		Search:      in_memory_repository.NewSearchManager(ism, rm),
+		Users:       users,
+		Sessions:    in_memory_repository.NewSessionManager(),
+		Households:  in_memory_repository.NewHouseholdManager(users),
+		APIKeys:     in_memory_repository.NewAPIKeyManager(),
+		Transactor:  in_memory_repository.NewTransactor(),
+	}
+}
+
+func TestIngredientStorageManager_Contract(t *testing.T) {
+	testutil.RunIngredientStorageContract(t, newBackend)
+}
+
+func TestRecipeManager_Contract(t *testing.T) {
+	testutil.RunRecipeContract(t, newBackend)
+}
+
+func TestTransactor_Contract(t *testing.T) {
+	testutil.RunTransactionContract(t, newBackend)
+}
+
+func TestUsers_Contract(t *testing.T) {
+	testutil.RunUserContract(t, newBackend)
+}
+
+func TestHouseholds_Contract(t *testing.T) {
+	testutil.RunHouseholdContract(t, newBackend)
+}
+
+func TestAPIKeys_Contract(t *testing.T) {
+	testutil.RunAPIKeyContract(t, newBackend)
+}
+
+func TestBackup_Contract(t *testing.T) {
+	testutil.RunBackupContract(t, newBackend)
+}
@@ -0,0 +1,159 @@
+package in_memory_repository
+
+import (
+	"context"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/household"
+	"q-q-tem-pra-hoje/internal/domain/user"
+	"slices"
+	"sync"
+	"time"
+)
+
+type householdManager struct {
+	mu          sync.Mutex
+	Households  []household.Household
+	Memberships []household.Membership
+	Invitations map[string]household.Invitation
+	users       user.UserManager
+}
+
+// NewHouseholdManager looks members' emails up in um.
+func NewHouseholdManager(um user.UserManager) *householdManager {
+	return &householdManager{Invitations: map[string]household.Invitation{}, users: um}
+}
+
+func (hm *householdManager) AddHousehold(ctx context.Context, h household.Household, ownerID int) (household.Household, error) {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	return hm.addHousehold(h, ownerID), nil
+}
+
+// addHousehold expects hm.mu to be held.
+func (hm *householdManager) addHousehold(h household.Household, ownerID int) household.Household {
+	h.Id = len(hm.Households) + 1
+	hm.Households = append(hm.Households, h)
+	hm.Memberships = append(hm.Memberships, household.Membership{Household: h, UserId: ownerID, Role: household.RoleOwner})
+	return h
+}
+
+func (hm *householdManager) EnsureMembership(ctx context.Context, userID int, name string) (household.Membership, bool, error) {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	for _, m := range hm.Memberships {
+		if m.UserId == userID {
+			return m, false, nil
+		}
+	}
+	h := hm.addHousehold(household.Household{Name: name}, userID)
+	return household.Membership{Household: h, UserId: userID, Role: household.RoleOwner}, true, nil
+}
+
+func (hm *householdManager) FindMemberships(ctx context.Context, userID int) ([]household.Membership, error) {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	memberships := []household.Membership{}
+	for _, m := range hm.Memberships {
+		if m.UserId == userID {
+			memberships = append(memberships, m)
+		}
+	}
+	return memberships, nil
+}
+
+func (hm *householdManager) FindMembership(ctx context.Context, householdID int, userID int) (household.Membership, error) {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	for _, m := range hm.Memberships {
+		if m.Household.Id == householdID && m.UserId == userID {
+			return m, nil
+		}
+	}
+	return household.Membership{}, domain.NewNotFound("household", householdID)
+}
+
+func (hm *householdManager) FindMembers(ctx context.Context, householdID int) ([]household.Member, error) {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	members := []household.Member{}
+	for _, m := range hm.Memberships {
+		if m.Household.Id != householdID {
+			continue
+		}
+		found, err := hm.users.FindUser(ctx, m.UserId)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, household.Member{UserId: m.UserId, Email: found.Email, Role: m.Role})
+	}
+	return members, nil
+}
+
+func (hm *householdManager) AddMember(ctx context.Context, householdID int, userID int, role household.Role) error {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	return hm.addMember(householdID, userID, role)
+}
+
+// addMember expects hm.mu to be held.
+func (hm *householdManager) addMember(householdID int, userID int, role household.Role) error {
+	if slices.ContainsFunc(hm.Memberships, func(m household.Membership) bool {
+		return m.Household.Id == householdID && m.UserId == userID
+	}) {
+		return domain.NewConflict("household", "already a member of this household")
+	}
+	i := slices.IndexFunc(hm.Households, func(h household.Household) bool { return h.Id == householdID })
+	if i < 0 {
+		return domain.NewNotFound("household", householdID)
+	}
+	hm.Memberships = append(hm.Memberships, household.Membership{Household: hm.Households[i], UserId: userID, Role: role})
+	return nil
+}
+
+func (hm *householdManager) RemoveMember(ctx context.Context, householdID int, userID int) error {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	i := slices.IndexFunc(hm.Memberships, func(m household.Membership) bool {
+		return m.Household.Id == householdID && m.UserId == userID
+	})
+	if i < 0 {
+		return domain.NewNotFound("member", userID)
+	}
+	if hm.Memberships[i].Role == household.RoleOwner && hm.owners(householdID) == 1 {
+		return domain.NewConflict("household", "a household needs at least one owner")
+	}
+	hm.Memberships = slices.Delete(hm.Memberships, i, i+1)
+	return nil
+}
+
+func (hm *householdManager) AddInvitation(ctx context.Context, invitation household.Invitation) error {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	hm.Invitations[invitation.CodeHash] = invitation
+	return nil
+}
+
+func (hm *householdManager) AcceptInvitation(ctx context.Context, codeHash string, userID int, now time.Time) (household.Invitation, error) {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	invitation, ok := hm.Invitations[codeHash]
+	if !ok || !now.Before(invitation.ExpiresAt) {
+		return household.Invitation{}, domain.NewNotFound("invitation", "")
+	}
+	if err := hm.addMember(invitation.HouseholdId, userID, invitation.Role); err != nil {
+		return household.Invitation{}, err
+	}
+	delete(hm.Invitations, codeHash)
+	return invitation, nil
+}
+
+// owners counts the owners of a household. It expects hm.mu to be held.
+func (hm *householdManager) owners(householdID int) int {
+	owners := 0
+	for _, m := range hm.Memberships {
+		if m.Household.Id == householdID && m.Role == household.RoleOwner {
+			owners++
+		}
+	}
+	return owners
+}
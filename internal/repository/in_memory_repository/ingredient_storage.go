@@ -1,45 +1,152 @@
 package in_memory_repository
 
 import (
+	"context"
+	"q-q-tem-pra-hoje/internal/domain"
 	"q-q-tem-pra-hoje/internal/domain/ingredient"
+	"slices"
+	"sync"
 )
 
+type storedIngredient struct {
+	ingredient  ingredient.Ingredient
+	id          int
+	householdID *int
+}
+
+func (s storedIngredient) value() ingredient.Ingredient {
+	id := s.id
+	found := s.ingredient
+	found.Id = &id
+	return found
+}
+
 type ingredientStorageManager struct {
-	Ingredients []ingredient.Ingredient
+	mu          sync.Mutex
+	ingredients []storedIngredient
+	nextID      int
 }
 
-func NewIngredientStorageManager() ingredientStorageManager {
-	return ingredientStorageManager{}
+func NewIngredientStorageManager() *ingredientStorageManager {
+	return &ingredientStorageManager{}
 }
 
-func (ism *ingredientStorageManager) AddIngredient(ingredient ingredient.Ingredient) error {
-	ism.Ingredients = append(ism.Ingredients, ingredient)
+// AddIngredient adds the quantity to the pantry's ingredient of the same
+// name, if there is one.
+func (ism *ingredientStorageManager) AddIngredient(ctx context.Context, ingredientParams ingredient.Ingredient) error {
+	ism.mu.Lock()
+	defer ism.mu.Unlock()
+
+	i := slices.IndexFunc(ism.ingredients, func(s storedIngredient) bool {
+		return s.ingredient.Name == ingredientParams.Name && inPantry(ctx, s.householdID)
+	})
+	if i >= 0 {
+		ism.ingredients[i].ingredient.Quantity += ingredientParams.Quantity
+		id, quantity := ism.ingredients[i].id, ingredientParams.Quantity
+		onRollback(ctx, func() {
+			ism.revert(id, func(s *storedIngredient) { s.ingredient.Quantity -= quantity })
+		})
+		return nil
+	}
+
+	ism.nextID++
+	ingredientParams.Id = nil
+	ism.ingredients = append(ism.ingredients, storedIngredient{ingredient: ingredientParams, id: ism.nextID, householdID: householdID(ctx)})
+	id := ism.nextID
+	onRollback(ctx, func() {
+		ism.mu.Lock()
+		defer ism.mu.Unlock()
+		ism.ingredients = slices.DeleteFunc(ism.ingredients, func(s storedIngredient) bool { return s.id == id })
+	})
 	return nil
 }
 
-func (ism *ingredientStorageManager) FindIngredients() ([]ingredient.Ingredient, error) {
-	ingredientMap := make(map[string]ingredient.Ingredient)
-	for _, ingredient := range ism.Ingredients {
-		if existing, exists := ingredientMap[ingredient.Name]; exists {
-			existing.Quantity += ingredient.Quantity
-			ingredientMap[ingredient.Name] = existing
-		} else {
-			ingredientMap[ingredient.Name] = ingredient
+func (ism *ingredientStorageManager) FindIngredients(ctx context.Context) ([]ingredient.Ingredient, error) {
+	ism.mu.Lock()
+	defer ism.mu.Unlock()
+
+	ingredients := []ingredient.Ingredient{}
+	for _, s := range ism.ingredients {
+		if inPantry(ctx, s.householdID) {
+			ingredients = append(ingredients, s.value())
 		}
 	}
+	return ingredients, nil
+}
 
-	ingredientsFound := make([]ingredient.Ingredient, 0, len(ingredientMap))
-	for _, ingredient := range ingredientMap {
-		ingredientsFound = append(ingredientsFound, ingredient)
+func (ism *ingredientStorageManager) CountIngredients(ctx context.Context) (int, error) {
+	ism.mu.Lock()
+	defer ism.mu.Unlock()
+
+	count := 0
+	for _, s := range ism.ingredients {
+		if inPantry(ctx, s.householdID) {
+			count++
+		}
 	}
-	return ingredientsFound, nil
+	return count, nil
+}
+
+func (ism *ingredientStorageManager) ListIngredients(ctx context.Context, opts domain.ListOptions) (domain.Page[ingredient.Ingredient], error) {
+	ingredients, _ := ism.FindIngredients(ctx)
+	return listPage(ingredients, opts,
+		func(i ingredient.Ingredient) int { return *i.Id },
+		func(i ingredient.Ingredient) string { return i.Name },
+		func(i ingredient.Ingredient) int { return i.Quantity },
+		domain.SortByName, domain.SortByCreated, domain.SortByQuantity)
 }
 
-func (ism *ingredientStorageManager) Update(ingredientParam ingredient.Ingredient) error {
-	ism.Ingredients = []ingredient.Ingredient{ingredientParam}
+func (ism *ingredientStorageManager) Update(ctx context.Context, ingredientParams ingredient.Ingredient) error {
+	if ingredientParams.Id == nil {
+		return domain.NewNotFound("ingredient", "")
+	}
+
+	ism.mu.Lock()
+	defer ism.mu.Unlock()
+
+	i := ism.index(ctx, uint(*ingredientParams.Id))
+	if i < 0 {
+		return domain.NewNotFound("ingredient", *ingredientParams.Id)
+	}
+	ingredientParams.Id = nil
+	previous := ism.ingredients[i]
+	ism.ingredients[i].ingredient = ingredientParams
+	onRollback(ctx, func() {
+		ism.revert(previous.id, func(s *storedIngredient) { s.ingredient = previous.ingredient })
+	})
 	return nil
 }
 
-func (ism *ingredientStorageManager) Delete(id uint) error {
+func (ism *ingredientStorageManager) Delete(ctx context.Context, id uint) error {
+	ism.mu.Lock()
+	defer ism.mu.Unlock()
+
+	i := ism.index(ctx, id)
+	if i < 0 {
+		return domain.NewNotFound("ingredient", id)
+	}
+	deleted := ism.ingredients[i]
+	ism.ingredients = slices.Delete(ism.ingredients, i, i+1)
+	onRollback(ctx, func() {
+		ism.mu.Lock()
+		defer ism.mu.Unlock()
+		ism.ingredients = insertByID(ism.ingredients, deleted, func(s storedIngredient) int { return s.id })
+	})
 	return nil
 }
+
+func (ism *ingredientStorageManager) index(ctx context.Context, id uint) int {
+	return slices.IndexFunc(ism.ingredients, func(s storedIngredient) bool {
+		return uint(s.id) == id && inPantry(ctx, s.householdID)
+	})
+}
+
+// revert changes the ingredient with the given id back, if it is still
+// there. It is what rolling back an update or a merge comes down to.
+func (ism *ingredientStorageManager) revert(id int, change func(s *storedIngredient)) {
+	ism.mu.Lock()
+	defer ism.mu.Unlock()
+	if i := slices.IndexFunc(ism.ingredients, func(s storedIngredient) bool { return s.id == id }); i >= 0 {
+		change(&ism.ingredients[i])
+	}
+}
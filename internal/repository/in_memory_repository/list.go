@@ -0,0 +1,91 @@
+package in_memory_repository
+
+import (
+	"cmp"
+	"fmt"
+	"q-q-tem-pra-hoje/internal/domain"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type listEntry[T any] struct {
+	item T
+	seq  int
+	key  string
+	num  int
+}
+
+// listPage applies opts to items. Ids are handed out in creation order, so an
+// item's id stands in for its creation time.
+func listPage[T any](items []T, opts domain.ListOptions, id func(T) int, name func(T) string, quantity func(T) int, allowed ...domain.SortField) (domain.Page[T], error) {
+	sort := opts.SortOrDefault()
+	if !slices.Contains(allowed, sort) {
+		return domain.Page[T]{}, domain.NewValidation(domain.FieldError{Field: "sort", Message: fmt.Sprintf("cannot sort by %s", sort)})
+	}
+
+	search := strings.ToLower(opts.Search)
+	entries := []listEntry[T]{}
+	for _, item := range items {
+		if !strings.Contains(strings.ToLower(name(item)), search) {
+			continue
+		}
+		entry := listEntry[T]{item: item, seq: id(item)}
+		switch sort {
+		case domain.SortByName:
+			entry.key = name(item)
+		case domain.SortByQuantity:
+			entry.num = quantity(item)
+		case domain.SortByCreated:
+			entry.num = entry.seq
+		}
+		entries = append(entries, entry)
+	}
+
+	compare := func(a, b listEntry[T]) int {
+		c := cmp.Or(strings.Compare(a.key, b.key), cmp.Compare(a.num, b.num), cmp.Compare(a.seq, b.seq))
+		if opts.Descending {
+			return -c
+		}
+		return c
+	}
+	slices.SortFunc(entries, compare)
+
+	if opts.After != nil {
+		after := listEntry[T]{seq: opts.After.ID}
+		if sort == domain.SortByName {
+			after.key = opts.After.Key
+		} else {
+			num, err := strconv.Atoi(opts.After.Key)
+			if err != nil {
+				return domain.Page[T]{}, domain.NewValidation(domain.FieldError{Field: "cursor", Message: "cursor does not match the sort order"})
+			}
+			after.num = num
+		}
+		start := len(entries)
+		for i, entry := range entries {
+			if compare(entry, after) > 0 {
+				start = i
+				break
+			}
+		}
+		entries = entries[start:]
+	}
+
+	var next *domain.Cursor
+	if opts.Limit > 0 && len(entries) > opts.Limit {
+		entries = entries[:opts.Limit]
+		last := entries[len(entries)-1]
+		key := last.key
+		if sort != domain.SortByName {
+			key = strconv.Itoa(last.num)
+		}
+		next = &domain.Cursor{Key: key, ID: last.seq}
+	}
+
+	page := domain.Page[T]{Items: make([]T, len(entries)), Next: next}
+	for i, entry := range entries {
+		page.Items[i] = entry.item
+	}
+	return page, nil
+}
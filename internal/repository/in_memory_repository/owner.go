@@ -0,0 +1,68 @@
+package in_memory_repository
+
+import (
+	"context"
+	"q-q-tem-pra-hoje/internal/domain/household"
+	"q-q-tem-pra-hoje/internal/domain/recipe"
+	"q-q-tem-pra-hoje/internal/domain/user"
+)
+
+// These mirror the scoping of the Postgres repositories, where a nil owner
+// or household stands for NULL.
+
+// ownerID is the owner of new rows: the user ctx acts for, or nobody in
+// system scope.
+func ownerID(ctx context.Context) *int {
+	if id, ok := user.UserIDFromContext(ctx); ok {
+		return &id
+	}
+	return nil
+}
+
+// householdID is the household of new rows: the one ctx acts within, or none
+// outside of one.
+func householdID(ctx context.Context) *int {
+	if m, ok := household.MembershipFromContext(ctx); ok {
+		return &m.Household.Id
+	}
+	return nil
+}
+
+// ownedBy reports whether a row owned by owner belongs to the user ctx acts
+// for. In system scope every row does.
+func ownedBy(ctx context.Context, owner *int) bool {
+	id, ok := user.UserIDFromContext(ctx)
+	return !ok || owner != nil && *owner == id
+}
+
+// inPantry reports whether a row of the given household is in the pantry of
+// the household ctx acts within. A user acting outside of any household has
+// no pantry; system scope sees every row.
+func inPantry(ctx context.Context, householdID *int) bool {
+	m, ok := household.MembershipFromContext(ctx)
+	if !ok {
+		_, ok := user.UserIDFromContext(ctx)
+		return !ok
+	}
+	return householdID != nil && *householdID == m.Household.Id
+}
+
+// canRead reports whether the user ctx acts for may read r: their own
+// recipes, the ones shared with the household ctx acts within and public
+// ones. In system scope every recipe is readable.
+func canRead(ctx context.Context, r storedRecipe) bool {
+	if ownedBy(ctx, r.userID) || r.visibility == recipe.VisibilityPublic {
+		return true
+	}
+	m, ok := household.MembershipFromContext(ctx)
+	return ok && r.visibility == recipe.VisibilityHousehold && r.householdID != nil && *r.householdID == m.Household.Id
+}
+
+// sameOwner compares owners the way the unique recipe index does, with
+// nobody equal to nobody.
+func sameOwner(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
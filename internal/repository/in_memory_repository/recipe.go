@@ -1,31 +1,201 @@
 package in_memory_repository
 
-import "q-q-tem-pra-hoje/internal/domain/recipe"
+import (
+	"context"
+	"fmt"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/ingredient"
+	"q-q-tem-pra-hoje/internal/domain/recipe"
+	"slices"
+	"sync"
+)
+
+type storedRecipe struct {
+	id          int
+	name        string
+	ingredients []ingredient.Ingredient
+	visibility  recipe.Visibility
+	userID      *int
+	householdID *int
+}
+
+// recipe returns a copy of the stored recipe, so callers cannot change it
+// behind the lock.
+func (s storedRecipe) recipe() recipe.Recipe {
+	id := s.id
+	r := recipe.Recipe{Id: &id, Name: s.name, Ingredients: slices.Clone(s.ingredients), Visibility: s.visibility}
+	if s.userID != nil {
+		userID := *s.userID
+		r.UserId = &userID
+	}
+	return r
+}
 
 type recipeManager struct {
-	Recipes      []recipe.Recipe
-	MethodCalled bool
+	mu      sync.Mutex
+	recipes []storedRecipe
+	nextID  int
 }
 
+// NewRecipeManager starts with the given recipes, owned by nobody and public
+// unless they say otherwise, since nobody could read them otherwise. Recipes
+// without an id are given one.
 func NewRecipeManager(recipes []recipe.Recipe) *recipeManager {
-	if len(recipes) > 0 {
-		return &recipeManager{Recipes: recipes, MethodCalled: false}
+	rm := &recipeManager{}
+	for _, r := range recipes {
+		if r.Id != nil {
+			rm.nextID = max(rm.nextID, *r.Id)
+		}
+	}
+	for _, r := range recipes {
+		if r.Visibility == "" {
+			r.Visibility = recipe.VisibilityPublic
+		}
+		stored := newStoredRecipe(context.Background(), r)
+		if r.Id != nil {
+			stored.id = *r.Id
+		} else {
+			rm.nextID++
+			stored.id = rm.nextID
+		}
+		rm.recipes = append(rm.recipes, stored)
+	}
+	return rm
+}
+
+// newStoredRecipe keeps the first of ingredients sharing a name, like the
+// Postgres repository does.
+func newStoredRecipe(ctx context.Context, r recipe.Recipe) storedRecipe {
+	ingredients := []ingredient.Ingredient{}
+	for _, ing := range r.Ingredients {
+		if !slices.ContainsFunc(ingredients, func(i ingredient.Ingredient) bool { return i.Name == ing.Name }) {
+			ing.Id = nil
+			ingredients = append(ingredients, ing)
+		}
 	}
-	return &recipeManager{MethodCalled: false}
+	return storedRecipe{name: r.Name, ingredients: ingredients, visibility: r.VisibilityOrDefault(), userID: ownerID(ctx), householdID: householdID(ctx)}
 }
 
-func (rm *recipeManager) AddRecipe(recipe recipe.Recipe) error {
-	rm.MethodCalled = true
-	rm.Recipes = append(rm.Recipes, recipe)
+func (rm *recipeManager) AddRecipe(ctx context.Context, r recipe.Recipe) error {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	stored := newStoredRecipe(ctx, r)
+	if rm.indexByName(stored.userID, stored.name) >= 0 {
+		return domain.NewConflict("recipe", fmt.Sprintf("a recipe named %q already exists", r.Name))
+	}
+	rm.nextID++
+	stored.id = rm.nextID
+	rm.recipes = append(rm.recipes, stored)
+	onRollback(ctx, func() { rm.remove(stored.id) })
 	return nil
 }
 
-func (rm *recipeManager) GetAllRecipes() ([]recipe.Recipe, error) {
-	rm.MethodCalled = true
-	return rm.Recipes, nil
+func (rm *recipeManager) UpsertRecipe(ctx context.Context, r recipe.Recipe) (bool, error) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	stored := newStoredRecipe(ctx, r)
+	if i := rm.indexByName(stored.userID, stored.name); i >= 0 {
+		previous := rm.recipes[i]
+		stored.id = previous.id
+		rm.recipes[i] = stored
+		onRollback(ctx, func() {
+			rm.mu.Lock()
+			defer rm.mu.Unlock()
+			if i := slices.IndexFunc(rm.recipes, func(r storedRecipe) bool { return r.id == previous.id }); i >= 0 {
+				rm.recipes[i] = previous
+			}
+		})
+		return false, nil
+	}
+	rm.nextID++
+	stored.id = rm.nextID
+	rm.recipes = append(rm.recipes, stored)
+	onRollback(ctx, func() { rm.remove(stored.id) })
+	return true, nil
+}
+
+func (rm *recipeManager) GetAllRecipes(ctx context.Context) ([]recipe.Recipe, error) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	return rm.visible(ctx), nil
+}
+
+func (rm *recipeManager) CountRecipes(ctx context.Context) (int, error) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	count := 0
+	for _, r := range rm.recipes {
+		if canRead(ctx, r) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (rm *recipeManager) ListRecipes(ctx context.Context, opts domain.ListOptions) (domain.Page[recipe.Recipe], error) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	return listPage(rm.visible(ctx), opts,
+		func(r recipe.Recipe) int { return *r.Id },
+		func(r recipe.Recipe) string { return r.Name },
+		func(r recipe.Recipe) int { return 0 },
+		domain.SortByName, domain.SortByCreated)
+}
+
+func (rm *recipeManager) GetRecipe(ctx context.Context, id uint) (recipe.Recipe, error) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	for _, r := range rm.recipes {
+		if uint(r.id) == id && canRead(ctx, r) {
+			return r.recipe(), nil
+		}
+	}
+	return recipe.Recipe{}, domain.NewNotFound("recipe", id)
+}
+
+// DeleteRecipe only deletes recipes of the user ctx acts for; shared ones
+// are not the readers' to delete.
+func (rm *recipeManager) DeleteRecipe(ctx context.Context, id uint) error {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	i := slices.IndexFunc(rm.recipes, func(r storedRecipe) bool {
+		return uint(r.id) == id && ownedBy(ctx, r.userID)
+	})
+	if i < 0 {
+		return domain.NewNotFound("recipe", id)
+	}
+	deleted := rm.recipes[i]
+	rm.recipes = slices.Delete(rm.recipes, i, i+1)
+	onRollback(ctx, func() {
+		rm.mu.Lock()
+		defer rm.mu.Unlock()
+		rm.recipes = insertByID(rm.recipes, deleted, func(r storedRecipe) int { return r.id })
+	})
+	return nil
+}
+
+// visible returns copies of the recipes ctx may read, in creation order.
+func (rm *recipeManager) visible(ctx context.Context) []recipe.Recipe {
+	recipes := []recipe.Recipe{}
+	for _, r := range rm.recipes {
+		if canRead(ctx, r) {
+			recipes = append(recipes, r.recipe())
+		}
+	}
+	return recipes
+}
+
+func (rm *recipeManager) indexByName(owner *int, name string) int {
+	return slices.IndexFunc(rm.recipes, func(r storedRecipe) bool {
+		return r.name == name && sameOwner(r.userID, owner)
+	})
 }
 
-func (rm *recipeManager) DeleteRecipe(id uint) error {
-	rm.MethodCalled = true
-  return nil
+func (rm *recipeManager) remove(id int) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.recipes = slices.DeleteFunc(rm.recipes, func(r storedRecipe) bool { return r.id == id })
 }
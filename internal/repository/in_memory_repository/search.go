@@ -0,0 +1,126 @@
+package in_memory_repository
+
+import (
+	"cmp"
+	"context"
+	"q-q-tem-pra-hoje/internal/domain/ingredient"
+	"q-q-tem-pra-hoje/internal/domain/recipe"
+	"q-q-tem-pra-hoje/internal/domain/search"
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+type searchManager struct {
+	ingredients ingredient.IngredientStorageManager
+	recipes     recipe.RecipeManager
+}
+
+// NewSearchManager searches the names held by the given managers. It
+// approximates the Postgres search: matching ignores case and accents, and a
+// word may be one typo away from the query word per four letters.
+func NewSearchManager(ism ingredient.IngredientStorageManager, rm recipe.RecipeManager) *searchManager {
+	return &searchManager{ingredients: ism, recipes: rm}
+}
+
+func (sm *searchManager) Search(ctx context.Context, query string, limit int) ([]search.Result, error) {
+	terms := strings.Fields(fold(query))
+
+	recipes, err := sm.recipes.GetAllRecipes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ingredients, err := sm.ingredients.FindIngredients(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []search.Result{}
+	add := func(kind search.Kind, id *int, name string) {
+		if rank := matchRank(terms, name); rank > 0 {
+			result := search.Result{Kind: kind, Name: name, Rank: rank}
+			if id != nil {
+				result.Id = *id
+			}
+			results = append(results, result)
+		}
+	}
+	for _, r := range recipes {
+		add(search.KindRecipe, r.Id, r.Name)
+	}
+	for _, i := range ingredients {
+		add(search.KindIngredient, i.Id, i.Name)
+	}
+
+	slices.SortStableFunc(results, func(a, b search.Result) int {
+		return cmp.Or(cmp.Compare(b.Rank, a.Rank), strings.Compare(a.Name, b.Name))
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// matchRank scores name against every query term: 1 for a word starting with
+// the term, 0.5 for a close misspelling. A name missing any term scores 0.
+func matchRank(terms []string, name string) float64 {
+	if len(terms) == 0 {
+		return 0
+	}
+	words := strings.FieldsFunc(fold(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	total := 0.0
+	for _, term := range terms {
+		best := 0.0
+		for _, word := range words {
+			if strings.HasPrefix(word, term) {
+				best = 1
+				break
+			}
+			if levenshtein(term, word) <= max(1, len([]rune(term))/4) {
+				best = 0.5
+			}
+		}
+		if best == 0 {
+			return 0
+		}
+		total += best
+	}
+	return total / float64(len(terms))
+}
+
+// fold lowercases s and strips its diacritics.
+func fold(s string) string {
+	folded, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), s)
+	if err != nil {
+		folded = s
+	}
+	return strings.ToLower(folded)
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
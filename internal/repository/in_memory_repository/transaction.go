@@ -0,0 +1,69 @@
+package in_memory_repository
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+type txKey struct{}
+
+// undoLog holds what it takes to revert the writes of one transaction, in
+// the order they were made.
+type undoLog struct {
+	mu    sync.Mutex
+	steps []func()
+}
+
+// onRollback records how to revert a write made under ctx, if ctx is in a
+// transaction. Repositories call it with their lock held, and steps take
+// the lock themselves when they run.
+func onRollback(ctx context.Context, step func()) {
+	log, ok := ctx.Value(txKey{}).(*undoLog)
+	if !ok {
+		return
+	}
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	log.steps = append(log.steps, step)
+}
+
+func (l *undoLog) rollback() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, step := range slices.Backward(l.steps) {
+		step()
+	}
+}
+
+type transactor struct{}
+
+// NewTransactor runs transactions over the in-memory repositories. A failed
+// transaction reverts only its own writes, so whatever other callers did
+// meanwhile stays, and ids handed out are never handed out again.
+func NewTransactor() *transactor {
+	return &transactor{}
+}
+
+func (t *transactor) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(txKey{}) != nil {
+		return fn(ctx)
+	}
+
+	log := &undoLog{}
+	err := fn(context.WithValue(ctx, txKey{}, log))
+	if err != nil {
+		log.rollback()
+	}
+	return err
+}
+
+// insertByID puts back a deleted item where it was among items, which are
+// kept in the order of their ids.
+func insertByID[T any](items []T, item T, id func(T) int) []T {
+	i := slices.IndexFunc(items, func(other T) bool { return id(other) > id(item) })
+	if i < 0 {
+		return append(items, item)
+	}
+	return slices.Insert(items, i, item)
+}
@@ -0,0 +1,79 @@
+package in_memory_repository_test
+
+import (
+	"context"
+	"errors"
+	"q-q-tem-pra-hoje/internal/domain/household"
+	"q-q-tem-pra-hoje/internal/domain/ingredient"
+	"q-q-tem-pra-hoje/internal/domain/recipe"
+	"q-q-tem-pra-hoje/internal/domain/user"
+	"q-q-tem-pra-hoje/internal/repository/in_memory_repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactor(t *testing.T) {
+	inHousehold := func(id int) context.Context {
+		ctx := user.WithUserID(context.Background(), id)
+		return household.WithMembership(ctx, household.Membership{Household: household.Household{Id: id}, UserId: id, Role: household.RoleOwner})
+	}
+	rice := []ingredient.Ingredient{{Name: "rice", MeasureType: "g", Quantity: 200}}
+
+	t.Run("it should keep writes made outside of a transaction that fails", func(t *testing.T) {
+		ism := in_memory_repository.NewIngredientStorageManager()
+		rm := in_memory_repository.NewRecipeManager(nil)
+		transactor := in_memory_repository.NewTransactor()
+		alice, bob := inHousehold(1), inHousehold(2)
+		require.NoError(t, ism.AddIngredient(bob, ingredient.Ingredient{Name: "onion", MeasureType: "unit", Quantity: 1}))
+
+		err := transactor.Transaction(alice, func(ctx context.Context) error {
+			require.NoError(t, ism.AddIngredient(ctx, ingredient.Ingredient{Name: "rice", MeasureType: "g", Quantity: 500}))
+			require.NoError(t, rm.AddRecipe(ctx, recipe.Recipe{Name: "plain rice", Ingredients: rice}))
+
+			require.NoError(t, ism.AddIngredient(bob, ingredient.Ingredient{Name: "onion", MeasureType: "unit", Quantity: 2}))
+			require.NoError(t, ism.AddIngredient(bob, ingredient.Ingredient{Name: "garlic", MeasureType: "unit", Quantity: 3}))
+			require.NoError(t, rm.AddRecipe(bob, recipe.Recipe{Name: "fried rice", Ingredients: rice}))
+			return errors.New("dry run")
+		})
+		require.Error(t, err)
+
+		pantry, err := ism.FindIngredients(alice)
+		require.NoError(t, err)
+		assert.Empty(t, pantry)
+		recipes, err := rm.GetAllRecipes(alice)
+		require.NoError(t, err)
+		assert.Empty(t, recipes)
+
+		pantry, err = ism.FindIngredients(bob)
+		require.NoError(t, err)
+		onion, garlic := 1, 3
+		assert.Equal(t, []ingredient.Ingredient{
+			{Id: &onion, Name: "onion", MeasureType: "unit", Quantity: 3},
+			{Id: &garlic, Name: "garlic", MeasureType: "unit", Quantity: 3},
+		}, pantry)
+		recipes, err = rm.GetAllRecipes(bob)
+		require.NoError(t, err)
+		require.Len(t, recipes, 1)
+		assert.Equal(t, 2, *recipes[0].Id)
+	})
+
+	t.Run("it should not hand out the ids of rolled back writes again", func(t *testing.T) {
+		ism := in_memory_repository.NewIngredientStorageManager()
+		transactor := in_memory_repository.NewTransactor()
+		alice := inHousehold(1)
+
+		err := transactor.Transaction(alice, func(ctx context.Context) error {
+			require.NoError(t, ism.AddIngredient(ctx, ingredient.Ingredient{Name: "rice", MeasureType: "g", Quantity: 500}))
+			return errors.New("dry run")
+		})
+		require.Error(t, err)
+		require.NoError(t, ism.AddIngredient(alice, ingredient.Ingredient{Name: "onion", MeasureType: "unit", Quantity: 1}))
+
+		pantry, err := ism.FindIngredients(alice)
+		require.NoError(t, err)
+		require.Len(t, pantry, 1)
+		assert.Equal(t, 2, *pantry[0].Id)
+	})
+}
@@ -0,0 +1,89 @@
+package in_memory_repository
+
+import (
+	"context"
+	"fmt"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/user"
+	"strings"
+	"sync"
+)
+
+type userManager struct {
+	mu    sync.Mutex
+	Users []user.User
+}
+
+func NewUserManager() *userManager {
+	return &userManager{}
+}
+
+func (um *userManager) AddUser(ctx context.Context, u user.User) (user.User, error) {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+	if _, err := um.findByEmail(u.Email); err == nil {
+		return user.User{}, domain.NewConflict("user", fmt.Sprintf("an account for %q already exists", u.Email))
+	}
+	u.Id = len(um.Users) + 1
+	um.Users = append(um.Users, u)
+	return u, nil
+}
+
+func (um *userManager) FindUser(ctx context.Context, id int) (user.User, error) {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+	for _, u := range um.Users {
+		if u.Id == id {
+			return u, nil
+		}
+	}
+	return user.User{}, domain.NewNotFound("user", id)
+}
+
+func (um *userManager) FindUserByEmail(ctx context.Context, email string) (user.User, error) {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+	return um.findByEmail(email)
+}
+
+func (um *userManager) findByEmail(email string) (user.User, error) {
+	for _, u := range um.Users {
+		if strings.EqualFold(u.Email, email) {
+			return u, nil
+		}
+	}
+	return user.User{}, domain.NewNotFound("user", email)
+}
+
+type sessionManager struct {
+	mu       sync.Mutex
+	Sessions map[string]user.Session
+}
+
+func NewSessionManager() *sessionManager {
+	return &sessionManager{Sessions: map[string]user.Session{}}
+}
+
+func (sm *sessionManager) AddSession(ctx context.Context, session user.Session) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.Sessions[session.TokenHash] = session
+	return nil
+}
+
+func (sm *sessionManager) FindSession(ctx context.Context, tokenHash string) (user.Session, error) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	session, ok := sm.Sessions[tokenHash]
+	if !ok {
+		return user.Session{}, domain.NewNotFound("session", "")
+	}
+	return session, nil
+}
+
+func (sm *sessionManager) DeleteSession(ctx context.Context, tokenHash string) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	delete(sm.Sessions, tokenHash)
+	return nil
+}
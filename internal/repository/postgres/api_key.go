@@ -0,0 +1,157 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/apikey"
+	"q-q-tem-pra-hoje/internal/repository/sqlstore"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type apiKeyManager struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewAPIKeyManager(db *sql.DB, logger *slog.Logger) *apiKeyManager {
+	return &apiKeyManager{db, logger}
+}
+
+const apiKeyColumns = "id, user_id, household_id, name, prefix, key_hash, scopes, created_at, last_used_at"
+
+func (km *apiKeyManager) AddKey(ctx context.Context, key apikey.Key) (apikey.Key, error) {
+	query := `INSERT INTO api_keys (user_id, household_id, name, prefix, key_hash, scopes)
+	          VALUES ($1, $2, $3, $4, $5, $6)
+	          RETURNING ` + apiKeyColumns
+	spanCtx, span := dialect.StartQuerySpan(ctx, "INSERT", "api_keys", query)
+	row := km.db.QueryRowContext(spanCtx, query, key.UserId, key.HouseholdId, key.Name, key.Prefix, key.Hash, pq.Array(scopeStrings(key.Scopes)))
+	created, err := scanKey(row)
+	sqlstore.EndQuerySpan(span, err)
+	if err != nil {
+		km.logger.ErrorContext(ctx, "failed to insert api key", "user_id", key.UserId, "error", err)
+		return apikey.Key{}, fmt.Errorf("failed to insert api key: %v", err)
+	}
+	return created, nil
+}
+
+func (km *apiKeyManager) FindKeys(ctx context.Context, userID int) (keys []apikey.Key, err error) {
+	query := "SELECT " + apiKeyColumns + " FROM api_keys WHERE user_id = $1 ORDER BY id"
+	spanCtx, span := dialect.StartQuerySpan(ctx, "SELECT", "api_keys", query)
+	defer func() { sqlstore.EndQuerySpan(span, err) }()
+
+	rows, err := km.db.QueryContext(spanCtx, query, userID)
+	if err != nil {
+		km.logger.ErrorContext(ctx, "failed to query api keys", "user_id", userID, "error", err)
+		return nil, fmt.Errorf("error executing query: %v", err)
+	}
+	defer rows.Close()
+
+	keys = []apikey.Key{}
+	for rows.Next() {
+		key, err := scanKey(rows)
+		if err != nil {
+			km.logger.ErrorContext(ctx, "failed to scan api key row", "error", err)
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (km *apiKeyManager) FindKeyByHash(ctx context.Context, hash string) (found apikey.Key, err error) {
+	query := "SELECT " + apiKeyColumns + " FROM api_keys WHERE key_hash = $1"
+	spanCtx, span := dialect.StartQuerySpan(ctx, "SELECT", "api_keys", query)
+	defer func() { sqlstore.EndQuerySpan(span, err) }()
+
+	found, err = scanKey(km.db.QueryRowContext(spanCtx, query, hash))
+	if err == sql.ErrNoRows {
+		return apikey.Key{}, domain.NewNotFound("api key", "")
+	}
+	if err != nil {
+		km.logger.ErrorContext(ctx, "failed to query api key", "error", err)
+		return apikey.Key{}, fmt.Errorf("error executing query: %v", err)
+	}
+	return found, nil
+}
+
+func (km *apiKeyManager) ReplaceHash(ctx context.Context, id int, userID int, prefix string, hash string) (rotated apikey.Key, err error) {
+	query := "UPDATE api_keys SET prefix = $3, key_hash = $4 WHERE id = $1 AND user_id = $2 RETURNING " + apiKeyColumns
+	spanCtx, span := dialect.StartQuerySpan(ctx, "UPDATE", "api_keys", query)
+	defer func() { sqlstore.EndQuerySpan(span, err) }()
+
+	rotated, err = scanKey(km.db.QueryRowContext(spanCtx, query, id, userID, prefix, hash))
+	if err == sql.ErrNoRows {
+		return apikey.Key{}, domain.NewNotFound("api key", id)
+	}
+	if err != nil {
+		km.logger.ErrorContext(ctx, "failed to rotate api key", "key_id", id, "error", err)
+		return apikey.Key{}, fmt.Errorf("failed to rotate api key: %v", err)
+	}
+	return rotated, nil
+}
+
+func (km *apiKeyManager) DeleteKey(ctx context.Context, id int, userID int) error {
+	query := "DELETE FROM api_keys WHERE id = $1 AND user_id = $2"
+	spanCtx, span := dialect.StartQuerySpan(ctx, "DELETE", "api_keys", query)
+	result, err := km.db.ExecContext(spanCtx, query, id, userID)
+	sqlstore.EndQuerySpan(span, err)
+	if err != nil {
+		km.logger.ErrorContext(ctx, "failed to delete api key", "key_id", id, "error", err)
+		return fmt.Errorf("failed to delete api key: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return domain.NewNotFound("api key", id)
+	}
+	return nil
+}
+
+func (km *apiKeyManager) TouchKey(ctx context.Context, id int, usedAt time.Time) error {
+	query := "UPDATE api_keys SET last_used_at = $2 WHERE id = $1"
+	spanCtx, span := dialect.StartQuerySpan(ctx, "UPDATE", "api_keys", query)
+	_, err := km.db.ExecContext(spanCtx, query, id, usedAt)
+	sqlstore.EndQuerySpan(span, err)
+	if err != nil {
+		return fmt.Errorf("failed to touch api key: %v", err)
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanKey(row rowScanner) (apikey.Key, error) {
+	var key apikey.Key
+	var scopes pq.StringArray
+	var lastUsedAt sql.NullTime
+	err := row.Scan(&key.Id, &key.UserId, &key.HouseholdId, &key.Name, &key.Prefix, &key.Hash, &scopes, &key.CreatedAt, &lastUsedAt)
+	if err != nil {
+		return apikey.Key{}, err
+	}
+	key.Scopes = make([]apikey.Scope, len(scopes))
+	for i, scope := range scopes {
+		key.Scopes[i] = apikey.Scope(scope)
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	return key, nil
+}
+
+func scopeStrings(scopes []apikey.Scope) []string {
+	result := make([]string, len(scopes))
+	for i, scope := range scopes {
+		result[i] = string(scope)
+	}
+	return result
+}
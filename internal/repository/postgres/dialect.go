@@ -0,0 +1,18 @@
+package postgres
+
+import (
+	"fmt"
+	"q-q-tem-pra-hoje/internal/repository/sqlstore"
+	"time"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+var dialect = sqlstore.Dialect{
+	System:      semconv.DBSystemPostgreSQL,
+	Placeholder: func(n int) string { return fmt.Sprintf("$%d", n) },
+	NameLike: func(column, placeholder string) string {
+		return column + " ILIKE " + placeholder
+	},
+	TimeArg: func(t time.Time) any { return t },
+}
@@ -0,0 +1,274 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/household"
+	"q-q-tem-pra-hoje/internal/repository/sqlstore"
+	"time"
+)
+
+type householdManager struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewHouseholdManager(db *sql.DB, logger *slog.Logger) *householdManager {
+	return &householdManager{db, logger}
+}
+
+// AddHousehold creates the household and its owner in one statement, so a
+// household never exists without an owner.
+func (hm *householdManager) AddHousehold(ctx context.Context, h household.Household, ownerID int) (household.Household, error) {
+	return hm.addHousehold(ctx, hm.db, h, ownerID)
+}
+
+func (hm *householdManager) addHousehold(ctx context.Context, q sqlstore.Querier, h household.Household, ownerID int) (household.Household, error) {
+	query := `
+		WITH created AS (INSERT INTO households (name) VALUES ($1) RETURNING id)
+		INSERT INTO household_members (household_id, user_id, role)
+		SELECT id, $2, $3 FROM created
+		RETURNING household_id`
+	spanCtx, span := dialect.StartQuerySpan(ctx, "INSERT", "households", query)
+	err := q.QueryRowContext(spanCtx, query, h.Name, ownerID, household.RoleOwner).Scan(&h.Id)
+	sqlstore.EndQuerySpan(span, err)
+	if err != nil {
+		hm.logger.ErrorContext(ctx, "failed to insert household", "user_id", ownerID, "error", err)
+		return household.Household{}, fmt.Errorf("failed to insert household: %v", err)
+	}
+	return h, nil
+}
+
+const selectMemberships = `SELECT h.id, h.name, m.user_id, m.role
+                           FROM household_members m
+                             JOIN households h ON h.id = m.household_id`
+
+// EnsureMembership locks the user's row first, so a concurrent call waits
+// for this one and then finds the household it added.
+func (hm *householdManager) EnsureMembership(ctx context.Context, userID int, name string) (m household.Membership, added bool, err error) {
+	tx, err := sqlstore.BeginLocal(ctx, hm.db)
+	if err != nil {
+		hm.logger.ErrorContext(ctx, "failed to begin membership lookup", "user_id", userID, "error", err)
+		return household.Membership{}, false, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := "SELECT id FROM users WHERE id = $1 FOR UPDATE"
+	spanCtx, span := dialect.StartQuerySpan(ctx, "SELECT", "users", query)
+	_, err = tx.ExecContext(spanCtx, query, userID)
+	sqlstore.EndQuerySpan(span, err)
+	if err != nil {
+		hm.logger.ErrorContext(ctx, "failed to lock user", "user_id", userID, "error", err)
+		return household.Membership{}, false, fmt.Errorf("failed to lock user: %v", err)
+	}
+
+	memberships, err := hm.findMemberships(ctx, tx, userID)
+	if err != nil {
+		return household.Membership{}, false, err
+	}
+	if len(memberships) > 0 {
+		return memberships[0], false, nil
+	}
+
+	h, err := hm.addHousehold(ctx, tx, household.Household{Name: name}, userID)
+	if err != nil {
+		return household.Membership{}, false, err
+	}
+	if err := tx.Commit(); err != nil {
+		hm.logger.ErrorContext(ctx, "failed to commit household", "user_id", userID, "error", err)
+		return household.Membership{}, false, fmt.Errorf("failed to commit household: %v", err)
+	}
+	return household.Membership{Household: h, UserId: userID, Role: household.RoleOwner}, true, nil
+}
+
+func (hm *householdManager) FindMemberships(ctx context.Context, userID int) ([]household.Membership, error) {
+	return hm.findMemberships(ctx, hm.db, userID)
+}
+
+func (hm *householdManager) findMemberships(ctx context.Context, q sqlstore.Querier, userID int) (memberships []household.Membership, err error) {
+	query := selectMemberships + " WHERE m.user_id = $1 ORDER BY m.joined_at, h.id"
+	spanCtx, span := dialect.StartQuerySpan(ctx, "SELECT", "household_members", query)
+	defer func() { sqlstore.EndQuerySpan(span, err) }()
+
+	rows, err := q.QueryContext(spanCtx, query, userID)
+	if err != nil {
+		hm.logger.ErrorContext(ctx, "failed to query memberships", "user_id", userID, "error", err)
+		return nil, fmt.Errorf("error executing query: %v", err)
+	}
+	defer rows.Close()
+
+	memberships = []household.Membership{}
+	for rows.Next() {
+		var m household.Membership
+		if err := rows.Scan(&m.Household.Id, &m.Household.Name, &m.UserId, &m.Role); err != nil {
+			hm.logger.ErrorContext(ctx, "failed to scan membership row", "error", err)
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		memberships = append(memberships, m)
+	}
+	return memberships, rows.Err()
+}
+
+func (hm *householdManager) FindMembership(ctx context.Context, householdID int, userID int) (found household.Membership, err error) {
+	query := selectMemberships + " WHERE m.household_id = $1 AND m.user_id = $2"
+	spanCtx, span := dialect.StartQuerySpan(ctx, "SELECT", "household_members", query)
+	defer func() { sqlstore.EndQuerySpan(span, err) }()
+
+	err = hm.db.QueryRowContext(spanCtx, query, householdID, userID).Scan(&found.Household.Id, &found.Household.Name, &found.UserId, &found.Role)
+	if err == sql.ErrNoRows {
+		return household.Membership{}, domain.NewNotFound("household", householdID)
+	}
+	if err != nil {
+		hm.logger.ErrorContext(ctx, "failed to query membership", "household_id", householdID, "error", err)
+		return household.Membership{}, fmt.Errorf("error executing query: %v", err)
+	}
+	return found, nil
+}
+
+func (hm *householdManager) FindMembers(ctx context.Context, householdID int) (members []household.Member, err error) {
+	query := `SELECT m.user_id, u.email, m.role
+	          FROM household_members m
+	            JOIN users u ON u.id = m.user_id
+	          WHERE m.household_id = $1
+	          ORDER BY m.joined_at, m.user_id`
+	spanCtx, span := dialect.StartQuerySpan(ctx, "SELECT", "household_members", query)
+	defer func() { sqlstore.EndQuerySpan(span, err) }()
+
+	rows, err := hm.db.QueryContext(spanCtx, query, householdID)
+	if err != nil {
+		hm.logger.ErrorContext(ctx, "failed to query members", "household_id", householdID, "error", err)
+		return nil, fmt.Errorf("error executing query: %v", err)
+	}
+	defer rows.Close()
+
+	members = []household.Member{}
+	for rows.Next() {
+		var m household.Member
+		if err := rows.Scan(&m.UserId, &m.Email, &m.Role); err != nil {
+			hm.logger.ErrorContext(ctx, "failed to scan member row", "error", err)
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+func (hm *householdManager) AddMember(ctx context.Context, householdID int, userID int, role household.Role) error {
+	return hm.addMember(ctx, hm.db, householdID, userID, role)
+}
+
+func (hm *householdManager) addMember(ctx context.Context, q sqlstore.Querier, householdID int, userID int, role household.Role) error {
+	query := "INSERT INTO household_members (household_id, user_id, role) VALUES ($1, $2, $3)"
+	spanCtx, span := dialect.StartQuerySpan(ctx, "INSERT", "household_members", query)
+	_, err := q.ExecContext(spanCtx, query, householdID, userID, role)
+	sqlstore.EndQuerySpan(span, err)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.NewConflict("household", "already a member of this household")
+		}
+		hm.logger.ErrorContext(ctx, "failed to insert member", "household_id", householdID, "user_id", userID, "error", err)
+		return fmt.Errorf("failed to insert member: %v", err)
+	}
+	return nil
+}
+
+// RemoveMember locks the household first, so two owners leaving at once
+// cannot both see the other one stay.
+func (hm *householdManager) RemoveMember(ctx context.Context, householdID int, userID int) error {
+	tx, err := sqlstore.BeginLocal(ctx, hm.db)
+	if err != nil {
+		hm.logger.ErrorContext(ctx, "failed to begin member removal", "household_id", householdID, "error", err)
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := "SELECT id FROM households WHERE id = $1 FOR UPDATE"
+	spanCtx, span := dialect.StartQuerySpan(ctx, "SELECT", "households", query)
+	_, err = tx.ExecContext(spanCtx, query, householdID)
+	sqlstore.EndQuerySpan(span, err)
+	if err != nil {
+		hm.logger.ErrorContext(ctx, "failed to lock household", "household_id", householdID, "error", err)
+		return fmt.Errorf("failed to lock household: %v", err)
+	}
+
+	var role household.Role
+	var owners int
+	query = `SELECT m.role, (SELECT count(*) FROM household_members o WHERE o.household_id = m.household_id AND o.role = $3)
+	         FROM household_members m
+	         WHERE m.household_id = $1 AND m.user_id = $2`
+	spanCtx, span = dialect.StartQuerySpan(ctx, "SELECT", "household_members", query)
+	err = tx.QueryRowContext(spanCtx, query, householdID, userID, household.RoleOwner).Scan(&role, &owners)
+	sqlstore.EndQuerySpan(span, err)
+	if err == sql.ErrNoRows {
+		return domain.NewNotFound("member", userID)
+	}
+	if err != nil {
+		hm.logger.ErrorContext(ctx, "failed to query member", "household_id", householdID, "user_id", userID, "error", err)
+		return fmt.Errorf("error executing query: %v", err)
+	}
+	if role == household.RoleOwner && owners == 1 {
+		return domain.NewConflict("household", "a household needs at least one owner")
+	}
+
+	query = "DELETE FROM household_members WHERE household_id = $1 AND user_id = $2"
+	spanCtx, span = dialect.StartQuerySpan(ctx, "DELETE", "household_members", query)
+	_, err = tx.ExecContext(spanCtx, query, householdID, userID)
+	sqlstore.EndQuerySpan(span, err)
+	if err != nil {
+		hm.logger.ErrorContext(ctx, "failed to delete member", "household_id", householdID, "user_id", userID, "error", err)
+		return fmt.Errorf("failed to delete member: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		hm.logger.ErrorContext(ctx, "failed to commit member removal", "household_id", householdID, "error", err)
+		return fmt.Errorf("failed to commit member removal: %v", err)
+	}
+	return nil
+}
+
+func (hm *householdManager) AddInvitation(ctx context.Context, invitation household.Invitation) error {
+	query := "INSERT INTO household_invitations (code_hash, household_id, role, expires_at) VALUES ($1, $2, $3, $4)"
+	spanCtx, span := dialect.StartQuerySpan(ctx, "INSERT", "household_invitations", query)
+	_, err := hm.db.ExecContext(spanCtx, query, invitation.CodeHash, invitation.HouseholdId, invitation.Role, invitation.ExpiresAt)
+	sqlstore.EndQuerySpan(span, err)
+	if err != nil {
+		hm.logger.ErrorContext(ctx, "failed to insert invitation", "household_id", invitation.HouseholdId, "error", err)
+		return fmt.Errorf("failed to insert invitation: %v", err)
+	}
+	return nil
+}
+
+// AcceptInvitation deletes the invitation as it reads it, so two requests
+// racing with the same code cannot both join.
+func (hm *householdManager) AcceptInvitation(ctx context.Context, codeHash string, userID int, now time.Time) (found household.Invitation, err error) {
+	tx, err := sqlstore.BeginLocal(ctx, hm.db)
+	if err != nil {
+		hm.logger.ErrorContext(ctx, "failed to begin invitation", "user_id", userID, "error", err)
+		return household.Invitation{}, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := "DELETE FROM household_invitations WHERE code_hash = $1 AND expires_at > $2 RETURNING code_hash, household_id, role, expires_at"
+	spanCtx, span := dialect.StartQuerySpan(ctx, "DELETE", "household_invitations", query)
+	err = tx.QueryRowContext(spanCtx, query, codeHash, now).Scan(&found.CodeHash, &found.HouseholdId, &found.Role, &found.ExpiresAt)
+	sqlstore.EndQuerySpan(span, err)
+	if err == sql.ErrNoRows {
+		return household.Invitation{}, domain.NewNotFound("invitation", "")
+	}
+	if err != nil {
+		hm.logger.ErrorContext(ctx, "failed to take invitation", "error", err)
+		return household.Invitation{}, fmt.Errorf("error executing query: %v", err)
+	}
+
+	if err := hm.addMember(ctx, tx, found.HouseholdId, userID, found.Role); err != nil {
+		return household.Invitation{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		hm.logger.ErrorContext(ctx, "failed to commit invitation", "household_id", found.HouseholdId, "error", err)
+		return household.Invitation{}, fmt.Errorf("failed to commit invitation: %v", err)
+	}
+	return found, nil
+}
@@ -1,33 +1,46 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"log/slog"
+	"q-q-tem-pra-hoje/internal/domain"
 	"q-q-tem-pra-hoje/internal/domain/ingredient"
+	"q-q-tem-pra-hoje/internal/repository/sqlstore"
+	"time"
 )
 
 type ingredientStorageManager struct {
-	db *sql.DB
+	db     *sql.DB
+	logger *slog.Logger
 }
 
-func NewIngredientStorageManager(db *sql.DB) ingredientStorageManager {
-	return ingredientStorageManager{db}
+func NewIngredientStorageManager(db *sql.DB, logger *slog.Logger) ingredientStorageManager {
+	return ingredientStorageManager{db, logger}
 }
 
-func (ism *ingredientStorageManager) AddIngredient(ingredientParams ingredient.Ingredient) error {
-	query := "SELECT id, quantity FROM ingredients_storage WHERE name = $1;"
+func (ism *ingredientStorageManager) AddIngredient(ctx context.Context, ingredientParams ingredient.Ingredient) error {
+	pantry, args := dialect.HouseholdCondition(ctx, "household_id", []any{ingredientParams.Name})
+	query := "SELECT id, quantity FROM ingredients_storage WHERE name = $1 AND " + pantry + ";"
 
 	var ingredientFound ingredient.Ingredient
-	err := ism.db.QueryRow(query, ingredientParams.Name).Scan(&ingredientFound.Id, &ingredientFound.Quantity)
+	spanCtx, span := dialect.StartQuerySpan(ctx, "SELECT", "ingredients_storage", query)
+	err := sqlstore.Conn(ctx, ism.db).QueryRowContext(spanCtx, query, args...).Scan(&ingredientFound.Id, &ingredientFound.Quantity)
+	sqlstore.EndQuerySpan(span, err)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			query := "INSERT INTO ingredients_storage (name, measure_type, quantity) VALUES ($1, $2, $3)"
-			_, err := ism.db.Exec(query, ingredientParams.Name, ingredientParams.MeasureType, ingredientParams.Quantity)
+			query := "INSERT INTO ingredients_storage (name, measure_type, quantity, household_id) VALUES ($1, $2, $3, $4)"
+			spanCtx, span := dialect.StartQuerySpan(ctx, "INSERT", "ingredients_storage", query)
+			_, err := sqlstore.Conn(ctx, ism.db).ExecContext(spanCtx, query, ingredientParams.Name, ingredientParams.MeasureType, ingredientParams.Quantity, sqlstore.HouseholdID(ctx))
+			sqlstore.EndQuerySpan(span, err)
 			if err != nil {
+				ism.logger.ErrorContext(ctx, "failed to insert ingredient", "name", ingredientParams.Name, "error", err)
 				return fmt.Errorf("failed to add ingredient: %v", err)
 			}
 			return nil
 		}
+		ism.logger.ErrorContext(ctx, "failed to look up ingredient", "name", ingredientParams.Name, "error", err)
 		return fmt.Errorf("error executing query: %v", err)
 	}
 
@@ -35,24 +48,32 @@ func (ism *ingredientStorageManager) AddIngredient(ingredientParams ingredient.I
 
 	query = "UPDATE ingredients_storage SET quantity = $2 WHERE id = $1"
 
-	_, err = ism.db.Exec(query, ingredientFound.Id, newQuantity)
+	spanCtx, span = dialect.StartQuerySpan(ctx, "UPDATE", "ingredients_storage", query)
+	_, err = sqlstore.Conn(ctx, ism.db).ExecContext(spanCtx, query, ingredientFound.Id, newQuantity)
+	sqlstore.EndQuerySpan(span, err)
 	if err != nil {
+		ism.logger.ErrorContext(ctx, "failed to merge ingredient quantity", "id", *ingredientFound.Id, "error", err)
 		return fmt.Errorf("error to update ingredient: %v", err)
 	}
 	return nil
 
 }
 
-func (ism *ingredientStorageManager) FindIngredients() ([]ingredient.Ingredient, error) {
-	query := "SELECT id, name, measure_type, quantity FROM ingredients_storage;"
-	rows, err := ism.db.Query(query)
+func (ism *ingredientStorageManager) FindIngredients(ctx context.Context) (ingredients []ingredient.Ingredient, err error) {
+	pantry, args := dialect.HouseholdCondition(ctx, "household_id", nil)
+	query := "SELECT id, name, measure_type, quantity FROM ingredients_storage WHERE " + pantry + " ORDER BY id;"
+	spanCtx, span := dialect.StartQuerySpan(ctx, "SELECT", "ingredients_storage", query)
+	defer func() { sqlstore.EndQuerySpan(span, err) }()
+
+	rows, err := sqlstore.Conn(ctx, ism.db).QueryContext(spanCtx, query, args...)
 
 	if err != nil {
+		ism.logger.ErrorContext(ctx, "failed to query ingredients", "error", err)
 		return nil, fmt.Errorf("error executing query: %v", err)
 	}
 	defer rows.Close()
 
-	ingredients := []ingredient.Ingredient{}
+	ingredients = []ingredient.Ingredient{}
 
 	for rows.Next() {
 		var ingredient ingredient.Ingredient
@@ -60,29 +81,119 @@ func (ism *ingredientStorageManager) FindIngredients() ([]ingredient.Ingredient,
 		err := rows.Scan(&ingredient.Id, &ingredient.Name, &ingredient.MeasureType, &ingredient.Quantity)
 
 		if err != nil {
+			ism.logger.ErrorContext(ctx, "failed to scan ingredient row", "error", err)
 			return nil, fmt.Errorf("error scanning row: %v", err)
 		}
 
 		ingredients = append(ingredients, ingredient)
 	}
+	if err := rows.Err(); err != nil {
+		ism.logger.ErrorContext(ctx, "failed to iterate ingredient rows", "error", err)
+		return nil, fmt.Errorf("error iterating rows: %v", err)
+	}
 	return ingredients, nil
 }
 
-func (ism *ingredientStorageManager) Update(ingredientParams ingredient.Ingredient) error {
-	query := "UPDATE ingredients_storage SET name = $1, quantity = $2, measure_type = $3 WHERE id = $4"
-	_, err := ism.db.Exec(query, ingredientParams.Name, ingredientParams.Quantity, ingredientParams.MeasureType, ingredientParams.Id)
+func (ism *ingredientStorageManager) CountIngredients(ctx context.Context) (count int, err error) {
+	pantry, args := dialect.HouseholdCondition(ctx, "household_id", nil)
+	query := "SELECT count(*) FROM ingredients_storage WHERE " + pantry
+	spanCtx, span := dialect.StartQuerySpan(ctx, "SELECT", "ingredients_storage", query)
+	defer func() { sqlstore.EndQuerySpan(span, err) }()
+
+	if err = sqlstore.Conn(ctx, ism.db).QueryRowContext(spanCtx, query, args...).Scan(&count); err != nil {
+		ism.logger.ErrorContext(ctx, "failed to count ingredients", "error", err)
+		return 0, fmt.Errorf("error counting ingredients: %v", err)
+	}
+	return count, nil
+}
+
+func (ism *ingredientStorageManager) ListIngredients(ctx context.Context, opts domain.ListOptions) (page domain.Page[ingredient.Ingredient], err error) {
+	pantry, args := dialect.HouseholdCondition(ctx, "household_id", nil)
+	clauses, args, err := dialect.ListClauses(pantry, args, "", opts, domain.SortByName, domain.SortByCreated, domain.SortByQuantity)
+	if err != nil {
+		return page, err
+	}
+
+	query := "SELECT id, name, measure_type, quantity, created_at FROM ingredients_storage" + clauses
+	spanCtx, span := dialect.StartQuerySpan(ctx, "SELECT", "ingredients_storage", query)
+	defer func() { sqlstore.EndQuerySpan(span, err) }()
+
+	rows, err := sqlstore.Conn(ctx, ism.db).QueryContext(spanCtx, query, args...)
+	if err != nil {
+		ism.logger.ErrorContext(ctx, "failed to list ingredients", "error", err)
+		return page, fmt.Errorf("error executing query: %v", err)
+	}
+	defer rows.Close()
+
+	type listed struct {
+		ingredient ingredient.Ingredient
+		createdAt  time.Time
+	}
+	var found []listed
+	for rows.Next() {
+		var row listed
+		err := rows.Scan(&row.ingredient.Id, &row.ingredient.Name, &row.ingredient.MeasureType, &row.ingredient.Quantity, &row.createdAt)
+		if err != nil {
+			ism.logger.ErrorContext(ctx, "failed to scan ingredient row", "error", err)
+			return page, fmt.Errorf("error scanning row: %v", err)
+		}
+		found = append(found, row)
+	}
+	if err := rows.Err(); err != nil {
+		return page, fmt.Errorf("error iterating rows: %v", err)
+	}
+
+	trimmed := sqlstore.TrimPage(found, opts.Limit, func(row listed) domain.Cursor {
+		key := sqlstore.CursorKey(opts.SortOrDefault(), row.ingredient.Name, row.ingredient.Quantity, row.createdAt)
+		return domain.Cursor{Key: key, ID: *row.ingredient.Id}
+	})
+	page.Next = trimmed.Next
+	page.Items = make([]ingredient.Ingredient, len(trimmed.Items))
+	for i, row := range trimmed.Items {
+		page.Items[i] = row.ingredient
+	}
+	return page, nil
+}
+
+func (ism *ingredientStorageManager) Update(ctx context.Context, ingredientParams ingredient.Ingredient) error {
+	pantry, args := dialect.HouseholdCondition(ctx, "household_id", []any{ingredientParams.Name, ingredientParams.Quantity, ingredientParams.MeasureType, ingredientParams.Id})
+	query := "UPDATE ingredients_storage SET name = $1, quantity = $2, measure_type = $3 WHERE id = $4 AND " + pantry
+	spanCtx, span := dialect.StartQuerySpan(ctx, "UPDATE", "ingredients_storage", query)
+	result, err := sqlstore.Conn(ctx, ism.db).ExecContext(spanCtx, query, args...)
+	sqlstore.EndQuerySpan(span, err)
 	if err != nil {
+		ism.logger.ErrorContext(ctx, "failed to update ingredient", "error", err)
 		return fmt.Errorf("error to update ingredient: %v", err)
 	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return domain.NewNotFound("ingredient", *ingredientParams.Id)
+	}
 	return nil
 }
 
-func (ism *ingredientStorageManager) Delete(id uint) error {
-	query := "DELETE from ingredients_storage WHERE id = $1"
-	_, err := ism.db.Exec(query, id)
+func (ism *ingredientStorageManager) Delete(ctx context.Context, id uint) error {
+	pantry, args := dialect.HouseholdCondition(ctx, "household_id", []any{id})
+	query := "DELETE from ingredients_storage WHERE id = $1 AND " + pantry
+	spanCtx, span := dialect.StartQuerySpan(ctx, "DELETE", "ingredients_storage", query)
+	result, err := sqlstore.Conn(ctx, ism.db).ExecContext(spanCtx, query, args...)
+	sqlstore.EndQuerySpan(span, err)
 
 	if err != nil {
+		ism.logger.ErrorContext(ctx, "failed to delete ingredient", "id", id, "error", err)
 		return fmt.Errorf("error to delete ingredient: %v", err)
 	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return domain.NewNotFound("ingredient", id)
+	}
 	return nil
 }
@@ -1,124 +1,374 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"log/slog"
+	"q-q-tem-pra-hoje/internal/domain"
 	"q-q-tem-pra-hoje/internal/domain/ingredient"
 	"q-q-tem-pra-hoje/internal/domain/recipe"
+	"q-q-tem-pra-hoje/internal/repository/sqlstore"
+	"time"
+
+	"github.com/lib/pq"
 )
 
 type recipeManager struct {
 	*sql.DB
+	logger *slog.Logger
 }
 
-func NewRecipeManager(db *sql.DB) *recipeManager {
-	return &recipeManager{db}
+func NewRecipeManager(db *sql.DB, logger *slog.Logger) *recipeManager {
+	return &recipeManager{db, logger}
 }
 
-func (rm recipeManager) AddRecipe(recipe recipe.Recipe) error {
+func (rm recipeManager) AddRecipe(ctx context.Context, recipe recipe.Recipe) error {
+	tx, err := sqlstore.BeginLocal(ctx, rm.DB)
+	if err != nil {
+		rm.logger.ErrorContext(ctx, "failed to begin recipe insert", "name", recipe.Name, "error", err)
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
 	var recipeId int
-	err := rm.QueryRow("INSERT INTO recipes (name) VALUES ($1) RETURNING id;", recipe.Name).Scan(&recipeId)
+	query := "INSERT INTO recipes (name, user_id, household_id, visibility) VALUES ($1, $2, $3, $4) RETURNING id;"
+	spanCtx, span := dialect.StartQuerySpan(ctx, "INSERT", "recipes", query)
+	err = tx.QueryRowContext(spanCtx, query, recipe.Name, sqlstore.OwnerID(ctx), sqlstore.HouseholdID(ctx), recipe.VisibilityOrDefault()).Scan(&recipeId)
+	sqlstore.EndQuerySpan(span, err)
 	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.NewConflict("recipe", fmt.Sprintf("a recipe named %q already exists", recipe.Name))
+		}
+		rm.logger.ErrorContext(ctx, "failed to insert recipe", "name", recipe.Name, "error", err)
 		return fmt.Errorf("failed to insert recipe: %v", err)
 	}
 
-	for _, ing := range recipe.Ingredients {
-		_, err = rm.Exec(`
+	query = `
 		      INSERT INTO recipes_ingredients (recipe_id, name, measure_type, quantity)
 		      VALUES ($1, $2, $3, $4)
 		      ON CONFLICT (recipe_id, name) DO NOTHING;
-		  `, recipeId, ing.Name, ing.MeasureType, ing.Quantity)
+		  `
+	for _, ing := range recipe.Ingredients {
+		spanCtx, span := dialect.StartQuerySpan(ctx, "INSERT", "recipes_ingredients", query)
+		_, err = tx.ExecContext(spanCtx, query, recipeId, ing.Name, ing.MeasureType, ing.Quantity)
+		sqlstore.EndQuerySpan(span, err)
 		if err != nil {
+			rm.logger.ErrorContext(ctx, "failed to insert recipe ingredient", "recipe_id", recipeId, "ingredient", ing.Name, "error", err)
 			return fmt.Errorf("failed to insert a recipe ingredient: %v", err)
 		}
 	}
+
+	if err := tx.Commit(); err != nil {
+		rm.logger.ErrorContext(ctx, "failed to commit recipe", "recipe_id", recipeId, "error", err)
+		return fmt.Errorf("failed to commit recipe: %v", err)
+	}
 	return nil
 }
-func (rm recipeManager) GetAllRecipes() ([]recipe.Recipe, error) {
-	rows, err := rm.Query(`SELECT 
+
+func (rm recipeManager) UpsertRecipe(ctx context.Context, recipe recipe.Recipe) (added bool, err error) {
+	tx, err := sqlstore.BeginLocal(ctx, rm.DB)
+	if err != nil {
+		rm.logger.ErrorContext(ctx, "failed to begin recipe upsert", "name", recipe.Name, "error", err)
+		return false, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var recipeId int
+	query := `
+		INSERT INTO recipes (name, user_id, household_id, visibility) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, name) DO UPDATE SET household_id = EXCLUDED.household_id, visibility = EXCLUDED.visibility
+		RETURNING id, xmax = 0;
+	`
+	spanCtx, span := dialect.StartQuerySpan(ctx, "INSERT", "recipes", query)
+	err = tx.QueryRowContext(spanCtx, query, recipe.Name, sqlstore.OwnerID(ctx), sqlstore.HouseholdID(ctx), recipe.VisibilityOrDefault()).Scan(&recipeId, &added)
+	sqlstore.EndQuerySpan(span, err)
+	if err != nil {
+		rm.logger.ErrorContext(ctx, "failed to upsert recipe", "name", recipe.Name, "error", err)
+		return false, fmt.Errorf("failed to upsert recipe: %v", err)
+	}
+
+	query = "DELETE FROM recipes_ingredients WHERE recipe_id = $1"
+	spanCtx, span = dialect.StartQuerySpan(ctx, "DELETE", "recipes_ingredients", query)
+	_, err = tx.ExecContext(spanCtx, query, recipeId)
+	sqlstore.EndQuerySpan(span, err)
+	if err != nil {
+		rm.logger.ErrorContext(ctx, "failed to delete recipe ingredients", "recipe_id", recipeId, "error", err)
+		return false, fmt.Errorf("failed to delete recipe ingredients: %v", err)
+	}
+
+	query = `
+		INSERT INTO recipes_ingredients (recipe_id, name, measure_type, quantity)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (recipe_id, name) DO NOTHING;
+	`
+	for _, ing := range recipe.Ingredients {
+		spanCtx, span := dialect.StartQuerySpan(ctx, "INSERT", "recipes_ingredients", query)
+		_, err = tx.ExecContext(spanCtx, query, recipeId, ing.Name, ing.MeasureType, ing.Quantity)
+		sqlstore.EndQuerySpan(span, err)
+		if err != nil {
+			rm.logger.ErrorContext(ctx, "failed to insert recipe ingredient", "recipe_id", recipeId, "ingredient", ing.Name, "error", err)
+			return false, fmt.Errorf("failed to insert a recipe ingredient: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		rm.logger.ErrorContext(ctx, "failed to commit recipe upsert", "recipe_id", recipeId, "error", err)
+		return false, fmt.Errorf("failed to commit recipe: %v", err)
+	}
+	return added, nil
+}
+
+const selectRecipes = `SELECT 
                           r.id,
                           r.name, 
+                          r.visibility,
+                          r.user_id,
                           i.name, 
                           i.measure_type, 
                           i.quantity 
                         FROM recipes r 
-                          LEFT JOIN recipes_ingredients i ON r.id = i.recipe_id`)
+                          LEFT JOIN recipes_ingredients i ON r.id = i.recipe_id`
+
+func (rm recipeManager) GetAllRecipes(ctx context.Context) (recipes []recipe.Recipe, err error) {
+	visible, args := dialect.VisibleRecipes(ctx, "r.", nil)
+	query := selectRecipes + " WHERE " + visible + " ORDER BY r.id, i.name"
+	spanCtx, span := dialect.StartQuerySpan(ctx, "SELECT", "recipes", query)
+	defer func() { sqlstore.EndQuerySpan(span, err) }()
+
+	rows, err := sqlstore.Conn(ctx, rm.DB).QueryContext(spanCtx, query, args...)
 
 	if err != nil {
+		rm.logger.ErrorContext(ctx, "failed to query recipes", "error", err)
 		return nil, fmt.Errorf("error querying recipes: %w", err)
 	}
 
 	defer rows.Close()
 
-	recipeMap := make(map[string]*recipe.Recipe)
+	return rm.scanRecipes(ctx, rows)
+}
+
+func (rm recipeManager) CountRecipes(ctx context.Context) (count int, err error) {
+	visible, args := dialect.VisibleRecipes(ctx, "r.", nil)
+	query := "SELECT count(*) FROM recipes r WHERE " + visible
+	spanCtx, span := dialect.StartQuerySpan(ctx, "SELECT", "recipes", query)
+	defer func() { sqlstore.EndQuerySpan(span, err) }()
+
+	if err = sqlstore.Conn(ctx, rm.DB).QueryRowContext(spanCtx, query, args...).Scan(&count); err != nil {
+		rm.logger.ErrorContext(ctx, "failed to count recipes", "error", err)
+		return 0, fmt.Errorf("error counting recipes: %w", err)
+	}
+	return count, nil
+}
+
+func (rm recipeManager) GetRecipe(ctx context.Context, id uint) (found recipe.Recipe, err error) {
+	visible, args := dialect.VisibleRecipes(ctx, "r.", []any{id})
+	query := selectRecipes + " WHERE r.id = $1 AND " + visible + " ORDER BY i.name"
+	spanCtx, span := dialect.StartQuerySpan(ctx, "SELECT", "recipes", query)
+	defer func() { sqlstore.EndQuerySpan(span, err) }()
+
+	rows, err := sqlstore.Conn(ctx, rm.DB).QueryContext(spanCtx, query, args...)
+	if err != nil {
+		rm.logger.ErrorContext(ctx, "failed to query recipe", "recipe_id", id, "error", err)
+		return recipe.Recipe{}, fmt.Errorf("error querying recipe: %w", err)
+	}
+
+	defer rows.Close()
+
+	recipes, err := rm.scanRecipes(ctx, rows)
+	if err != nil {
+		return recipe.Recipe{}, err
+	}
+	if len(recipes) == 0 {
+		return recipe.Recipe{}, domain.NewNotFound("recipe", id)
+	}
+	return recipes[0], nil
+}
+
+// scanRecipes folds the one-row-per-ingredient result of selectRecipes into
+// recipes, keeping the order of the rows.
+func (rm recipeManager) scanRecipes(ctx context.Context, rows *sql.Rows) ([]recipe.Recipe, error) {
+	recipesRetrieved := []recipe.Recipe{}
+	positions := make(map[int]int)
 
 	for rows.Next() {
 		var recipeId int
 		var recipeName string
+		var visibility recipe.Visibility
+		var userID sql.NullInt64
 		var ingredientName sql.NullString
 		var measureType sql.NullString
 		var quantity sql.NullInt64
 
-		err := rows.Scan(&recipeId, &recipeName, &ingredientName, &measureType, &quantity)
+		err := rows.Scan(&recipeId, &recipeName, &visibility, &userID, &ingredientName, &measureType, &quantity)
 		if err != nil {
-			fmt.Printf("failed to scan row: %v", err)
+			rm.logger.ErrorContext(ctx, "failed to scan recipe row", "error", err)
 			return nil, fmt.Errorf("failed to scan row: %v", err)
 		}
 
-		ingredientFound := ingredient.NewIngredient(nil, ingredientName.String, measureType.String, int(quantity.Int64))
-
-		if r, exists := recipeMap[recipeName]; exists {
-			r.Ingredients = append(r.Ingredients, ingredientFound)
-		} else {
-			newRecipe := &recipe.Recipe{
-				Id:   &recipeId,
-				Name: recipeName,
-			}
-			if ingredientName.Valid {
-				newRecipe.Ingredients = []ingredient.Ingredient{ingredientFound}
-
-			} else {
-				newRecipe.Ingredients = []ingredient.Ingredient{}
-			}
-			recipeMap[recipeName] = newRecipe
+		position, exists := positions[recipeId]
+		if !exists {
+			id := recipeId
+			recipesRetrieved = append(recipesRetrieved, recipe.Recipe{Id: &id, Name: recipeName, Ingredients: []ingredient.Ingredient{}, Visibility: visibility, UserId: nullableID(userID)})
+			position = len(recipesRetrieved) - 1
+			positions[recipeId] = position
+		}
+
+		if ingredientName.Valid {
+			ingredientFound := ingredient.NewIngredient(nil, ingredientName.String, measureType.String, int(quantity.Int64))
+			recipesRetrieved[position].Ingredients = append(recipesRetrieved[position].Ingredients, ingredientFound)
 		}
 	}
 
 	if err := rows.Err(); err != nil {
-		fmt.Printf("error iterating rows: %v", err)
+		rm.logger.ErrorContext(ctx, "failed to iterate recipe rows", "error", err)
 		return nil, fmt.Errorf("error iterating rows: %v", err)
 	}
 
-	recipesRetrieved := []recipe.Recipe{}
-	for _, r := range recipeMap {
-		recipesRetrieved = append(recipesRetrieved, *r)
+	return recipesRetrieved, nil
+}
+
+func (rm recipeManager) ListRecipes(ctx context.Context, opts domain.ListOptions) (page domain.Page[recipe.Recipe], err error) {
+	visible, args := dialect.VisibleRecipes(ctx, "r.", nil)
+	clauses, args, err := dialect.ListClauses(visible, args, "r.", opts, domain.SortByName, domain.SortByCreated)
+	if err != nil {
+		return page, err
 	}
 
-	return recipesRetrieved, nil
+	found, err := rm.listRecipeRows(ctx, clauses, args)
+	if err != nil {
+		return page, err
+	}
+
+	trimmed := sqlstore.TrimPage(found, opts.Limit, func(row listedRecipe) domain.Cursor {
+		key := sqlstore.CursorKey(opts.SortOrDefault(), row.recipe.Name, 0, row.createdAt)
+		return domain.Cursor{Key: key, ID: *row.recipe.Id}
+	})
+	page.Next = trimmed.Next
+	page.Items = make([]recipe.Recipe, len(trimmed.Items))
+	for i, row := range trimmed.Items {
+		page.Items[i] = row.recipe
+	}
+
+	if err := rm.loadIngredients(ctx, page.Items); err != nil {
+		return domain.Page[recipe.Recipe]{}, err
+	}
+	return page, nil
 }
 
-func (rm recipeManager) DeleteRecipe(id uint) error {
-	_, err := rm.Exec(`
+type listedRecipe struct {
+	recipe    recipe.Recipe
+	createdAt time.Time
+}
+
+func (rm recipeManager) listRecipeRows(ctx context.Context, clauses string, args []any) (found []listedRecipe, err error) {
+	query := "SELECT r.id, r.name, r.visibility, r.user_id, r.created_at FROM recipes r" + clauses
+	spanCtx, span := dialect.StartQuerySpan(ctx, "SELECT", "recipes", query)
+	defer func() { sqlstore.EndQuerySpan(span, err) }()
+
+	rows, err := sqlstore.Conn(ctx, rm.DB).QueryContext(spanCtx, query, args...)
+	if err != nil {
+		rm.logger.ErrorContext(ctx, "failed to list recipes", "error", err)
+		return nil, fmt.Errorf("error querying recipes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row listedRecipe
+		var id int
+		var userID sql.NullInt64
+		if err := rows.Scan(&id, &row.recipe.Name, &row.recipe.Visibility, &userID, &row.createdAt); err != nil {
+			rm.logger.ErrorContext(ctx, "failed to scan recipe row", "error", err)
+			return nil, fmt.Errorf("failed to scan row: %v", err)
+		}
+		row.recipe.Id = &id
+		row.recipe.UserId = nullableID(userID)
+		row.recipe.Ingredients = []ingredient.Ingredient{}
+		found = append(found, row)
+	}
+	return found, rows.Err()
+}
+
+// loadIngredients fills the ingredients of recipes with a single query.
+func (rm recipeManager) loadIngredients(ctx context.Context, recipes []recipe.Recipe) (err error) {
+	if len(recipes) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(recipes))
+	positions := make(map[int]int, len(recipes))
+	for i, r := range recipes {
+		ids[i] = int64(*r.Id)
+		positions[*r.Id] = i
+	}
+
+	query := "SELECT recipe_id, name, measure_type, quantity FROM recipes_ingredients WHERE recipe_id = ANY($1) ORDER BY recipe_id, name"
+	spanCtx, span := dialect.StartQuerySpan(ctx, "SELECT", "recipes_ingredients", query)
+	defer func() { sqlstore.EndQuerySpan(span, err) }()
+
+	rows, err := sqlstore.Conn(ctx, rm.DB).QueryContext(spanCtx, query, pq.Array(ids))
+	if err != nil {
+		rm.logger.ErrorContext(ctx, "failed to query recipe ingredients", "error", err)
+		return fmt.Errorf("error querying recipe ingredients: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var recipeId int
+		var ing ingredient.Ingredient
+		if err := rows.Scan(&recipeId, &ing.Name, &ing.MeasureType, &ing.Quantity); err != nil {
+			rm.logger.ErrorContext(ctx, "failed to scan recipe ingredient row", "error", err)
+			return fmt.Errorf("failed to scan row: %v", err)
+		}
+		position := positions[recipeId]
+		recipes[position].Ingredients = append(recipes[position].Ingredients, ing)
+	}
+	return rows.Err()
+}
+
+// DeleteRecipe only deletes recipes of the user ctx acts for; shared ones
+// are not the readers' to delete.
+func (rm recipeManager) DeleteRecipe(ctx context.Context, id uint) error {
+	owner, args := dialect.OwnerCondition(ctx, "user_id", []any{id})
+	query := `
 		DELETE FROM recipes_ingredients 
-		WHERE recipe_id IN (SELECT id FROM recipes WHERE id = $1)
-	`, id)
+		WHERE recipe_id IN (SELECT id FROM recipes WHERE id = $1 AND ` + owner + `)
+	`
+	spanCtx, span := dialect.StartQuerySpan(ctx, "DELETE", "recipes_ingredients", query)
+	_, err := sqlstore.Conn(ctx, rm.DB).ExecContext(spanCtx, query, args...)
+	sqlstore.EndQuerySpan(span, err)
 	if err != nil {
+		rm.logger.ErrorContext(ctx, "failed to delete recipe ingredients", "recipe_id", id, "error", err)
 		return fmt.Errorf("failed to delete recipe ingredients: %v", err)
 	}
 
-	result, err := rm.Exec("DELETE FROM recipes WHERE id = $1", id)
+	query = "DELETE FROM recipes WHERE id = $1 AND " + owner
+	spanCtx, span = dialect.StartQuerySpan(ctx, "DELETE", "recipes", query)
+	result, err := sqlstore.Conn(ctx, rm.DB).ExecContext(spanCtx, query, args...)
+	sqlstore.EndQuerySpan(span, err)
 	if err != nil {
+		rm.logger.ErrorContext(ctx, "failed to delete recipe", "recipe_id", id, "error", err)
 		return fmt.Errorf("failed to delete recipe: %v", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
+		rm.logger.ErrorContext(ctx, "failed to check deleted recipe rows", "recipe_id", id, "error", err)
 		return fmt.Errorf("failed to check rows affected: %v", err)
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("recipe not found")
+		return domain.NewNotFound("recipe", id)
 	}
 
 	return nil
 }
+
+// nullableID converts a nullable id column, such as the author of a recipe.
+func nullableID(id sql.NullInt64) *int {
+	if !id.Valid {
+		return nil
+	}
+	value := int(id.Int64)
+	return &value
+}
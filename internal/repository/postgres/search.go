@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"q-q-tem-pra-hoje/internal/domain/search"
+	"q-q-tem-pra-hoje/internal/repository/sqlstore"
+)
+
+type searchManager struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewSearchManager(db *sql.DB, logger *slog.Logger) *searchManager {
+	return &searchManager{db, logger}
+}
+
+// searchHits matches names either through Portuguese full-text search or,
+// to tolerate typos, through trigram word similarity. Both sides are
+// unaccented so "berinjela" finds "Berinjela à parmegiana" and "abobrinha"
+// finds "abóbrinha". The expressions must match the indexes created by
+// migration 000004.
+const searchHits = `
+WITH query AS (
+    SELECT websearch_to_tsquery('portuguese', immutable_unaccent($1)) AS tsq,
+           immutable_unaccent(lower($1)) AS term
+)
+SELECT kind, id, name, rank FROM (
+    SELECT 'recipe' AS kind, r.id, r.name, r.user_id, r.household_id, r.visibility,
+           ts_rank(to_tsvector('portuguese', immutable_unaccent(r.name)), q.tsq)
+               + word_similarity(q.term, immutable_unaccent(lower(r.name))) AS rank
+    FROM recipes r, query q
+    WHERE to_tsvector('portuguese', immutable_unaccent(r.name)) @@ q.tsq
+       OR q.term <% immutable_unaccent(lower(r.name))
+    UNION ALL
+    SELECT 'ingredient' AS kind, i.id, i.name, NULL, i.household_id, NULL,
+           ts_rank(to_tsvector('portuguese', immutable_unaccent(i.name)), q.tsq)
+               + word_similarity(q.term, immutable_unaccent(lower(i.name))) AS rank
+    FROM ingredients_storage i, query q
+    WHERE to_tsvector('portuguese', immutable_unaccent(i.name)) @@ q.tsq
+       OR q.term <% immutable_unaccent(lower(i.name))
+) hits`
+
+func (sm *searchManager) Search(ctx context.Context, query string, limit int) (results []search.Result, err error) {
+	recipes, args := dialect.VisibleRecipes(ctx, "hits.", []any{query, limit})
+	pantry, args := dialect.HouseholdCondition(ctx, "hits.household_id", args)
+	scope := "(hits.kind = 'recipe' AND " + recipes + ") OR (hits.kind = 'ingredient' AND " + pantry + ")"
+	statement := searchHits + " WHERE " + scope + " ORDER BY rank DESC, name, id LIMIT $2;"
+	spanCtx, span := dialect.StartQuerySpan(ctx, "SELECT", "recipes", statement)
+	defer func() { sqlstore.EndQuerySpan(span, err) }()
+
+	rows, err := sm.db.QueryContext(spanCtx, statement, args...)
+	if err != nil {
+		sm.logger.ErrorContext(ctx, "failed to search", "query", query, "error", err)
+		return nil, fmt.Errorf("error executing query: %v", err)
+	}
+	defer rows.Close()
+
+	results = []search.Result{}
+	for rows.Next() {
+		var result search.Result
+		if err := rows.Scan(&result.Kind, &result.Id, &result.Name, &result.Rank); err != nil {
+			sm.logger.ErrorContext(ctx, "failed to scan search row", "error", err)
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,159 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/apikey"
+	"q-q-tem-pra-hoje/internal/repository/sqlstore"
+	"time"
+)
+
+type apiKeyManager struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewAPIKeyManager(db *sql.DB, logger *slog.Logger) *apiKeyManager {
+	return &apiKeyManager{db, logger}
+}
+
+const apiKeyColumns = "id, user_id, household_id, name, prefix, key_hash, scopes, created_at, last_used_at"
+
+func (km *apiKeyManager) AddKey(ctx context.Context, key apikey.Key) (apikey.Key, error) {
+	scopes, err := json.Marshal(key.Scopes)
+	if err != nil {
+		return apikey.Key{}, fmt.Errorf("failed to encode api key scopes: %v", err)
+	}
+
+	query := `INSERT INTO api_keys (user_id, household_id, name, prefix, key_hash, scopes)
+	          VALUES (?, ?, ?, ?, ?, ?)
+	          RETURNING ` + apiKeyColumns
+	spanCtx, span := dialect.StartQuerySpan(ctx, "INSERT", "api_keys", query)
+	row := km.db.QueryRowContext(spanCtx, query, key.UserId, key.HouseholdId, key.Name, key.Prefix, key.Hash, string(scopes))
+	created, err := scanKey(row)
+	sqlstore.EndQuerySpan(span, err)
+	if err != nil {
+		km.logger.ErrorContext(ctx, "failed to insert api key", "user_id", key.UserId, "error", err)
+		return apikey.Key{}, fmt.Errorf("failed to insert api key: %v", err)
+	}
+	return created, nil
+}
+
+func (km *apiKeyManager) FindKeys(ctx context.Context, userID int) (keys []apikey.Key, err error) {
+	query := "SELECT " + apiKeyColumns + " FROM api_keys WHERE user_id = ? ORDER BY id"
+	spanCtx, span := dialect.StartQuerySpan(ctx, "SELECT", "api_keys", query)
+	defer func() { sqlstore.EndQuerySpan(span, err) }()
+
+	rows, err := km.db.QueryContext(spanCtx, query, userID)
+	if err != nil {
+		km.logger.ErrorContext(ctx, "failed to query api keys", "user_id", userID, "error", err)
+		return nil, fmt.Errorf("error executing query: %v", err)
+	}
+	defer rows.Close()
+
+	keys = []apikey.Key{}
+	for rows.Next() {
+		key, err := scanKey(rows)
+		if err != nil {
+			km.logger.ErrorContext(ctx, "failed to scan api key row", "error", err)
+			return nil, fmt.Errorf("error scanning row: %v", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (km *apiKeyManager) FindKeyByHash(ctx context.Context, hash string) (found apikey.Key, err error) {
+	query := "SELECT " + apiKeyColumns + " FROM api_keys WHERE key_hash = ?"
+	spanCtx, span := dialect.StartQuerySpan(ctx, "SELECT", "api_keys", query)
+	defer func() { sqlstore.EndQuerySpan(span, err) }()
+
+	found, err = scanKey(km.db.QueryRowContext(spanCtx, query, hash))
+	if err == sql.ErrNoRows {
+		return apikey.Key{}, domain.NewNotFound("api key", "")
+	}
+	if err != nil {
+		km.logger.ErrorContext(ctx, "failed to query api key", "error", err)
+		return apikey.Key{}, fmt.Errorf("error executing query: %v", err)
+	}
+	return found, nil
+}
+
+func (km *apiKeyManager) ReplaceHash(ctx context.Context, id int, userID int, prefix string, hash string) (rotated apikey.Key, err error) {
+	query := "UPDATE api_keys SET prefix = ?, key_hash = ? WHERE id = ? AND user_id = ? RETURNING " + apiKeyColumns
+	spanCtx, span := dialect.StartQuerySpan(ctx, "UPDATE", "api_keys", query)
+	defer func() { sqlstore.EndQuerySpan(span, err) }()
+
+	rotated, err = scanKey(km.db.QueryRowContext(spanCtx, query, prefix, hash, id, userID))
+	if err == sql.ErrNoRows {
+		return apikey.Key{}, domain.NewNotFound("api key", id)
+	}
+	if err != nil {
+		km.logger.ErrorContext(ctx, "failed to rotate api key", "key_id", id, "error", err)
+		return apikey.Key{}, fmt.Errorf("failed to rotate api key: %v", err)
+	}
+	return rotated, nil
+}
+
+func (km *apiKeyManager) DeleteKey(ctx context.Context, id int, userID int) error {
+	query := "DELETE FROM api_keys WHERE id = ? AND user_id = ?"
+	spanCtx, span := dialect.StartQuerySpan(ctx, "DELETE", "api_keys", query)
+	result, err := km.db.ExecContext(spanCtx, query, id, userID)
+	sqlstore.EndQuerySpan(span, err)
+	if err != nil {
+		km.logger.ErrorContext(ctx, "failed to delete api key", "key_id", id, "error", err)
+		return fmt.Errorf("failed to delete api key: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return domain.NewNotFound("api key", id)
+	}
+	return nil
+}
+
+func (km *apiKeyManager) TouchKey(ctx context.Context, id int, usedAt time.Time) error {
+	query := "UPDATE api_keys SET last_used_at = ? WHERE id = ?"
+	spanCtx, span := dialect.StartQuerySpan(ctx, "UPDATE", "api_keys", query)
+	_, err := km.db.ExecContext(spanCtx, query, formatTime(usedAt), id)
+	sqlstore.EndQuerySpan(span, err)
+	if err != nil {
+		return fmt.Errorf("failed to touch api key: %v", err)
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanKey(row rowScanner) (apikey.Key, error) {
+	var key apikey.Key
+	var scopes, createdAt string
+	var lastUsedAt sql.NullString
+	err := row.Scan(&key.Id, &key.UserId, &key.HouseholdId, &key.Name, &key.Prefix, &key.Hash, &scopes, &createdAt, &lastUsedAt)
+	if err != nil {
+		return apikey.Key{}, err
+	}
+	if err := json.Unmarshal([]byte(scopes), &key.Scopes); err != nil {
+		return apikey.Key{}, fmt.Errorf("failed to decode api key scopes: %v", err)
+	}
+	if key.CreatedAt, err = parseTime(createdAt); err != nil {
+		return apikey.Key{}, err
+	}
+	if lastUsedAt.Valid {
+		usedAt, err := parseTime(lastUsedAt.String)
+		if err != nil {
+			return apikey.Key{}, err
+		}
+		key.LastUsedAt = &usedAt
+	}
+	return key, nil
+}
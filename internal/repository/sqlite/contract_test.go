@@ -0,0 +1,62 @@
+package sqlite_test
+
+import (
+	"log/slog"
+	"q-q-tem-pra-hoje/internal/repository/in_memory_repository"
+	"q-q-tem-pra-hoje/internal/repository/sqlite"
+	"q-q-tem-pra-hoje/internal/testutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+func newBackend(t *testing.T) testutil.Backend {
+	db := testutil.NewSQLiteDB(t)
+	ism := sqlite.NewIngredientStorageManager(db, discardLogger)
+	rm := sqlite.NewRecipeManager(db, discardLogger)
+	return testutil.Backend{
+		Ingredients: ism,
+		Recipes:     rm,
+		// The SQLite storage searches with the in-memory matcher.
+		Search:     in_memory_repository.NewSearchManager(ism, rm),
+		Users:      sqlite.NewUserManager(db, discardLogger),
+		Sessions:   sqlite.NewSessionManager(db, discardLogger),
+		Households: sqlite.NewHouseholdManager(db, discardLogger),
+		APIKeys:    sqlite.NewAPIKeyManager(db, discardLogger),
+		Transactor: sqlite.NewTransactor(db, discardLogger),
+		BreakRecipeIngredients: func(t *testing.T) {
+			_, err := db.Exec("CREATE TRIGGER break_recipe_ingredients BEFORE INSERT ON recipes_ingredients BEGIN SELECT RAISE(ABORT, 'storage is down'); END")
+			require.NoError(t, err)
+		},
+	}
+}
+
+func TestIngredientStorageManager_Contract(t *testing.T) {
+	testutil.RunIngredientStorageContract(t, newBackend)
+}
+
+func TestRecipeManager_Contract(t *testing.T) {
+	testutil.RunRecipeContract(t, newBackend)
+}
+
+func TestTransactor_Contract(t *testing.T) {
+	testutil.RunTransactionContract(t, newBackend)
+}
+
+func TestUsers_Contract(t *testing.T) {
+	testutil.RunUserContract(t, newBackend)
+}
+
+func TestHouseholds_Contract(t *testing.T) {
+	testutil.RunHouseholdContract(t, newBackend)
+}
+
+func TestAPIKeys_Contract(t *testing.T) {
+	testutil.RunAPIKeyContract(t, newBackend)
+}
+
+func TestBackup_Contract(t *testing.T) {
+	testutil.RunBackupContract(t, newBackend)
+}
@@ -0,0 +1,21 @@
+package sqlite
+
+import (
+	"q-q-tem-pra-hoje/internal/repository/sqlstore"
+	"time"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// dialect uses positional placeholders, so the arguments of the conditions
+// it builds are appended to args in the order the conditions appear in the
+// query.
+var dialect = sqlstore.Dialect{
+	System:      semconv.DBSystemSqlite,
+	Placeholder: func(n int) string { return "?" },
+	// LIKE ignores the case of ASCII letters only, unlike ILIKE.
+	NameLike: func(column, placeholder string) string {
+		return column + " LIKE " + placeholder + ` ESCAPE '\'`
+	},
+	TimeArg: func(t time.Time) any { return formatTime(t) },
+}
@@ -0,0 +1,15 @@
+package sqlite
+
+import "time"
+
+// timeLayout is how the schema stores times: UTC text with millisecond
+// precision, which sorts the way the times compare.
+const timeLayout = "2006-01-02T15:04:05.000Z"
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+func parseTime(s string) (time.Time, error) {
+	return time.Parse(timeLayout, s)
+}
@@ -0,0 +1,14 @@
+package sqlite
+
+import (
+	"database/sql"
+	"log/slog"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/repository/sqlstore"
+)
+
+// NewTransactor runs transactions the ingredient and recipe repositories of
+// db take part in.
+func NewTransactor(db *sql.DB, logger *slog.Logger) domain.Transactor {
+	return sqlstore.NewTransactor(db, logger)
+}
@@ -0,0 +1,113 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/user"
+	"q-q-tem-pra-hoje/internal/repository/sqlstore"
+)
+
+type userManager struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewUserManager(db *sql.DB, logger *slog.Logger) *userManager {
+	return &userManager{db, logger}
+}
+
+func (um *userManager) AddUser(ctx context.Context, u user.User) (user.User, error) {
+	query := "INSERT INTO users (email, password_hash) VALUES (?, ?) RETURNING id"
+	spanCtx, span := dialect.StartQuerySpan(ctx, "INSERT", "users", query)
+	err := um.db.QueryRowContext(spanCtx, query, u.Email, u.PasswordHash).Scan(&u.Id)
+	sqlstore.EndQuerySpan(span, err)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return user.User{}, domain.NewConflict("user", fmt.Sprintf("an account for %q already exists", u.Email))
+		}
+		um.logger.ErrorContext(ctx, "failed to insert user", "error", err)
+		return user.User{}, fmt.Errorf("failed to insert user: %v", err)
+	}
+	return u, nil
+}
+
+func (um *userManager) FindUser(ctx context.Context, id int) (user.User, error) {
+	return um.findUser(ctx, "SELECT id, email, password_hash FROM users WHERE id = ?", id)
+}
+
+// FindUserByEmail ignores the case of ASCII letters only, as SQLite's lower
+// does.
+func (um *userManager) FindUserByEmail(ctx context.Context, email string) (user.User, error) {
+	return um.findUser(ctx, "SELECT id, email, password_hash FROM users WHERE lower(email) = lower(?)", email)
+}
+
+func (um *userManager) findUser(ctx context.Context, query string, key any) (found user.User, err error) {
+	spanCtx, span := dialect.StartQuerySpan(ctx, "SELECT", "users", query)
+	defer func() { sqlstore.EndQuerySpan(span, err) }()
+
+	err = um.db.QueryRowContext(spanCtx, query, key).Scan(&found.Id, &found.Email, &found.PasswordHash)
+	if err == sql.ErrNoRows {
+		return user.User{}, domain.NewNotFound("user", key)
+	}
+	if err != nil {
+		um.logger.ErrorContext(ctx, "failed to query user", "error", err)
+		return user.User{}, fmt.Errorf("error executing query: %v", err)
+	}
+	return found, nil
+}
+
+type sessionManager struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewSessionManager(db *sql.DB, logger *slog.Logger) *sessionManager {
+	return &sessionManager{db, logger}
+}
+
+func (sm *sessionManager) AddSession(ctx context.Context, session user.Session) error {
+	query := "INSERT INTO sessions (token_hash, user_id, expires_at) VALUES (?, ?, ?)"
+	spanCtx, span := dialect.StartQuerySpan(ctx, "INSERT", "sessions", query)
+	_, err := sm.db.ExecContext(spanCtx, query, session.TokenHash, session.UserId, formatTime(session.ExpiresAt))
+	sqlstore.EndQuerySpan(span, err)
+	if err != nil {
+		sm.logger.ErrorContext(ctx, "failed to insert session", "user_id", session.UserId, "error", err)
+		return fmt.Errorf("failed to insert session: %v", err)
+	}
+	return nil
+}
+
+func (sm *sessionManager) FindSession(ctx context.Context, tokenHash string) (found user.Session, err error) {
+	query := "SELECT token_hash, user_id, expires_at FROM sessions WHERE token_hash = ?"
+	spanCtx, span := dialect.StartQuerySpan(ctx, "SELECT", "sessions", query)
+	defer func() { sqlstore.EndQuerySpan(span, err) }()
+
+	var expiresAt string
+	err = sm.db.QueryRowContext(spanCtx, query, tokenHash).Scan(&found.TokenHash, &found.UserId, &expiresAt)
+	if err == sql.ErrNoRows {
+		return user.Session{}, domain.NewNotFound("session", "")
+	}
+	if err == nil {
+		found.ExpiresAt, err = parseTime(expiresAt)
+	}
+	if err != nil {
+		sm.logger.ErrorContext(ctx, "failed to query session", "error", err)
+		return user.Session{}, fmt.Errorf("error executing query: %v", err)
+	}
+	return found, nil
+}
+
+func (sm *sessionManager) DeleteSession(ctx context.Context, tokenHash string) error {
+	query := "DELETE FROM sessions WHERE token_hash = ?"
+	spanCtx, span := dialect.StartQuerySpan(ctx, "DELETE", "sessions", query)
+	_, err := sm.db.ExecContext(spanCtx, query, tokenHash)
+	sqlstore.EndQuerySpan(span, err)
+	if err != nil {
+		sm.logger.ErrorContext(ctx, "failed to delete session", "error", err)
+		return fmt.Errorf("failed to delete session: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+// Package sqlstore holds what the Postgres and SQLite repositories share:
+// the transaction ctx carries, the spans of their statements, and the scope
+// and pagination conditions they build, written once against a Dialect.
+package sqlstore
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Dialect is what sets the SQL of one database apart.
+type Dialect struct {
+	// System names the database on the spans of its statements.
+	System attribute.KeyValue
+	// Placeholder returns the placeholder of the nth argument, counting
+	// from 1.
+	Placeholder func(n int) string
+	// NameLike matches column against the LIKE pattern in placeholder,
+	// whose wildcards are escaped with a backslash.
+	NameLike func(column, placeholder string) string
+	// TimeArg returns the argument a time is compared with timestamp
+	// columns as.
+	TimeArg func(t time.Time) any
+}
+
+// next returns the placeholder of the last of args.
+func (d Dialect) next(args []any) string {
+	return d.Placeholder(len(args))
+}
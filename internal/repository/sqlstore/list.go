@@ -0,0 +1,112 @@
+package sqlstore
+
+import (
+	"fmt"
+	"q-q-tem-pra-hoje/internal/domain"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// keyset describes the column a list is sorted on and how a cursor key for
+// that column is parsed back into a query argument.
+type keyset struct {
+	column string
+	parse  func(d Dialect, key string) (any, error)
+}
+
+var keysets = map[domain.SortField]keyset{
+	domain.SortByName: {column: "name", parse: func(d Dialect, key string) (any, error) {
+		return key, nil
+	}},
+	domain.SortByCreated: {column: "created_at", parse: func(d Dialect, key string) (any, error) {
+		t, err := time.Parse(time.RFC3339Nano, key)
+		if err != nil {
+			return nil, err
+		}
+		return d.TimeArg(t), nil
+	}},
+	domain.SortByQuantity: {column: "quantity", parse: func(d Dialect, key string) (any, error) {
+		return strconv.Atoi(key)
+	}},
+}
+
+// ListClauses builds the WHERE, ORDER BY and LIMIT clauses of a keyset
+// paginated query on table alias prefix, limited to the rows matching the
+// scope condition, whose placeholders are numbered by args. Ties on the sort
+// column are broken by id so cursors are stable. One extra row is requested
+// to detect whether a next page exists.
+func (d Dialect) ListClauses(scope string, args []any, prefix string, opts domain.ListOptions, allowed ...domain.SortField) (string, []any, error) {
+	sort := opts.SortOrDefault()
+	ks, ok := keysets[sort]
+	if !ok || !containsSort(allowed, sort) {
+		return "", nil, domain.NewValidation(domain.FieldError{Field: "sort", Message: fmt.Sprintf("cannot sort by %s", sort)})
+	}
+
+	column := prefix + ks.column
+	id := prefix + "id"
+	conditions := []string{scope}
+
+	if opts.Search != "" {
+		args = append(args, "%"+escapeLike(opts.Search)+"%")
+		conditions = append(conditions, d.NameLike(prefix+"name", d.next(args)))
+	}
+
+	direction, comparison := "ASC", ">"
+	if opts.Descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	if opts.After != nil {
+		key, err := ks.parse(d, opts.After.Key)
+		if err != nil {
+			return "", nil, domain.NewValidation(domain.FieldError{Field: "cursor", Message: "cursor does not match the sort order"})
+		}
+		args = append(args, key, opts.After.ID)
+		conditions = append(conditions, fmt.Sprintf("(%s, %s) %s (%s, %s)", column, id, comparison, d.Placeholder(len(args)-1), d.next(args)))
+	}
+
+	var clauses strings.Builder
+	clauses.WriteString(" WHERE " + strings.Join(conditions, " AND "))
+	fmt.Fprintf(&clauses, " ORDER BY %s %s, %s %s", column, direction, id, direction)
+	if opts.Limit > 0 {
+		fmt.Fprintf(&clauses, " LIMIT %d", opts.Limit+1)
+	}
+	return clauses.String(), args, nil
+}
+
+// CursorKey formats the sort value of a row the way keysets parse it.
+func CursorKey(sort domain.SortField, name string, quantity int, createdAt time.Time) string {
+	switch sort {
+	case domain.SortByCreated:
+		return createdAt.UTC().Format(time.RFC3339Nano)
+	case domain.SortByQuantity:
+		return strconv.Itoa(quantity)
+	default:
+		return name
+	}
+}
+
+// TrimPage drops the extra row requested by ListClauses and returns the
+// cursor of the last kept row when there are more.
+func TrimPage[T any](items []T, limit int, cursorOf func(T) domain.Cursor) domain.Page[T] {
+	if limit <= 0 || len(items) <= limit {
+		return domain.Page[T]{Items: items}
+	}
+	items = items[:limit]
+	next := cursorOf(items[limit-1])
+	return domain.Page[T]{Items: items, Next: &next}
+}
+
+func containsSort(fields []domain.SortField, field domain.SortField) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
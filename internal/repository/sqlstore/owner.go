@@ -0,0 +1,70 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"q-q-tem-pra-hoje/internal/domain/household"
+	"q-q-tem-pra-hoje/internal/domain/recipe"
+	"q-q-tem-pra-hoje/internal/domain/user"
+)
+
+// OwnerID is the value stored in the user_id column of new rows: the user
+// ctx acts for, or NULL in system scope.
+func OwnerID(ctx context.Context) any {
+	if id, ok := user.UserIDFromContext(ctx); ok {
+		return id
+	}
+	return nil
+}
+
+// OwnerCondition restricts column to the rows of the user ctx acts for,
+// numbering its placeholder after args. In system scope it matches every row.
+func (d Dialect) OwnerCondition(ctx context.Context, column string, args []any) (string, []any) {
+	id, ok := user.UserIDFromContext(ctx)
+	if !ok {
+		return "TRUE", args
+	}
+	args = append(args, id)
+	return column + " = " + d.next(args), args
+}
+
+// HouseholdID is the value stored in the household_id column of new rows:
+// the household ctx acts within, or NULL outside of one.
+func HouseholdID(ctx context.Context) any {
+	if m, ok := household.MembershipFromContext(ctx); ok {
+		return m.Household.Id
+	}
+	return nil
+}
+
+// HouseholdCondition restricts column to the rows of the household ctx acts
+// within. A user acting outside of any household matches nothing; system
+// scope matches every row.
+func (d Dialect) HouseholdCondition(ctx context.Context, column string, args []any) (string, []any) {
+	m, ok := household.MembershipFromContext(ctx)
+	if !ok {
+		if _, ok := user.UserIDFromContext(ctx); ok {
+			return "FALSE", args
+		}
+		return "TRUE", args
+	}
+	args = append(args, m.Household.Id)
+	return column + " = " + d.next(args), args
+}
+
+// VisibleRecipes restricts the recipes of table alias prefix to those the
+// user ctx acts for may read: their own, the ones shared with the household
+// ctx acts within and public ones. In system scope it matches every row.
+func (d Dialect) VisibleRecipes(ctx context.Context, prefix string, args []any) (string, []any) {
+	id, ok := user.UserIDFromContext(ctx)
+	if !ok {
+		return "TRUE", args
+	}
+	args = append(args, id, recipe.VisibilityPublic)
+	condition := fmt.Sprintf("%suser_id = %s OR %svisibility = %s", prefix, d.Placeholder(len(args)-1), prefix, d.next(args))
+	if m, ok := household.MembershipFromContext(ctx); ok {
+		args = append(args, recipe.VisibilityHousehold, m.Household.Id)
+		condition += fmt.Sprintf(" OR (%svisibility = %s AND %shousehold_id = %s)", prefix, d.Placeholder(len(args)-1), prefix, d.next(args))
+	}
+	return "(" + condition + ")", args
+}
@@ -0,0 +1,32 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"q-q-tem-pra-hoje/internal/tracing"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// StartQuerySpan opens a client span for a single SQL statement, named after
+// the operation and table as the database semantic conventions recommend.
+func (d Dialect) StartQuerySpan(ctx context.Context, operation string, table string, query string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, operation+" "+table,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			d.System,
+			semconv.DBOperationName(operation),
+			semconv.DBCollectionName(table),
+			semconv.DBQueryText(query),
+		),
+	)
+}
+
+// EndQuerySpan ends the span, treating sql.ErrNoRows as a normal outcome.
+func EndQuerySpan(span trace.Span, err error) {
+	if err == sql.ErrNoRows {
+		err = nil
+	}
+	tracing.End(span, err)
+}
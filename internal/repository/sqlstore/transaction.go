@@ -0,0 +1,88 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+)
+
+type txKey struct{}
+
+type transactor struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewTransactor runs transactions the repositories of db take part in,
+// through Conn and BeginLocal.
+func NewTransactor(db *sql.DB, logger *slog.Logger) *transactor {
+	return &transactor{db, logger}
+}
+
+func (t *transactor) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		t.logger.ErrorContext(ctx, "failed to begin transaction", "error", err)
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		t.logger.ErrorContext(ctx, "failed to commit transaction", "error", err)
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+// Querier is what *sql.DB and *sql.Tx have in common.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Conn returns the transaction ctx carries, or db outside of one.
+func Conn(ctx context.Context, db *sql.DB) Querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// LocalTx is a transaction of a single repository method. Within the
+// transaction ctx carries it does nothing itself, leaving the outcome to
+// whoever began that one.
+type LocalTx struct {
+	*sql.Tx
+	joined bool
+}
+
+func BeginLocal(ctx context.Context, db *sql.DB) (LocalTx, error) {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return LocalTx{Tx: tx, joined: true}, nil
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	return LocalTx{Tx: tx}, err
+}
+
+func (t LocalTx) Commit() error {
+	if t.joined {
+		return nil
+	}
+	return t.Tx.Commit()
+}
+
+func (t LocalTx) Rollback() error {
+	if t.joined {
+		return nil
+	}
+	return t.Tx.Rollback()
+}
@@ -0,0 +1,171 @@
+// Package seed loads fixture recipes, such as the sample recipes of a demo
+// environment.
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"q-q-tem-pra-hoje/internal/domain/ingredient"
+	"q-q-tem-pra-hoje/internal/domain/recipe"
+	"regexp"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fixture is a file of recipes to seed, in YAML or JSON.
+type Fixture struct {
+	Recipes []Recipe `yaml:"recipes"`
+}
+
+type Recipe struct {
+	Name string `yaml:"name"`
+	// Visibility defaults to public, since seeded recipes have no author
+	// who could read them otherwise.
+	Visibility  string       `yaml:"visibility"`
+	Ingredients []Ingredient `yaml:"ingredients"`
+}
+
+type Ingredient struct {
+	Name        string `yaml:"name"`
+	Quantity    int    `yaml:"quantity"`
+	MeasureType string `yaml:"measureType"`
+}
+
+// Result counts what Apply did.
+type Result struct {
+	Added   int
+	Updated int
+}
+
+// fixtureName is how the fixtures of an environment are named: a version
+// that orders them, like the migrations, then a description.
+var fixtureName = regexp.MustCompile(`^\d+_[\w-]+\.(yaml|yml|json)$`)
+
+// Environments lists the environments fsys has fixtures for, one directory
+// each.
+func Environments(fsys fs.FS) ([]string, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	var environments []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			environments = append(environments, entry.Name())
+		}
+	}
+	return environments, nil
+}
+
+// ReadEnvironment reads the fixtures of the environment's directory in fsys,
+// in version order, and merges them. A later fixture's recipe replaces an
+// earlier one of the same name.
+func ReadEnvironment(fsys fs.FS, environment string) (Fixture, error) {
+	entries, err := fs.ReadDir(fsys, environment)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return Fixture{}, fmt.Errorf("unknown seed environment %q", environment)
+		}
+		return Fixture{}, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || !fixtureName.MatchString(entry.Name()) {
+			return Fixture{}, fmt.Errorf("%s/%s: fixtures must be files named like 001_description.yaml", environment, entry.Name())
+		}
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	var merged Fixture
+	positions := map[string]int{}
+	for _, name := range names {
+		fixture, err := readFile(fsys, path.Join(environment, name))
+		if err != nil {
+			return Fixture{}, err
+		}
+		for _, r := range fixture.Recipes {
+			if i, ok := positions[r.Name]; ok {
+				merged.Recipes[i] = r
+				continue
+			}
+			positions[r.Name] = len(merged.Recipes)
+			merged.Recipes = append(merged.Recipes, r)
+		}
+	}
+	return merged, nil
+}
+
+func readFile(fsys fs.FS, name string) (Fixture, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return Fixture{}, err
+	}
+	defer f.Close()
+	fixture, err := Read(f)
+	if err != nil {
+		return Fixture{}, fmt.Errorf("%s: %w", name, err)
+	}
+	return fixture, nil
+}
+
+// Read decodes a fixture, rejecting unknown fields so typos do not go
+// unnoticed.
+func Read(r io.Reader) (Fixture, error) {
+	var fixture Fixture
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&fixture); err != nil {
+		if errors.Is(err, io.EOF) {
+			return Fixture{}, fmt.Errorf("empty fixture")
+		}
+		return Fixture{}, err
+	}
+	return fixture, nil
+}
+
+// Apply upserts the fixture's recipes by name through rm, so applying a
+// fixture again only brings its recipes back in line with it. Every recipe
+// is validated before any is written. ctx must be in system scope, so the
+// recipes belong to nobody.
+func Apply(ctx context.Context, rm recipe.RecipeManager, fixture Fixture) (Result, error) {
+	recipes := make([]recipe.Recipe, len(fixture.Recipes))
+	for i, r := range fixture.Recipes {
+		recipes[i] = r.toDomain()
+		if err := recipes[i].Validate(); err != nil {
+			return Result{}, fmt.Errorf("recipe %d (%q): %w", i+1, r.Name, err)
+		}
+	}
+
+	var result Result
+	for _, r := range recipes {
+		added, err := rm.UpsertRecipe(ctx, r)
+		if err != nil {
+			return result, fmt.Errorf("recipe %q: %w", r.Name, err)
+		}
+		if added {
+			result.Added++
+		} else {
+			result.Updated++
+		}
+	}
+	return result, nil
+}
+
+func (r Recipe) toDomain() recipe.Recipe {
+	visibility := recipe.Visibility(r.Visibility)
+	if visibility == "" {
+		visibility = recipe.VisibilityPublic
+	}
+	ingredients := make([]ingredient.Ingredient, len(r.Ingredients))
+	for i, ing := range r.Ingredients {
+		ingredients[i] = ingredient.NewIngredient(nil, ing.Name, ing.MeasureType, ing.Quantity)
+	}
+	return recipe.Recipe{Name: r.Name, Ingredients: ingredients, Visibility: visibility}
+}
@@ -0,0 +1,143 @@
+package seed_test
+
+import (
+	"context"
+	"q-q-tem-pra-hoje/internal/domain/ingredient"
+	"q-q-tem-pra-hoje/internal/domain/recipe"
+	"q-q-tem-pra-hoje/internal/seed"
+	"q-q-tem-pra-hoje/seeds"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MockRecipeManager struct {
+	recipe.RecipeManager
+	added []recipe.Recipe
+}
+
+func (m *MockRecipeManager) UpsertRecipe(ctx context.Context, r recipe.Recipe) (bool, error) {
+	for i, existing := range m.added {
+		if existing.Name == r.Name {
+			m.added[i] = r
+			return false, nil
+		}
+	}
+	m.added = append(m.added, r)
+	return true, nil
+}
+
+const fixture = `
+recipes:
+  - name: Tropeiro
+    ingredients:
+      - name: feijão
+        quantity: 500
+        measureType: g
+  - name: Quibe
+    visibility: household
+    ingredients:
+      - name: trigo
+        quantity: 250
+        measureType: g
+`
+
+func TestRead(t *testing.T) {
+	t.Run("it should reject unknown fields", func(t *testing.T) {
+		_, err := seed.Read(strings.NewReader("recipes:\n  - name: Quibe\n    serves: 4\n"))
+
+		assert.ErrorContains(t, err, "field serves not found")
+	})
+}
+
+func TestReadEnvironment(t *testing.T) {
+	fsys := fstest.MapFS{
+		"demo/001_base.yaml":  {Data: []byte(fixture)},
+		"demo/002_later.json": {Data: []byte(`{"recipes": [{"name": "Quibe", "ingredients": [{"name": "trigo", "quantity": 500, "measureType": "g"}]}, {"name": "Mjadra"}]}`)},
+		"empty/README.md":     {Data: []byte("not a fixture")},
+	}
+
+	t.Run("it should merge the fixtures in version order", func(t *testing.T) {
+		f, err := seed.ReadEnvironment(fsys, "demo")
+
+		require.NoError(t, err)
+		require.Len(t, f.Recipes, 3)
+		assert.Equal(t, "Tropeiro", f.Recipes[0].Name)
+		assert.Equal(t, seed.Recipe{Name: "Quibe", Ingredients: []seed.Ingredient{{Name: "trigo", Quantity: 500, MeasureType: "g"}}}, f.Recipes[1])
+		assert.Equal(t, "Mjadra", f.Recipes[2].Name)
+	})
+
+	t.Run("it should reject files not named like fixtures", func(t *testing.T) {
+		_, err := seed.ReadEnvironment(fsys, "empty")
+
+		assert.ErrorContains(t, err, "empty/README.md")
+	})
+
+	t.Run("it should reject unknown environments", func(t *testing.T) {
+		_, err := seed.ReadEnvironment(fsys, "production")
+
+		assert.ErrorContains(t, err, `unknown seed environment "production"`)
+	})
+}
+
+func TestEnvironments(t *testing.T) {
+	t.Run("it should list the embedded environments", func(t *testing.T) {
+		environments, err := seed.Environments(seeds.FS)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"demo", "development"}, environments)
+	})
+
+	t.Run("it should read every embedded environment", func(t *testing.T) {
+		for _, environment := range []string{"demo", "development"} {
+			f, err := seed.ReadEnvironment(seeds.FS, environment)
+			require.NoError(t, err)
+
+			_, err = seed.Apply(context.Background(), &MockRecipeManager{}, f)
+			assert.NoError(t, err, environment)
+		}
+	})
+}
+
+func TestApply(t *testing.T) {
+	t.Run("it should add the recipes as public unless told otherwise", func(t *testing.T) {
+		f, err := seed.Read(strings.NewReader(fixture))
+		require.NoError(t, err)
+		rm := &MockRecipeManager{}
+
+		result, err := seed.Apply(context.Background(), rm, f)
+
+		require.NoError(t, err)
+		assert.Equal(t, seed.Result{Added: 2}, result)
+		assert.Equal(t, []recipe.Recipe{
+			{Name: "Tropeiro", Visibility: recipe.VisibilityPublic, Ingredients: []ingredient.Ingredient{{Name: "feijão", Quantity: 500, MeasureType: "g"}}},
+			{Name: "Quibe", Visibility: recipe.VisibilityHousehold, Ingredients: []ingredient.Ingredient{{Name: "trigo", Quantity: 250, MeasureType: "g"}}},
+		}, rm.added)
+	})
+
+	t.Run("it should update recipes that already exist", func(t *testing.T) {
+		f, err := seed.Read(strings.NewReader(fixture))
+		require.NoError(t, err)
+		rm := &MockRecipeManager{}
+		_, err = seed.Apply(context.Background(), rm, f)
+		require.NoError(t, err)
+
+		result, err := seed.Apply(context.Background(), rm, f)
+
+		require.NoError(t, err)
+		assert.Equal(t, seed.Result{Updated: 2}, result)
+		assert.Len(t, rm.added, 2)
+	})
+
+	t.Run("it should add nothing when a recipe is invalid", func(t *testing.T) {
+		rm := &MockRecipeManager{}
+
+		_, err := seed.Apply(context.Background(), rm, seed.Fixture{Recipes: []seed.Recipe{{Name: "Quibe", Ingredients: []seed.Ingredient{{Name: "trigo", MeasureType: "g"}}}, {Name: "Empty"}}})
+
+		assert.ErrorContains(t, err, `recipe 2 ("Empty")`)
+		assert.Empty(t, rm.added)
+	})
+}
@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"q-q-tem-pra-hoje/internal/domain/apikey"
+	"q-q-tem-pra-hoje/internal/server/dto"
+	"q-q-tem-pra-hoje/internal/server/problem"
+)
+
+type APIKeyController struct {
+	APIKeyProvider apikey.APIKeyProvider
+	Logger         *slog.Logger
+}
+
+func NewAPIKeyController(kp apikey.APIKeyProvider, logger *slog.Logger) *APIKeyController {
+	return &APIKeyController{APIKeyProvider: kp, Logger: logger}
+}
+
+func (kc APIKeyController) List(w http.ResponseWriter, r *http.Request) {
+	keys, err := kc.APIKeyProvider.FindKeys(r.Context())
+	if err != nil {
+		kc.respondWithError(w, r, err)
+		return
+	}
+	kc.respondWithJSON(w, http.StatusOK, dto.FromAPIKeys(keys))
+}
+
+func (kc APIKeyController) Create(w http.ResponseWriter, r *http.Request) {
+	var input dto.APIKeyInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		kc.respondWithError(w, r, problem.InvalidBody(err))
+		return
+	}
+
+	created, token, err := kc.APIKeyProvider.Create(r.Context(), input.ToDomain())
+	if err != nil {
+		kc.respondWithError(w, r, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	kc.respondWithJSON(w, http.StatusCreated, dto.IssuedAPIKey{APIKey: dto.FromAPIKey(created), Key: token})
+}
+
+// Rotate replaces the secret of a key; the old secret stops working at once.
+func (kc APIKeyController) Rotate(w http.ResponseWriter, r *http.Request) {
+	id, err := dto.PathID(r, "id")
+	if err != nil {
+		kc.respondWithError(w, r, err)
+		return
+	}
+
+	rotated, token, err := kc.APIKeyProvider.Rotate(r.Context(), id)
+	if err != nil {
+		kc.respondWithError(w, r, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	kc.respondWithJSON(w, http.StatusOK, dto.IssuedAPIKey{APIKey: dto.FromAPIKey(rotated), Key: token})
+}
+
+func (kc APIKeyController) Revoke(w http.ResponseWriter, r *http.Request) {
+	id, err := dto.PathID(r, "id")
+	if err != nil {
+		kc.respondWithError(w, r, err)
+		return
+	}
+
+	if err := kc.APIKeyProvider.Revoke(r.Context(), id); err != nil {
+		kc.respondWithError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (kc APIKeyController) respondWithError(w http.ResponseWriter, r *http.Request, err error) {
+	problem.Respond(w, r, kc.Logger, err)
+}
+
+func (kc APIKeyController) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
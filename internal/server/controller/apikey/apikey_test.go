@@ -0,0 +1,96 @@
+package controller_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/apikey"
+	controller "q-q-tem-pra-hoje/internal/server/controller/apikey"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+var createdAt = time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+type MockAPIKeyService struct {
+	apikey.APIKeyProvider
+}
+
+func (m *MockAPIKeyService) Create(ctx context.Context, key apikey.Key) (apikey.Key, string, error) {
+	if err := key.Validate(); err != nil {
+		return apikey.Key{}, "", err
+	}
+	key.Id, key.HouseholdId, key.Prefix, key.CreatedAt = 1, 3, "qqk_abcdef", createdAt
+	return key, "qqk_abcdefsecret", nil
+}
+
+func (m *MockAPIKeyService) FindKeys(ctx context.Context) ([]apikey.Key, error) {
+	return []apikey.Key{{Id: 1, HouseholdId: 3, Name: "Scanner", Prefix: "qqk_abcdef", Hash: "hash", Scopes: []apikey.Scope{apikey.ScopePantryRead}, CreatedAt: createdAt}}, nil
+}
+
+func (m *MockAPIKeyService) Revoke(ctx context.Context, id int) error {
+	if id != 1 {
+		return domain.NewNotFound("api key", id)
+	}
+	return nil
+}
+
+func TestAPIKeyController_Create(t *testing.T) {
+	t.Run("it should return the key once, uncacheable", func(t *testing.T) {
+		ctrl := controller.NewAPIKeyController(&MockAPIKeyService{}, discardLogger)
+
+		w := httptest.NewRecorder()
+		ctrl.Create(w, httptest.NewRequest(http.MethodPost, "/api/v1/api-keys", bytes.NewBufferString(`{"name":"Scanner","scopes":["pantry:write"]}`)))
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		assert.JSONEq(t, `{"id":1,"name":"Scanner","prefix":"qqk_abcdef","scopes":["pantry:write"],"householdId":3,"createdAt":"2030-01-02T03:04:05Z","lastUsedAt":null,"key":"qqk_abcdefsecret"}`, w.Body.String())
+	})
+
+	t.Run("it should reject an unknown scope", func(t *testing.T) {
+		ctrl := controller.NewAPIKeyController(&MockAPIKeyService{}, discardLogger)
+
+		w := httptest.NewRecorder()
+		ctrl.Create(w, httptest.NewRequest(http.MethodPost, "/api/v1/api-keys", bytes.NewBufferString(`{"name":"Scanner","scopes":["pantry:admin"]}`)))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Contains(t, w.Body.String(), "scopes[0]")
+	})
+}
+
+func TestAPIKeyController_List(t *testing.T) {
+	t.Run("it should leave the hash out", func(t *testing.T) {
+		ctrl := controller.NewAPIKeyController(&MockAPIKeyService{}, discardLogger)
+
+		w := httptest.NewRecorder()
+		ctrl.List(w, httptest.NewRequest(http.MethodGet, "/api/v1/api-keys", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `[{"id":1,"name":"Scanner","prefix":"qqk_abcdef","scopes":["pantry:read"],"householdId":3,"createdAt":"2030-01-02T03:04:05Z","lastUsedAt":null}]`, w.Body.String())
+	})
+}
+
+func TestAPIKeyController_Revoke(t *testing.T) {
+	for _, tc := range []struct {
+		id             string
+		expectedStatus int
+	}{{"1", http.StatusNoContent}, {"2", http.StatusNotFound}, {"abc", http.StatusBadRequest}} {
+		t.Run("it should answer "+http.StatusText(tc.expectedStatus)+" for key "+tc.id, func(t *testing.T) {
+			ctrl := controller.NewAPIKeyController(&MockAPIKeyService{}, discardLogger)
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/api-keys/"+tc.id, nil)
+			req.SetPathValue("id", tc.id)
+			w := httptest.NewRecorder()
+			ctrl.Revoke(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/user"
+	"q-q-tem-pra-hoje/internal/server/dto"
+	"q-q-tem-pra-hoje/internal/server/problem"
+	"strings"
+)
+
+type AuthController struct {
+	AuthProvider user.AuthProvider
+	Logger       *slog.Logger
+}
+
+func NewAuthController(ap user.AuthProvider, logger *slog.Logger) *AuthController {
+	return &AuthController{AuthProvider: ap, Logger: logger}
+}
+
+// BearerToken returns the token of an "Authorization: Bearer <token>" header.
+func BearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+func (ac AuthController) Register(w http.ResponseWriter, r *http.Request) {
+	var input dto.CredentialsInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		problem.Respond(w, r, ac.Logger, problem.InvalidBody(err))
+		return
+	}
+
+	registered, err := ac.AuthProvider.Register(r.Context(), input.ToDomain())
+	if err != nil {
+		problem.Respond(w, r, ac.Logger, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(dto.FromUser(registered))
+}
+
+func (ac AuthController) Login(w http.ResponseWriter, r *http.Request) {
+	var input dto.CredentialsInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		problem.Respond(w, r, ac.Logger, problem.InvalidBody(err))
+		return
+	}
+
+	token, expiresAt, err := ac.AuthProvider.Login(r.Context(), input.ToDomain())
+	if err != nil {
+		problem.Respond(w, r, ac.Logger, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(dto.Session{Token: token, ExpiresAt: expiresAt})
+}
+
+// Logout revokes the token the request was authenticated with.
+func (ac AuthController) Logout(w http.ResponseWriter, r *http.Request) {
+	token, ok := BearerToken(r)
+	if !ok {
+		problem.Respond(w, r, ac.Logger, domain.NewUnauthenticated("authentication required"))
+		return
+	}
+
+	if err := ac.AuthProvider.Logout(r.Context(), token); err != nil {
+		problem.Respond(w, r, ac.Logger, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ac AuthController) Me(w http.ResponseWriter, r *http.Request) {
+	current, err := ac.AuthProvider.CurrentUser(r.Context())
+	if err != nil {
+		problem.Respond(w, r, ac.Logger, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(dto.FromUser(current))
+}
@@ -0,0 +1,120 @@
+package controller_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/user"
+	controller "q-q-tem-pra-hoje/internal/server/controller/auth"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+type MockAuthService struct {
+	user.AuthProvider
+	lastCredentials user.Credentials
+	loggedOutToken  string
+}
+
+func (m *MockAuthService) Register(ctx context.Context, credentials user.Credentials) (user.User, error) {
+	m.lastCredentials = credentials
+	return user.User{Id: 3, Email: credentials.Email, PasswordHash: "$2a$10$secret"}, nil
+}
+
+func (m *MockAuthService) Login(ctx context.Context, credentials user.Credentials) (string, time.Time, error) {
+	if credentials.Password != "correct horse" {
+		return "", time.Time{}, domain.NewUnauthenticated("invalid email or password")
+	}
+	return "token-123", time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC), nil
+}
+
+func (m *MockAuthService) Logout(ctx context.Context, token string) error {
+	m.loggedOutToken = token
+	return nil
+}
+
+func TestAuthController_Register(t *testing.T) {
+	t.Run("it should never return the password hash", func(t *testing.T) {
+		mock := &MockAuthService{}
+		ctrl := controller.NewAuthController(mock, discardLogger)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/register", bytes.NewBufferString(`{"email":"cook@example.com","password":"correct horse"}`))
+		w := httptest.NewRecorder()
+
+		ctrl.Register(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.JSONEq(t, `{"id":3,"email":"cook@example.com"}`, w.Body.String())
+		assert.Equal(t, user.Credentials{Email: "cook@example.com", Password: "correct horse"}, mock.lastCredentials)
+	})
+
+	t.Run("it should reject a malformed body", func(t *testing.T) {
+		ctrl := controller.NewAuthController(&MockAuthService{}, discardLogger)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/register", bytes.NewBufferString(`{"email":`))
+		w := httptest.NewRecorder()
+
+		ctrl.Register(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestAuthController_Login(t *testing.T) {
+	testCases := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Valid credentials",
+			body:           `{"email":"cook@example.com","password":"correct horse"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"token":"token-123","expiresAt":"2030-01-02T03:04:05Z"}`,
+		},
+		{
+			name:           "Wrong password",
+			body:           `{"email":"cook@example.com","password":"wrong"}`,
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"type":"about:blank","title":"Unauthorized","status":401,"detail":"invalid email or password","instance":"/api/v1/auth/login"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := controller.NewAuthController(&MockAuthService{}, discardLogger)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewBufferString(tc.body))
+			w := httptest.NewRecorder()
+
+			ctrl.Login(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.JSONEq(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestAuthController_Logout(t *testing.T) {
+	t.Run("it should revoke the bearer token", func(t *testing.T) {
+		mock := &MockAuthService{}
+		ctrl := controller.NewAuthController(mock, discardLogger)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/logout", nil)
+		req.Header.Set("Authorization", "Bearer token-123")
+		w := httptest.NewRecorder()
+
+		ctrl.Logout(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "token-123", mock.loggedOutToken)
+	})
+}
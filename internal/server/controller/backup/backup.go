@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"q-q-tem-pra-hoje/internal/domain/backup"
+	"q-q-tem-pra-hoje/internal/server/dto"
+	"q-q-tem-pra-hoje/internal/server/problem"
+	"strconv"
+)
+
+type BackupController struct {
+	BackupProvider backup.BackupProvider
+	Logger         *slog.Logger
+}
+
+func NewBackupController(bp backup.BackupProvider, logger *slog.Logger) *BackupController {
+	return &BackupController{BackupProvider: bp, Logger: logger}
+}
+
+// Export serves the backup as a download named after the day it was taken.
+func (bc BackupController) Export(w http.ResponseWriter, r *http.Request) {
+	document, err := bc.BackupProvider.Export(r.Context())
+	if err != nil {
+		bc.respondWithError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"backup-%s.json\"", document.ExportedAt.Format("2006-01-02")))
+	bc.respondWithJSON(w, http.StatusOK, dto.FromBackup(document))
+}
+
+// Import loads the backup in the body. The mode query parameter picks merge
+// (the default) or replace, and dryRun=true reports without keeping
+// anything.
+func (bc BackupController) Import(w http.ResponseWriter, r *http.Request) {
+	opts := backup.ImportOptions{Mode: backup.Mode(r.URL.Query().Get("mode"))}
+	if dryRun := r.URL.Query().Get("dryRun"); dryRun != "" {
+		var err error
+		if opts.DryRun, err = strconv.ParseBool(dryRun); err != nil {
+			bc.respondWithError(w, r, problem.BadRequest("dryRun parameter must be true or false"))
+			return
+		}
+	}
+
+	var input dto.Backup
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		bc.respondWithError(w, r, problem.InvalidBody(err))
+		return
+	}
+
+	report, err := bc.BackupProvider.Import(r.Context(), input.ToDomain(), opts)
+	if err != nil {
+		bc.respondWithError(w, r, err)
+		return
+	}
+	bc.respondWithJSON(w, http.StatusOK, dto.FromImportReport(report))
+}
+
+func (bc BackupController) respondWithError(w http.ResponseWriter, r *http.Request, err error) {
+	problem.Respond(w, r, bc.Logger, err)
+}
+
+func (bc BackupController) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	if payload != nil {
+		if err := json.NewEncoder(w).Encode(payload); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
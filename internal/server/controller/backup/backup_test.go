@@ -0,0 +1,132 @@
+package controller_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"q-q-tem-pra-hoje/internal/domain/backup"
+	"q-q-tem-pra-hoje/internal/domain/ingredient"
+	"q-q-tem-pra-hoje/internal/domain/recipe"
+	controller "q-q-tem-pra-hoje/internal/server/controller/backup"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+type MockBackupService struct {
+	document     backup.Document
+	report       backup.Report
+	err          error
+	lastDocument backup.Document
+	lastOptions  backup.ImportOptions
+}
+
+func (m *MockBackupService) Export(ctx context.Context) (backup.Document, error) {
+	return m.document, m.err
+}
+
+func (m *MockBackupService) Import(ctx context.Context, document backup.Document, opts backup.ImportOptions) (backup.Report, error) {
+	m.lastDocument = document
+	m.lastOptions = opts
+	return m.report, m.err
+}
+
+func TestBackupController_Export(t *testing.T) {
+	t.Run("it should serve the backup as a download", func(t *testing.T) {
+		mock := &MockBackupService{document: backup.Document{
+			Version:     backup.Version,
+			ExportedAt:  time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC),
+			Household:   "Home",
+			Ingredients: []ingredient.Ingredient{{Name: "Rice", MeasureType: "g", Quantity: 500}},
+			Recipes:     []recipe.Recipe{{Name: "Plain rice", Ingredients: []ingredient.Ingredient{{Name: "Rice", MeasureType: "g", Quantity: 100}}}},
+		}}
+		ctrl := controller.NewBackupController(mock, discardLogger)
+		w := httptest.NewRecorder()
+
+		ctrl.Export(w, httptest.NewRequest(http.MethodGet, "/api/v1/export", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `attachment; filename="backup-2026-10-19.json"`, w.Header().Get("Content-Disposition"))
+		assert.JSONEq(t, `{"version":1,"exportedAt":"2026-10-19T12:00:00Z","household":"Home",
+			"ingredients":[{"name":"Rice","measureType":"g","quantity":500}],
+			"recipes":[{"name":"Plain rice","visibility":"private","ingredients":[{"name":"Rice","measureType":"g","quantity":100}]}]}`, w.Body.String())
+	})
+
+	t.Run("it should report a service error", func(t *testing.T) {
+		ctrl := controller.NewBackupController(&MockBackupService{err: errors.New("database error")}, discardLogger)
+		w := httptest.NewRecorder()
+
+		ctrl.Export(w, httptest.NewRequest(http.MethodGet, "/api/v1/export", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestBackupController_Import(t *testing.T) {
+	body := `{"version":1,"ingredients":[{"name":"Rice","measureType":"g","quantity":500}],
+		"recipes":[{"name":"Plain rice","visibility":"public","ingredients":[{"name":"Rice","measureType":"g","quantity":100}]}]}`
+
+	testCases := []struct {
+		name            string
+		target          string
+		body            string
+		expectedStatus  int
+		expectedOptions backup.ImportOptions
+	}{
+		{
+			name:           "Default options",
+			target:         "/api/v1/import",
+			body:           body,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:            "Dry run replacing",
+			target:          "/api/v1/import?mode=replace&dryRun=true",
+			body:            body,
+			expectedStatus:  http.StatusOK,
+			expectedOptions: backup.ImportOptions{Mode: backup.ModeReplace, DryRun: true},
+		},
+		{
+			name:           "Invalid dryRun",
+			target:         "/api/v1/import?dryRun=maybe",
+			body:           body,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid body",
+			target:         "/api/v1/import",
+			body:           `{"version":`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mock := &MockBackupService{report: backup.Report{Mode: backup.ModeMerge, Ingredients: backup.Counts{Added: 1}, Recipes: backup.Counts{Updated: 1}}}
+			ctrl := controller.NewBackupController(mock, discardLogger)
+			w := httptest.NewRecorder()
+
+			ctrl.Import(w, httptest.NewRequest(http.MethodPost, tc.target, strings.NewReader(tc.body)))
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+			assert.Equal(t, tc.expectedOptions, mock.lastOptions)
+			assert.Equal(t, backup.Document{
+				Version:     1,
+				Ingredients: []ingredient.Ingredient{{Name: "Rice", MeasureType: "g", Quantity: 500}},
+				Recipes:     []recipe.Recipe{{Name: "Plain rice", Visibility: recipe.VisibilityPublic, Ingredients: []ingredient.Ingredient{{Name: "Rice", MeasureType: "g", Quantity: 100}}}},
+			}, mock.lastDocument)
+			assert.JSONEq(t, `{"mode":"merge","dryRun":false,
+				"ingredients":{"added":1,"updated":0,"unchanged":0,"deleted":0},
+				"recipes":{"added":0,"updated":1,"unchanged":0,"deleted":0}}`, w.Body.String())
+		})
+	}
+}
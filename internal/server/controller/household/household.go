@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"q-q-tem-pra-hoje/internal/domain/household"
+	"q-q-tem-pra-hoje/internal/server/dto"
+	"q-q-tem-pra-hoje/internal/server/problem"
+	"strconv"
+)
+
+// HouseholdHeader selects the household a request acts within. Without it
+// requests use the user's oldest household.
+const HouseholdHeader = "X-Household-ID"
+
+type HouseholdController struct {
+	HouseholdProvider household.HouseholdProvider
+	Logger            *slog.Logger
+}
+
+func NewHouseholdController(hp household.HouseholdProvider, logger *slog.Logger) *HouseholdController {
+	return &HouseholdController{HouseholdProvider: hp, Logger: logger}
+}
+
+// RequestedHousehold reads the X-Household-ID header, returning nil when it
+// is absent.
+func RequestedHousehold(r *http.Request) (*int, error) {
+	value := r.Header.Get(HouseholdHeader)
+	if value == "" {
+		return nil, nil
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil || id < 1 {
+		return nil, problem.BadRequest(HouseholdHeader + " must be a positive integer")
+	}
+	return &id, nil
+}
+
+func (hc HouseholdController) List(w http.ResponseWriter, r *http.Request) {
+	memberships, err := hc.HouseholdProvider.FindMemberships(r.Context())
+	if err != nil {
+		hc.respondWithError(w, r, err)
+		return
+	}
+	hc.respondWithJSON(w, http.StatusOK, dto.FromMemberships(memberships))
+}
+
+func (hc HouseholdController) Create(w http.ResponseWriter, r *http.Request) {
+	var input dto.HouseholdInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		hc.respondWithError(w, r, problem.InvalidBody(err))
+		return
+	}
+
+	created, err := hc.HouseholdProvider.Create(r.Context(), input.ToDomain())
+	if err != nil {
+		hc.respondWithError(w, r, err)
+		return
+	}
+	hc.respondWithJSON(w, http.StatusCreated, dto.FromMembership(created))
+}
+
+func (hc HouseholdController) Members(w http.ResponseWriter, r *http.Request) {
+	id, err := dto.PathID(r, "id")
+	if err != nil {
+		hc.respondWithError(w, r, err)
+		return
+	}
+
+	members, err := hc.HouseholdProvider.FindMembers(r.Context(), id)
+	if err != nil {
+		hc.respondWithError(w, r, err)
+		return
+	}
+	hc.respondWithJSON(w, http.StatusOK, dto.FromMembers(members))
+}
+
+func (hc HouseholdController) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	id, err := dto.PathID(r, "id")
+	if err != nil {
+		hc.respondWithError(w, r, err)
+		return
+	}
+	userID, err := dto.PathID(r, "userId")
+	if err != nil {
+		hc.respondWithError(w, r, err)
+		return
+	}
+
+	if err := hc.HouseholdProvider.RemoveMember(r.Context(), id, userID); err != nil {
+		hc.respondWithError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (hc HouseholdController) Invite(w http.ResponseWriter, r *http.Request) {
+	id, err := dto.PathID(r, "id")
+	if err != nil {
+		hc.respondWithError(w, r, err)
+		return
+	}
+
+	var input dto.InvitationInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		hc.respondWithError(w, r, problem.InvalidBody(err))
+		return
+	}
+
+	role := household.Role(input.Role)
+	code, expiresAt, err := hc.HouseholdProvider.Invite(r.Context(), id, role)
+	if err != nil {
+		hc.respondWithError(w, r, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	hc.respondWithJSON(w, http.StatusCreated, dto.Invitation{Code: code, Role: input.Role, ExpiresAt: expiresAt})
+}
+
+func (hc HouseholdController) Join(w http.ResponseWriter, r *http.Request) {
+	var input dto.JoinInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		hc.respondWithError(w, r, problem.InvalidBody(err))
+		return
+	}
+
+	joined, err := hc.HouseholdProvider.Join(r.Context(), input.Code)
+	if err != nil {
+		hc.respondWithError(w, r, err)
+		return
+	}
+	hc.respondWithJSON(w, http.StatusOK, dto.FromMembership(joined))
+}
+
+func (hc HouseholdController) respondWithError(w http.ResponseWriter, r *http.Request, err error) {
+	problem.Respond(w, r, hc.Logger, err)
+}
+
+func (hc HouseholdController) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
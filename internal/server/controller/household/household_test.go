@@ -0,0 +1,109 @@
+package controller_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/household"
+	controller "q-q-tem-pra-hoje/internal/server/controller/household"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+type MockHouseholdService struct {
+	household.HouseholdProvider
+	removed []int
+}
+
+func (m *MockHouseholdService) FindMemberships(ctx context.Context) ([]household.Membership, error) {
+	return []household.Membership{{Household: household.Household{Id: 1, Name: "Personal"}, UserId: 3, Role: household.RoleOwner}}, nil
+}
+
+func (m *MockHouseholdService) Invite(ctx context.Context, householdID int, role household.Role) (string, time.Time, error) {
+	if householdID != 1 {
+		return "", time.Time{}, domain.NewForbidden("only owners can invite to the household")
+	}
+	return "code-123", time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC), nil
+}
+
+func (m *MockHouseholdService) RemoveMember(ctx context.Context, householdID int, userID int) error {
+	m.removed = append(m.removed, householdID, userID)
+	return nil
+}
+
+func TestHouseholdController_List(t *testing.T) {
+	t.Run("it should return the role of the user", func(t *testing.T) {
+		ctrl := controller.NewHouseholdController(&MockHouseholdService{}, discardLogger)
+
+		w := httptest.NewRecorder()
+		ctrl.List(w, httptest.NewRequest(http.MethodGet, "/api/v1/households", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `[{"id":1,"name":"Personal","role":"owner"}]`, w.Body.String())
+	})
+}
+
+func TestHouseholdController_Invite(t *testing.T) {
+	t.Run("it should return an uncacheable code", func(t *testing.T) {
+		ctrl := controller.NewHouseholdController(&MockHouseholdService{}, discardLogger)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/households/1/invitations", bytes.NewBufferString(`{"role":"member"}`))
+		req.SetPathValue("id", "1")
+		w := httptest.NewRecorder()
+
+		ctrl.Invite(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		assert.JSONEq(t, `{"code":"code-123","role":"member","expiresAt":"2030-01-02T03:04:05Z"}`, w.Body.String())
+	})
+
+	t.Run("it should report a forbidden invitation", func(t *testing.T) {
+		ctrl := controller.NewHouseholdController(&MockHouseholdService{}, discardLogger)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/households/2/invitations", bytes.NewBufferString(`{"role":"member"}`))
+		req.SetPathValue("id", "2")
+		w := httptest.NewRecorder()
+
+		ctrl.Invite(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+func TestHouseholdController_RemoveMember(t *testing.T) {
+	t.Run("it should read both ids from the path", func(t *testing.T) {
+		mock := &MockHouseholdService{}
+		ctrl := controller.NewHouseholdController(mock, discardLogger)
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/households/4/members/9", nil)
+		req.SetPathValue("id", "4")
+		req.SetPathValue("userId", "9")
+		w := httptest.NewRecorder()
+
+		ctrl.RemoveMember(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, []int{4, 9}, mock.removed)
+	})
+
+	t.Run("it should reject a malformed user id", func(t *testing.T) {
+		ctrl := controller.NewHouseholdController(&MockHouseholdService{}, discardLogger)
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/households/4/members/me", nil)
+		req.SetPathValue("id", "4")
+		req.SetPathValue("userId", "me")
+		w := httptest.NewRecorder()
+
+		ctrl.RemoveMember(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
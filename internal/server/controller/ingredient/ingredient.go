@@ -2,74 +2,63 @@ package controller
 
 import (
 	"encoding/json"
-	"errors"
+	"log/slog"
 	"net/http"
+	"q-q-tem-pra-hoje/internal/domain"
 	"q-q-tem-pra-hoje/internal/domain/ingredient"
-	"strconv"
+	"q-q-tem-pra-hoje/internal/server/dto"
+	"q-q-tem-pra-hoje/internal/server/problem"
 )
 
-var (
-	ErrInvalidRequestBody    = errors.New("invalid or missing fields in request body")
-	ErrMethodNotAllowed      = errors.New("method not allowed")
-	ErrInvalidId             = errors.New("invalid id parameter")
-	ErrMissingId             = errors.New("id parameter is required")
-	ErrInternalServerError   = errors.New("internal server error")
-)
-
-type Response struct {
-	Message string `json:"message,omitempty"`
-	Data    any    `json:"data,omitempty"`
-}
-
 type IngredientController struct {
 	service ingredient.IngredientStorageProvider
+	logger  *slog.Logger
 }
 
-func NewIngredientController(service ingredient.IngredientStorageProvider) *IngredientController {
+func NewIngredientController(service ingredient.IngredientStorageProvider, logger *slog.Logger) *IngredientController {
 	if service == nil {
 		panic("ingredient service cannot be nil")
 	}
-	return &IngredientController{service: service}
+	if logger == nil {
+		panic("ingredient logger cannot be nil")
+	}
+	return &IngredientController{service: service, logger: logger}
 }
 
+// ServeHTTP dispatches the deprecated root routes, where DELETE takes the id
+// from the query string. The /api/v1 routes call the handlers directly.
 func (ic *IngredientController) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	dto.LegacyQueryID(r)
 	switch r.Method {
 	case http.MethodPost:
 		ic.Add(w, r)
 	case http.MethodGet:
-		ic.GetAll(w, r)
+		ic.list(w, r, 0)
 	case http.MethodPatch:
 		ic.Update(w, r)
 	case http.MethodDelete:
 		ic.Delete(w, r)
 	default:
-		ic.respondWithError(w, http.StatusMethodNotAllowed, ErrMethodNotAllowed)
+		ic.respondWithError(w, r, problem.MethodNotAllowed(http.MethodGet, http.MethodPost, http.MethodPatch, http.MethodDelete))
 	}
 }
 
-type IngredientInput struct {
-	Name        string `json:"name"`
-	MeasureType string `json:"measureType"`
-	Quantity    int    `json:"quantity"`
-}
-
 func (ic *IngredientController) Add(w http.ResponseWriter, r *http.Request) {
-	var input IngredientInput
+	var input dto.IngredientInput
 
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		ic.respondWithError(w, http.StatusBadRequest, ErrInvalidRequestBody)
+		ic.respondWithError(w, r, problem.InvalidBody(err))
 		return
 	}
 
-	if input.Name == "" || input.MeasureType == "" {
-		ic.respondWithError(w, http.StatusBadRequest, ErrInvalidRequestBody)
+	ing := input.ToDomain(nil)
+	if err := ing.Validate(); err != nil {
+		ic.respondWithError(w, r, err)
 		return
 	}
 
-	ing := ingredient.NewIngredient(nil, input.Name, input.MeasureType, input.Quantity)
-
-	if err := ic.service.Add(ing); err != nil {
-		ic.respondWithError(w, http.StatusInternalServerError, ErrInternalServerError)
+	if err := ic.service.Add(r.Context(), ing); err != nil {
+		ic.respondWithError(w, r, err)
 		return
 	}
 
@@ -77,38 +66,50 @@ func (ic *IngredientController) Add(w http.ResponseWriter, r *http.Request) {
 }
 
 func (ic *IngredientController) GetAll(w http.ResponseWriter, r *http.Request) {
-	ingredients, err := ic.service.FindIngredients()
+	ic.list(w, r, domain.DefaultPageSize)
+}
+
+// list answers a list request, paging by defaultLimit unless it sets a
+// limit. The deprecated route passes 0 and keeps returning every match.
+func (ic *IngredientController) list(w http.ResponseWriter, r *http.Request, defaultLimit int) {
+	opts, err := dto.ListOptions(r, defaultLimit, domain.SortByName, domain.SortByCreated, domain.SortByQuantity)
+	if err != nil {
+		ic.respondWithError(w, r, err)
+		return
+	}
+
+	page, err := ic.service.ListIngredients(r.Context(), opts)
 	if err != nil {
-		ic.respondWithError(w, http.StatusInternalServerError, errors.New("failed to retrieve ingredients"))
+		ic.respondWithError(w, r, err)
 		return
 	}
 
-	ic.respondWithJSON(w, http.StatusOK, ingredients)
+	dto.SetNextPage(w, r, page.Next)
+	ic.respondWithJSON(w, http.StatusOK, dto.Render(w, r, page.Items, dto.FromIngredients(page.Items)))
 }
 
 func (ic *IngredientController) Update(w http.ResponseWriter, r *http.Request) {
-	id := r.PathValue("id")
-	intId, err := strconv.Atoi(id)
-	if id == "" || err != nil {
-		ic.respondWithError(w, http.StatusBadRequest, ErrMissingId)
+	id, err := dto.PathID(r, "id")
+	if err != nil {
+		ic.respondWithError(w, r, err)
 		return
 	}
-	var input IngredientInput
+
+	var input dto.IngredientInput
 
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		ic.respondWithError(w, http.StatusBadRequest, ErrInvalidRequestBody)
+		ic.respondWithError(w, r, problem.InvalidBody(err))
 		return
 	}
 
-	if input.Name == "" || input.MeasureType == "" {
-		ic.respondWithError(w, http.StatusBadRequest, ErrInvalidRequestBody)
+	updatedIngredient := input.ToDomain(&id)
+	if err := updatedIngredient.Validate(); err != nil {
+		ic.respondWithError(w, r, err)
 		return
 	}
 
-	updatedIngredient := ingredient.NewIngredient(&intId, input.Name, input.MeasureType, input.Quantity)
-
-	if err := ic.service.Update(updatedIngredient); err != nil {
-		ic.respondWithError(w, http.StatusInternalServerError, errors.New("failed to update ingredient"))
+	if err := ic.service.Update(r.Context(), updatedIngredient); err != nil {
+		ic.respondWithError(w, r, err)
 		return
 	}
 
@@ -116,23 +117,22 @@ func (ic *IngredientController) Update(w http.ResponseWriter, r *http.Request) {
 }
 
 func (ic *IngredientController) Delete(w http.ResponseWriter, r *http.Request) {
-	id, err := ic.extractIdFromPath(r.URL.Query().Get("id"))
-
+	id, err := dto.PathID(r, "id")
 	if err != nil {
-		ic.respondWithError(w, http.StatusBadRequest, ErrInvalidId)
+		ic.respondWithError(w, r, err)
 		return
 	}
 
-	if err := ic.service.Delete(uint(id)); err != nil {
-		ic.respondWithError(w, http.StatusInternalServerError, errors.New("failed to delete ingredient"))
+	if err := ic.service.Delete(r.Context(), uint(id)); err != nil {
+		ic.respondWithError(w, r, err)
 		return
 	}
 
 	ic.respondWithJSON(w, http.StatusNoContent, nil)
 }
 
-func (ic *IngredientController) respondWithError(w http.ResponseWriter, code int, err error) {
-	ic.respondWithJSON(w, code, Response{Message: err.Error()})
+func (ic *IngredientController) respondWithError(w http.ResponseWriter, r *http.Request, err error) {
+	problem.Respond(w, r, ic.logger, err)
 }
 
 func (ic *IngredientController) respondWithJSON(w http.ResponseWriter, code int, payload any) {
@@ -146,15 +146,3 @@ func (ic *IngredientController) respondWithJSON(w http.ResponseWriter, code int,
 		}
 	}
 }
-func (ic *IngredientController) extractIdFromPath(idString string) (uint, error) {
-	if idString == "" {
-		return 0, ErrMissingId
-	}
-
-	id, err := strconv.ParseUint(idString, 10, 64)
-	if err != nil {
-		return 0, ErrInvalidId
-	}
-	return uint(id), nil
-
-}
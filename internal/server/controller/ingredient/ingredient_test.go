@@ -2,26 +2,47 @@ package controller_test
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"q-q-tem-pra-hoje/internal/domain"
 	"q-q-tem-pra-hoje/internal/domain/ingredient"
+	"q-q-tem-pra-hoje/internal/logging"
+	"q-q-tem-pra-hoje/internal/repository/in_memory_repository"
 	controller "q-q-tem-pra-hoje/internal/server/controller/ingredient"
+	"q-q-tem-pra-hoje/internal/server/dto"
+	ingredientService "q-q-tem-pra-hoje/internal/service/ingredient"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+var discardLogger = slog.New(slog.DiscardHandler)
+
+func expectedContentType(status int) string {
+	if status >= http.StatusBadRequest {
+		return "application/problem+json"
+	}
+	return "application/json"
+}
+
 type MockIngredientService struct {
 	addFunc             func(ingredient.Ingredient) error
 	findIngredientsFunc func() ([]ingredient.Ingredient, error)
+	lastListOptions     domain.ListOptions
+	nextCursor          *domain.Cursor
 	updateFunc          func(ingredient.Ingredient) error
 	lastIngredient      ingredient.Ingredient
 	deleteFunc          func(uint) error
 	lastDeletedId       uint
 }
 
-func (m *MockIngredientService) Add(ing ingredient.Ingredient) error {
+func (m *MockIngredientService) Add(ctx context.Context, ing ingredient.Ingredient) error {
 	m.lastIngredient = ing
 	if m.addFunc != nil {
 		return m.addFunc(ing)
@@ -29,14 +50,20 @@ func (m *MockIngredientService) Add(ing ingredient.Ingredient) error {
 	return nil
 }
 
-func (m *MockIngredientService) FindIngredients() ([]ingredient.Ingredient, error) {
+func (m *MockIngredientService) FindIngredients(ctx context.Context) ([]ingredient.Ingredient, error) {
 	if m.findIngredientsFunc != nil {
 		return m.findIngredientsFunc()
 	}
 	return []ingredient.Ingredient{}, nil
 }
 
-func (m *MockIngredientService) Update(ing ingredient.Ingredient) error {
+func (m *MockIngredientService) ListIngredients(ctx context.Context, opts domain.ListOptions) (domain.Page[ingredient.Ingredient], error) {
+	m.lastListOptions = opts
+	ingredients, err := m.FindIngredients(ctx)
+	return domain.Page[ingredient.Ingredient]{Items: ingredients, Next: m.nextCursor}, err
+}
+
+func (m *MockIngredientService) Update(ctx context.Context, ing ingredient.Ingredient) error {
 	m.lastIngredient = ing
 	if m.updateFunc != nil {
 		return m.updateFunc(ing)
@@ -44,7 +71,7 @@ func (m *MockIngredientService) Update(ing ingredient.Ingredient) error {
 	return nil
 }
 
-func (m *MockIngredientService) Delete(id uint) error {
+func (m *MockIngredientService) Delete(ctx context.Context, id uint) error {
 	m.lastDeletedId = id
 	if m.deleteFunc != nil {
 		return m.deleteFunc(id)
@@ -56,7 +83,7 @@ func TestIngredientController_ServeHTTP(t *testing.T) {
 	t.Run("it should return method not allowed", func(t *testing.T) {
 		mockService := &MockIngredientService{}
 
-		ctrl := controller.NewIngredientController(mockService)
+		ctrl := controller.NewIngredientController(mockService, discardLogger)
 
 		req := httptest.NewRequest(http.MethodPut, "/ingredient", bytes.NewBufferString(`{}`))
 		req.Header.Set("Content-Type", "application/json")
@@ -64,8 +91,23 @@ func TestIngredientController_ServeHTTP(t *testing.T) {
 
 		ctrl.ServeHTTP(w, req)
 		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, "GET, POST, PATCH, DELETE", w.Header().Get("Allow"))
+
+		assert.JSONEq(t, `{"type":"about:blank","title":"Method Not Allowed","status":405,"detail":"method not allowed","instance":"/ingredient"}`, w.Body.String())
+	})
+
+	t.Run("it should include the request id in error responses", func(t *testing.T) {
+		mockService := &MockIngredientService{}
+
+		ctrl := controller.NewIngredientController(mockService, discardLogger)
+
+		req := httptest.NewRequest(http.MethodPut, "/ingredient", bytes.NewBufferString(`{}`))
+		req = req.WithContext(logging.WithRequestID(req.Context(), "req-42"))
+		w := httptest.NewRecorder()
 
-		assert.JSONEq(t, `{"message":"method not allowed"}`, w.Body.String())
+		ctrl.ServeHTTP(w, req)
+
+		assert.JSONEq(t, `{"type":"about:blank","title":"Method Not Allowed","status":405,"detail":"method not allowed","instance":"/ingredient","requestId":"req-42"}`, w.Body.String())
 	})
 }
 
@@ -95,13 +137,13 @@ func TestIngredientController_Add(t *testing.T) {
 			name:           "Invalid JSON",
 			requestBody:    `{"name":`,
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"message":"invalid or missing fields in request body"}`,
+			expectedBody:   `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid request body","instance":"/ingredient"}`,
 		},
 		{
 			name:           "Empty required fields",
 			requestBody:    `{"name":"","measureType":"","quantity":1}`,
-			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"message":"invalid or missing fields in request body"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"type":"about:blank","title":"Unprocessable Entity","status":422,"detail":"validation failed","instance":"/ingredient","errors":[{"field":"name","message":"ingredient name cannot be empty"},{"field":"measureType","message":"ingredient measure type cannot be empty"}]}`,
 		},
 		{
 			name:        "Service error",
@@ -110,7 +152,7 @@ func TestIngredientController_Add(t *testing.T) {
 				return errors.New("service error")
 			},
 			expectedStatus: http.StatusInternalServerError,
-			expectedBody:   `{"message":"internal server error"}`,
+			expectedBody:   `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/ingredient"}`,
 		},
 	}
 
@@ -119,7 +161,7 @@ func TestIngredientController_Add(t *testing.T) {
 			mockService := &MockIngredientService{
 				addFunc: tc.mockAddFunc,
 			}
-			ctrl := controller.NewIngredientController(mockService)
+			ctrl := controller.NewIngredientController(mockService, discardLogger)
 
 			req := httptest.NewRequest(http.MethodPost, "/ingredient", bytes.NewBufferString(tc.requestBody))
 			req.Header.Set("Content-Type", "application/json")
@@ -128,7 +170,7 @@ func TestIngredientController_Add(t *testing.T) {
 			ctrl.Add(w, req)
 
 			assert.Equal(t, tc.expectedStatus, w.Code)
-			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, expectedContentType(tc.expectedStatus), w.Header().Get("Content-Type"))
 
 			if tc.expectedBody != "" {
 				assert.JSONEq(t, tc.expectedBody, w.Body.String())
@@ -159,7 +201,7 @@ func TestIngredientController_GetAll(t *testing.T) {
 				}, nil
 			},
 			expectedStatus: http.StatusOK,
-			expectedBody:   `[{"Id":1,"Name":"onion","MeasureType":"unit","Quantity":20},{"Id":2,"Name":"garlic","MeasureType":"unit","Quantity":2}]`,
+			expectedBody:   `[{"id":1,"name":"onion","measureType":"unit","quantity":20},{"id":2,"name":"garlic","measureType":"unit","quantity":2}]`,
 		},
 		{
 			name: "Service error",
@@ -167,7 +209,7 @@ func TestIngredientController_GetAll(t *testing.T) {
 				return nil, errors.New("database error")
 			},
 			expectedStatus: http.StatusInternalServerError,
-			expectedBody:   `{"message":"failed to retrieve ingredients"}`,
+			expectedBody:   `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/ingredient"}`,
 		},
 		{
 			name: "Empty ingredients list",
@@ -184,7 +226,7 @@ func TestIngredientController_GetAll(t *testing.T) {
 			mockService := &MockIngredientService{
 				findIngredientsFunc: tc.mockFindFunc,
 			}
-			ctrl := controller.NewIngredientController(mockService)
+			ctrl := controller.NewIngredientController(mockService, discardLogger)
 
 			req := httptest.NewRequest(http.MethodGet, "/ingredient", nil)
 			w := httptest.NewRecorder()
@@ -192,7 +234,7 @@ func TestIngredientController_GetAll(t *testing.T) {
 			ctrl.GetAll(w, req)
 
 			assert.Equal(t, tc.expectedStatus, w.Code)
-			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, expectedContentType(tc.expectedStatus), w.Header().Get("Content-Type"))
 
 			if tc.expectedBody != "" {
 				assert.JSONEq(t, tc.expectedBody, w.Body.String())
@@ -201,6 +243,75 @@ func TestIngredientController_GetAll(t *testing.T) {
 	}
 }
 
+func TestIngredientController_GetAll_ListOptions(t *testing.T) {
+	t.Run("it should pass the query parameters to the service and link the next page", func(t *testing.T) {
+		mockService := &MockIngredientService{nextCursor: &domain.Cursor{Key: "5", ID: 7}}
+		ctrl := controller.NewIngredientController(mockService, discardLogger)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/ingredients?q=pep&sort=-quantity&limit=2", nil)
+		w := httptest.NewRecorder()
+
+		ctrl.GetAll(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, domain.ListOptions{Search: "pep", Sort: domain.SortByQuantity, Descending: true, Limit: 2}, mockService.lastListOptions)
+
+		cursor := mockService.nextCursor.Encode()
+		assert.Equal(t, cursor, w.Header().Get("X-Next-Cursor"))
+		assert.Equal(t, `</api/v1/ingredients?cursor=`+cursor+`&limit=2&q=pep&sort=-quantity>; rel="next"`, w.Header().Get("Link"))
+	})
+
+	t.Run("it should page a list request without a limit", func(t *testing.T) {
+		repository := in_memory_repository.NewIngredientStorageManager()
+		for i := range domain.DefaultPageSize + 10 {
+			require.NoError(t, repository.AddIngredient(context.Background(), ingredient.Ingredient{Name: fmt.Sprintf("Spice %d", i), MeasureType: "g", Quantity: 1}))
+		}
+		ctrl := controller.NewIngredientController(ingredientService.NewService(repository, discardLogger), discardLogger)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/ingredients", nil)
+		w := httptest.NewRecorder()
+
+		ctrl.GetAll(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var page []dto.Ingredient
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		assert.Len(t, page, domain.DefaultPageSize)
+		assert.NotEmpty(t, w.Header().Get("X-Next-Cursor"))
+	})
+
+	t.Run("it should return every ingredient on the deprecated route without a limit", func(t *testing.T) {
+		repository := in_memory_repository.NewIngredientStorageManager()
+		for i := range domain.DefaultPageSize + 10 {
+			require.NoError(t, repository.AddIngredient(context.Background(), ingredient.Ingredient{Name: fmt.Sprintf("Spice %d", i), MeasureType: "g", Quantity: 1}))
+		}
+		ctrl := controller.NewIngredientController(ingredientService.NewService(repository, discardLogger), discardLogger)
+
+		req := httptest.NewRequest(http.MethodGet, "/ingredient", nil)
+		w := httptest.NewRecorder()
+
+		ctrl.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var all []dto.Ingredient
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &all))
+		assert.Len(t, all, domain.DefaultPageSize+10)
+		assert.Empty(t, w.Header().Get("X-Next-Cursor"))
+	})
+
+	t.Run("it should reject an out of range limit", func(t *testing.T) {
+		ctrl := controller.NewIngredientController(&MockIngredientService{}, discardLogger)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/ingredients?limit=500", nil)
+		w := httptest.NewRecorder()
+
+		ctrl.GetAll(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	})
+}
+
 func TestIngredientController_Update(t *testing.T) {
 	testCases := []struct {
 		name           string
@@ -227,13 +338,13 @@ func TestIngredientController_Update(t *testing.T) {
 			name:           "Invalid JSON",
 			requestBody:    `{"name":`,
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"message":"invalid or missing fields in request body"}`,
+			expectedBody:   `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid request body","instance":"/ingredient/1"}`,
 		},
 		{
 			name:           "Empty required fields",
 			requestBody:    `{"name":"","measureType":"","quantity":1}`,
-			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"message":"invalid or missing fields in request body"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"type":"about:blank","title":"Unprocessable Entity","status":422,"detail":"validation failed","instance":"/ingredient/1","errors":[{"field":"name","message":"ingredient name cannot be empty"},{"field":"measureType","message":"ingredient measure type cannot be empty"}]}`,
 		},
 		{
 			name:        "Service error",
@@ -242,7 +353,16 @@ func TestIngredientController_Update(t *testing.T) {
 				return errors.New("service error")
 			},
 			expectedStatus: http.StatusInternalServerError,
-			expectedBody:   `{"message":"failed to update ingredient"}`,
+			expectedBody:   `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/ingredient/1"}`,
+		},
+		{
+			name:        "Ingredient not found",
+			requestBody: `{"name":"Salt","measureType":"unit","quantity":1}`,
+			mockUpdateFunc: func(ing ingredient.Ingredient) error {
+				return domain.NewNotFound("ingredient", *ing.Id)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"type":"about:blank","title":"Not Found","status":404,"detail":"ingredient 1 not found","instance":"/ingredient/1"}`,
 		},
 	}
 
@@ -251,7 +371,7 @@ func TestIngredientController_Update(t *testing.T) {
 			mockService := &MockIngredientService{
 				updateFunc: tc.mockUpdateFunc,
 			}
-			ctrl := controller.NewIngredientController(mockService)
+			ctrl := controller.NewIngredientController(mockService, discardLogger)
 
 			mux := http.NewServeMux()
 			mux.HandleFunc("PATCH /ingredient/{id}", func(w http.ResponseWriter, r *http.Request) {
@@ -266,7 +386,7 @@ func TestIngredientController_Update(t *testing.T) {
 			mux.ServeHTTP(w, req)
 
 			assert.Equal(t, tc.expectedStatus, w.Code)
-			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, expectedContentType(tc.expectedStatus), w.Header().Get("Content-Type"))
 
 			if tc.expectedBody != "" {
 				assert.JSONEq(t, tc.expectedBody, w.Body.String())
@@ -282,15 +402,15 @@ func TestIngredientController_Update(t *testing.T) {
 func TestIngredientController_Delete(t *testing.T) {
 	testCases := []struct {
 		name           string
-		urlPath        string
+		id             string
 		mockDeleteFunc func(uint) error
 		expectedStatus int
 		expectedBody   string
 		validateMock   func(*testing.T, *MockIngredientService)
 	}{
 		{
-			name:    "Successful deletion",
-			urlPath: "/ingredient?id=42",
+			name: "Successful deletion",
+			id:   "42",
 			mockDeleteFunc: func(id uint) error {
 				return nil
 			},
@@ -301,24 +421,32 @@ func TestIngredientController_Delete(t *testing.T) {
 		},
 		{
 			name:           "Missing Id parameter",
-			urlPath:        "/ingredient",
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"message":"invalid id parameter"}`,
+			expectedBody:   `{"type":"about:blank","title":"Bad Request","status":400,"detail":"id parameter is required","instance":"/ingredient"}`,
 		},
 		{
 			name:           "Invalid Id format",
-			urlPath:        "/ingredient?id=abc",
+			id:             "abc",
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"message":"invalid id parameter"}`,
+			expectedBody:   `{"type":"about:blank","title":"Bad Request","status":400,"detail":"id parameter must be a positive integer","instance":"/ingredient"}`,
 		},
 		{
-			name:    "Service error",
-			urlPath: "/ingredient?id=42",
+			name: "Service error",
+			id:   "42",
 			mockDeleteFunc: func(id uint) error {
 				return errors.New("service error")
 			},
 			expectedStatus: http.StatusInternalServerError,
-			expectedBody:   `{"message":"failed to delete ingredient"}`,
+			expectedBody:   `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/ingredient"}`,
+		},
+		{
+			name: "Ingredient not found",
+			id:   "42",
+			mockDeleteFunc: func(id uint) error {
+				return domain.NewNotFound("ingredient", id)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"type":"about:blank","title":"Not Found","status":404,"detail":"ingredient 42 not found","instance":"/ingredient"}`,
 		},
 	}
 	for _, tc := range testCases {
@@ -326,15 +454,16 @@ func TestIngredientController_Delete(t *testing.T) {
 			mockService := &MockIngredientService{
 				deleteFunc: tc.mockDeleteFunc,
 			}
-			ctrl := controller.NewIngredientController(mockService)
+			ctrl := controller.NewIngredientController(mockService, discardLogger)
 
-			req := httptest.NewRequest(http.MethodDelete, tc.urlPath, nil)
+			req := httptest.NewRequest(http.MethodDelete, "/ingredient", nil)
+			req.SetPathValue("id", tc.id)
 			w := httptest.NewRecorder()
 
 			ctrl.Delete(w, req)
 
 			assert.Equal(t, tc.expectedStatus, w.Code)
-			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, expectedContentType(tc.expectedStatus), w.Header().Get("Content-Type"))
 
 			if tc.expectedBody != "" {
 				assert.JSONEq(t, tc.expectedBody, w.Body.String())
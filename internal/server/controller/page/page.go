@@ -0,0 +1,454 @@
+package controller
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/ingredient"
+	"q-q-tem-pra-hoje/internal/domain/recipe"
+	"q-q-tem-pra-hoje/internal/domain/recommendation"
+	"q-q-tem-pra-hoje/internal/domain/user"
+	"q-q-tem-pra-hoje/internal/server/dto"
+	"q-q-tem-pra-hoje/internal/server/problem"
+	"q-q-tem-pra-hoje/web"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SessionCookie holds the session token of the HTML pages, which cannot
+// send an Authorization header from a plain form.
+const SessionCookie = "qqtem_session"
+
+// LoginCSRFCookie holds the CSRF token of the login and register forms.
+// They are posted before there is a session to derive one from, so the
+// form must post back the value of this cookie, which other sites can
+// neither read nor set.
+const LoginCSRFCookie = "qqtem_login_csrf"
+
+// blankRecipeRows is how many empty ingredient rows the recipe form offers
+// without JavaScript.
+const blankRecipeRows = 3
+
+// PageController renders the HTML pages on top of the same services as the
+// JSON API. Forms post back here and are answered with a redirect, so a
+// reload never submits them twice.
+type PageController struct {
+	auth            user.AuthProvider
+	ingredients     ingredient.IngredientStorageProvider
+	recipes         recipe.RecipeProvider
+	recommendations recommendation.RecommendationProvider
+	ui              *web.UI
+	// secureCookies marks cookies Secure on plain HTTP requests too.
+	secureCookies bool
+	logger        *slog.Logger
+}
+
+func NewPageController(ap user.AuthProvider, is ingredient.IngredientStorageProvider, rs recipe.RecipeProvider, res recommendation.RecommendationProvider, ui *web.UI, secureCookies bool, logger *slog.Logger) *PageController {
+	return &PageController{auth: ap, ingredients: is, recipes: rs, recommendations: res, ui: ui, secureCookies: secureCookies, logger: logger}
+}
+
+// SessionToken returns the token of the session cookie.
+func SessionToken(r *http.Request) (string, bool) {
+	cookie, err := r.Cookie(SessionCookie)
+	if err != nil || cookie.Value == "" {
+		return "", false
+	}
+	return cookie.Value, true
+}
+
+// CSRFToken is the token forms of a session must post back. It is derived
+// from the session token, which other sites cannot read.
+func CSRFToken(sessionToken string) string {
+	sum := sha256.Sum256([]byte("csrf:" + sessionToken))
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidCSRF reports whether a posted form carries the token of its session.
+func ValidCSRF(r *http.Request, sessionToken string) bool {
+	return subtle.ConstantTimeCompare([]byte(r.PostFormValue("csrf")), []byte(CSRFToken(sessionToken))) == 1
+}
+
+type loginForm struct {
+	Email string
+	Next  string
+	CSRF  string
+}
+
+type ingredientForm struct {
+	Name        string
+	Quantity    string
+	MeasureType string
+}
+
+type pantryPage struct {
+	Ingredients []ingredient.Ingredient
+	Search      string
+	Next        string
+	Form        ingredientForm
+}
+
+type recipeForm struct {
+	Name       string
+	Visibility string
+	Rows       []ingredientForm
+}
+
+type recipesPage struct {
+	Recipes []recipe.Recipe
+	Search  string
+	Next    string
+	Form    recipeForm
+}
+
+func (pc *PageController) Home(w http.ResponseWriter, r *http.Request) {
+	http.Redirect(w, r, "/pantry", http.StatusSeeOther)
+}
+
+func (pc *PageController) LoginForm(w http.ResponseWriter, r *http.Request) {
+	form := loginForm{Next: r.URL.Query().Get("next"), CSRF: pc.loginCSRF(w, r)}
+	pc.render(w, r, http.StatusOK, "login", web.View{Title: "Log in", Data: form})
+}
+
+func (pc *PageController) Login(w http.ResponseWriter, r *http.Request) {
+	pc.login(w, r, false)
+}
+
+// Register creates the account and logs it in.
+func (pc *PageController) Register(w http.ResponseWriter, r *http.Request) {
+	pc.login(w, r, true)
+}
+
+func (pc *PageController) login(w http.ResponseWriter, r *http.Request, register bool) {
+	if err := r.ParseForm(); err != nil {
+		pc.fail(w, r, problem.InvalidBody(err))
+		return
+	}
+	cookie, err := r.Cookie(LoginCSRFCookie)
+	if err != nil || cookie.Value == "" || subtle.ConstantTimeCompare([]byte(r.PostFormValue("csrf")), []byte(cookie.Value)) != 1 {
+		pc.fail(w, r, domain.NewForbidden("invalid CSRF token"))
+		return
+	}
+	credentials := user.Credentials{Email: r.PostFormValue("email"), Password: r.PostFormValue("password")}
+	form := loginForm{Email: credentials.Email, Next: r.PostFormValue("next"), CSRF: cookie.Value}
+
+	if register {
+		_, err = pc.auth.Register(r.Context(), credentials)
+	}
+	var token string
+	var expiresAt time.Time
+	if err == nil {
+		token, expiresAt, err = pc.auth.Login(r.Context(), credentials)
+	}
+	if err != nil {
+		pc.failForm(w, r, err, "login", web.View{Title: "Log in", Data: form})
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookie,
+		Value:    token,
+		Path:     "/",
+		Expires:  expiresAt,
+		HttpOnly: true,
+		Secure:   pc.secure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, localPath(form.Next, "/pantry"), http.StatusSeeOther)
+}
+
+// loginCSRF returns the token of the LoginCSRFCookie, setting the cookie
+// first when the browser has none yet.
+func (pc *PageController) loginCSRF(w http.ResponseWriter, r *http.Request) string {
+	if cookie, err := r.Cookie(LoginCSRFCookie); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+	token := rand.Text()
+	http.SetCookie(w, &http.Cookie{Name: LoginCSRFCookie, Value: token, Path: "/", HttpOnly: true, Secure: pc.secure(r), SameSite: http.SameSiteLaxMode})
+	return token
+}
+
+// secure reports whether cookies set in answer to r must be Secure.
+func (pc *PageController) secure(r *http.Request) bool {
+	return pc.secureCookies || r.TLS != nil
+}
+
+func (pc *PageController) Logout(w http.ResponseWriter, r *http.Request) {
+	if token, ok := SessionToken(r); ok {
+		if err := pc.auth.Logout(r.Context(), token); err != nil {
+			pc.fail(w, r, err)
+			return
+		}
+	}
+	http.SetCookie(w, &http.Cookie{Name: SessionCookie, Value: "", Path: "/", MaxAge: -1, HttpOnly: true, Secure: pc.secure(r), SameSite: http.SameSiteLaxMode})
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+func (pc *PageController) Pantry(w http.ResponseWriter, r *http.Request) {
+	pc.renderPantry(w, r, http.StatusOK, ingredientForm{MeasureType: "unit"}, nil)
+}
+
+func (pc *PageController) AddIngredient(w http.ResponseWriter, r *http.Request) {
+	form := ingredientFormFrom(r)
+	ing, err := form.toDomain(nil)
+	if err == nil {
+		err = pc.ingredients.Add(r.Context(), ing)
+	}
+	if err != nil {
+		pc.renderPantry(w, r, http.StatusOK, form, err)
+		return
+	}
+	http.Redirect(w, r, "/pantry", http.StatusSeeOther)
+}
+
+func (pc *PageController) UpdateIngredient(w http.ResponseWriter, r *http.Request) {
+	id, err := dto.PathID(r, "id")
+	if err != nil {
+		pc.fail(w, r, err)
+		return
+	}
+	ing, err := ingredientFormFrom(r).toDomain(&id)
+	if err == nil {
+		err = pc.ingredients.Update(r.Context(), ing)
+	}
+	if err != nil {
+		pc.renderPantry(w, r, http.StatusOK, ingredientForm{MeasureType: "unit"}, err)
+		return
+	}
+	http.Redirect(w, r, "/pantry", http.StatusSeeOther)
+}
+
+func (pc *PageController) DeleteIngredient(w http.ResponseWriter, r *http.Request) {
+	id, err := dto.PathID(r, "id")
+	if err != nil {
+		pc.fail(w, r, err)
+		return
+	}
+	if err := pc.ingredients.Delete(r.Context(), uint(id)); err != nil {
+		pc.fail(w, r, err)
+		return
+	}
+	http.Redirect(w, r, "/pantry", http.StatusSeeOther)
+}
+
+// renderPantry shows the pantry with the add form filled as given, and the
+// failure of the last submission, if any.
+func (pc *PageController) renderPantry(w http.ResponseWriter, r *http.Request, status int, form ingredientForm, failure error) {
+	opts, err := listOptions(r, domain.SortByName)
+	if err != nil {
+		pc.fail(w, r, err)
+		return
+	}
+	page, err := pc.ingredients.ListIngredients(r.Context(), opts)
+	if err != nil {
+		pc.fail(w, r, err)
+		return
+	}
+
+	view := web.View{Title: "Pantry", Data: pantryPage{Ingredients: page.Items, Search: opts.Search, Next: nextCursor(page.Next), Form: form}}
+	if failure != nil {
+		pc.failForm(w, r, failure, "pantry", view)
+		return
+	}
+	pc.render(w, r, status, "pantry", view)
+}
+
+func (pc *PageController) Recipes(w http.ResponseWriter, r *http.Request) {
+	pc.renderRecipes(w, r, recipeForm{Visibility: string(recipe.VisibilityPrivate)}, nil)
+}
+
+func (pc *PageController) CreateRecipe(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		pc.fail(w, r, problem.InvalidBody(err))
+		return
+	}
+	form := recipeForm{Name: strings.TrimSpace(r.PostFormValue("name")), Visibility: r.PostFormValue("visibility")}
+	created := recipe.Recipe{Name: form.Name, Visibility: recipe.Visibility(form.Visibility)}
+	var failure error
+	names, quantities, measures := r.PostForm["ingredientName"], r.PostForm["ingredientQuantity"], r.PostForm["ingredientMeasureType"]
+	for i, name := range names {
+		row := ingredientForm{Name: strings.TrimSpace(name), Quantity: at(quantities, i), MeasureType: at(measures, i)}
+		if row.Name == "" {
+			continue
+		}
+		form.Rows = append(form.Rows, row)
+		ing, err := row.toDomain(nil)
+		if err != nil && failure == nil {
+			failure = err
+		}
+		created.Ingredients = append(created.Ingredients, ing)
+	}
+	if failure == nil {
+		failure = pc.recipes.Create(r.Context(), created)
+	}
+	if failure != nil {
+		pc.renderRecipes(w, r, form, failure)
+		return
+	}
+	http.Redirect(w, r, "/recipes", http.StatusSeeOther)
+}
+
+func (pc *PageController) renderRecipes(w http.ResponseWriter, r *http.Request, form recipeForm, failure error) {
+	opts, err := listOptions(r, domain.SortByName)
+	if err != nil {
+		pc.fail(w, r, err)
+		return
+	}
+	page, err := pc.recipes.ListRecipes(r.Context(), opts)
+	if err != nil {
+		pc.fail(w, r, err)
+		return
+	}
+
+	for len(form.Rows) < blankRecipeRows {
+		form.Rows = append(form.Rows, ingredientForm{MeasureType: "unit"})
+	}
+	view := web.View{Title: "Recipes", Data: recipesPage{Recipes: page.Items, Search: opts.Search, Next: nextCursor(page.Next), Form: form}}
+	if failure != nil {
+		pc.failForm(w, r, failure, "recipes", view)
+		return
+	}
+	pc.render(w, r, http.StatusOK, "recipes", view)
+}
+
+func (pc *PageController) Recipe(w http.ResponseWriter, r *http.Request) {
+	id, err := dto.PathID(r, "id")
+	if err != nil {
+		pc.fail(w, r, err)
+		return
+	}
+	found, err := pc.recipes.FindRecipe(r.Context(), uint(id))
+	if err != nil {
+		pc.fail(w, r, err)
+		return
+	}
+	pc.render(w, r, http.StatusOK, "recipe", web.View{Title: found.Name, Data: &found})
+}
+
+func (pc *PageController) DeleteRecipe(w http.ResponseWriter, r *http.Request) {
+	id, err := dto.PathID(r, "id")
+	if err != nil {
+		pc.fail(w, r, err)
+		return
+	}
+	if err := pc.recipes.Delete(r.Context(), uint(id)); err != nil {
+		pc.fail(w, r, err)
+		return
+	}
+	http.Redirect(w, r, "/recipes", http.StatusSeeOther)
+}
+
+func (pc *PageController) Recommendations(w http.ResponseWriter, r *http.Request) {
+	ingredients, err := pc.ingredients.FindIngredients(r.Context())
+	if err != nil {
+		pc.fail(w, r, err)
+		return
+	}
+	recommendations, err := pc.recommendations.GetRecommendations(r.Context(), &ingredients)
+	if err != nil {
+		pc.fail(w, r, err)
+		return
+	}
+	pc.render(w, r, http.StatusOK, "recommendations", web.View{Title: "Recommendations", Data: recommendations})
+}
+
+func ingredientFormFrom(r *http.Request) ingredientForm {
+	return ingredientForm{
+		Name:        strings.TrimSpace(r.PostFormValue("name")),
+		Quantity:    r.PostFormValue("quantity"),
+		MeasureType: r.PostFormValue("measureType"),
+	}
+}
+
+func (f ingredientForm) toDomain(id *int) (ingredient.Ingredient, error) {
+	quantity, err := strconv.Atoi(strings.TrimSpace(f.Quantity))
+	if err != nil {
+		return ingredient.Ingredient{}, domain.NewValidation(domain.FieldError{Field: "quantity", Message: "ingredient quantity must be a whole number"})
+	}
+	return ingredient.NewIngredient(id, f.Name, f.MeasureType, quantity), nil
+}
+
+func listOptions(r *http.Request, sorts ...domain.SortField) (domain.ListOptions, error) {
+	opts, err := dto.ListOptions(r, domain.DefaultPageSize, sorts...)
+	if err != nil {
+		return opts, err
+	}
+	if opts.Sort == "" {
+		opts.Sort = domain.SortByName
+	}
+	return opts, nil
+}
+
+func nextCursor(next *domain.Cursor) string {
+	if next == nil {
+		return ""
+	}
+	return next.Encode()
+}
+
+func at(values []string, i int) string {
+	if i < len(values) {
+		return values[i]
+	}
+	return ""
+}
+
+// localPath keeps redirects after login on this site.
+func localPath(next string, fallback string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return fallback
+	}
+	return next
+}
+
+// failForm shows a form again with what was wrong with it. Failures that
+// are not about the input get the error page instead.
+func (pc *PageController) failForm(w http.ResponseWriter, r *http.Request, err error, page string, view web.View) {
+	var validation *domain.ValidationError
+	var unauthenticated *domain.UnauthenticatedError
+	var conflict *domain.ConflictError
+	switch {
+	case errors.As(err, &validation):
+		for _, field := range validation.Fields {
+			view.Errors = append(view.Errors, field.Message)
+		}
+	case errors.As(err, &unauthenticated), errors.As(err, &conflict):
+		view.Errors = []string{err.Error()}
+	default:
+		pc.fail(w, r, err)
+		return
+	}
+	view.CSRF = csrfFor(r)
+	pc.render(w, r, problem.FromError(err).Status, page, view)
+}
+
+// fail renders the error page with the status the JSON API would answer.
+func (pc *PageController) fail(w http.ResponseWriter, r *http.Request, err error) {
+	p := problem.FromError(err)
+	if p.Status >= http.StatusInternalServerError {
+		pc.logger.ErrorContext(r.Context(), "page failed", "method", r.Method, "path", r.URL.Path, "error", err)
+	}
+	pc.render(w, r, p.Status, "error", web.View{Title: http.StatusText(p.Status), Errors: []string{p.Detail}})
+}
+
+func (pc *PageController) render(w http.ResponseWriter, r *http.Request, status int, page string, view web.View) {
+	if view.CSRF == "" {
+		view.CSRF = csrfFor(r)
+	}
+	if err := pc.ui.Render(w, status, page, view); err != nil {
+		pc.logger.ErrorContext(r.Context(), "failed to render page", "page", page, "error", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+func csrfFor(r *http.Request) string {
+	if token, ok := SessionToken(r); ok {
+		return CSRFToken(token)
+	}
+	return ""
+}
@@ -0,0 +1,267 @@
+package controller_test
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/ingredient"
+	"q-q-tem-pra-hoje/internal/domain/recipe"
+	"q-q-tem-pra-hoje/internal/domain/recommendation"
+	"q-q-tem-pra-hoje/internal/domain/user"
+	controller "q-q-tem-pra-hoje/internal/server/controller/page"
+	"q-q-tem-pra-hoje/web"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+var expiresAt = time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+type MockAuthService struct {
+	user.AuthProvider
+}
+
+func (m *MockAuthService) Login(ctx context.Context, credentials user.Credentials) (string, time.Time, error) {
+	if credentials.Password != "secret" {
+		return "", time.Time{}, domain.NewUnauthenticated("invalid email or password")
+	}
+	return "token", expiresAt, nil
+}
+
+type MockIngredientService struct {
+	ingredient.IngredientStorageProvider
+	added []ingredient.Ingredient
+}
+
+func (m *MockIngredientService) Add(ctx context.Context, ing ingredient.Ingredient) error {
+	if err := ing.Validate(); err != nil {
+		return err
+	}
+	m.added = append(m.added, ing)
+	return nil
+}
+
+func (m *MockIngredientService) ListIngredients(ctx context.Context, opts domain.ListOptions) (domain.Page[ingredient.Ingredient], error) {
+	return domain.Page[ingredient.Ingredient]{Items: []ingredient.Ingredient{{Name: "Egg", Quantity: 6, MeasureType: "unit"}}}, nil
+}
+
+type MockRecipeService struct {
+	recipe.RecipeProvider
+	created []recipe.Recipe
+}
+
+func (m *MockRecipeService) Create(ctx context.Context, r recipe.Recipe) error {
+	m.created = append(m.created, r)
+	return nil
+}
+
+func (m *MockRecipeService) ListRecipes(ctx context.Context, opts domain.ListOptions) (domain.Page[recipe.Recipe], error) {
+	return domain.Page[recipe.Recipe]{}, nil
+}
+
+func (m *MockRecipeService) FindRecipe(ctx context.Context, id uint) (recipe.Recipe, error) {
+	return recipe.Recipe{}, domain.NewNotFound("recipe", id)
+}
+
+type MockRecommendationService struct {
+	recommendation.RecommendationProvider
+}
+
+func newController(is *MockIngredientService, rs *MockRecipeService) *controller.PageController {
+	return controller.NewPageController(&MockAuthService{}, is, rs, &MockRecommendationService{}, web.New(), false, discardLogger)
+}
+
+func post(target string, form url.Values) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(&http.Cookie{Name: controller.SessionCookie, Value: "token"})
+	return req
+}
+
+// postLogin posts the login form with a CSRF cookie, and the cookie's token
+// unless the form carries one.
+func postLogin(form url.Values) *http.Request {
+	if !form.Has("csrf") {
+		form.Set("csrf", "login-token")
+	}
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(&http.Cookie{Name: controller.LoginCSRFCookie, Value: "login-token"})
+	return req
+}
+
+func TestPageController_LoginForm(t *testing.T) {
+	t.Run("it should set a CSRF cookie and put its token in the form", func(t *testing.T) {
+		ctrl := newController(&MockIngredientService{}, &MockRecipeService{})
+
+		w := httptest.NewRecorder()
+		ctrl.LoginForm(w, httptest.NewRequest(http.MethodGet, "/login", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		cookie := w.Result().Cookies()[0]
+		assert.Equal(t, controller.LoginCSRFCookie, cookie.Name)
+		assert.NotEmpty(t, cookie.Value)
+		assert.True(t, cookie.HttpOnly)
+		assert.Contains(t, w.Body.String(), `name="csrf" value="`+cookie.Value+`"`)
+	})
+
+	t.Run("it should keep the CSRF cookie the browser already has", func(t *testing.T) {
+		ctrl := newController(&MockIngredientService{}, &MockRecipeService{})
+
+		req := httptest.NewRequest(http.MethodGet, "/login", nil)
+		req.AddCookie(&http.Cookie{Name: controller.LoginCSRFCookie, Value: "login-token"})
+		w := httptest.NewRecorder()
+		ctrl.LoginForm(w, req)
+
+		assert.Empty(t, w.Result().Cookies())
+		assert.Contains(t, w.Body.String(), `name="csrf" value="login-token"`)
+	})
+}
+
+func TestPageController_Login(t *testing.T) {
+	t.Run("it should set the session cookie and go to the requested page", func(t *testing.T) {
+		ctrl := newController(&MockIngredientService{}, &MockRecipeService{})
+
+		w := httptest.NewRecorder()
+		ctrl.Login(w, postLogin(url.Values{"email": {"cook@example.com"}, "password": {"secret"}, "next": {"/recipes"}}))
+
+		assert.Equal(t, http.StatusSeeOther, w.Code)
+		assert.Equal(t, "/recipes", w.Header().Get("Location"))
+		cookie := w.Result().Cookies()[0]
+		assert.Equal(t, controller.SessionCookie, cookie.Name)
+		assert.Equal(t, "token", cookie.Value)
+		assert.True(t, cookie.HttpOnly)
+		assert.False(t, cookie.Secure)
+		assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+	})
+
+	t.Run("it should mark the session cookie Secure when told to", func(t *testing.T) {
+		ctrl := controller.NewPageController(&MockAuthService{}, &MockIngredientService{}, &MockRecipeService{}, &MockRecommendationService{}, web.New(), true, discardLogger)
+
+		w := httptest.NewRecorder()
+		ctrl.Login(w, postLogin(url.Values{"email": {"cook@example.com"}, "password": {"secret"}}))
+
+		assert.Equal(t, http.StatusSeeOther, w.Code)
+		assert.True(t, w.Result().Cookies()[0].Secure)
+	})
+
+	t.Run("it should reject a form without a CSRF cookie", func(t *testing.T) {
+		ctrl := newController(&MockIngredientService{}, &MockRecipeService{})
+
+		w := httptest.NewRecorder()
+		ctrl.Login(w, post("/login", url.Values{"email": {"cook@example.com"}, "password": {"secret"}, "csrf": {"login-token"}}))
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Empty(t, w.Result().Cookies())
+	})
+
+	t.Run("it should reject a form whose token does not match its CSRF cookie", func(t *testing.T) {
+		ctrl := newController(&MockIngredientService{}, &MockRecipeService{})
+
+		w := httptest.NewRecorder()
+		ctrl.Register(w, postLogin(url.Values{"email": {"cook@example.com"}, "password": {"secret"}, "csrf": {"forged"}}))
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Empty(t, w.Result().Cookies())
+	})
+
+	t.Run("it should not redirect to another site", func(t *testing.T) {
+		ctrl := newController(&MockIngredientService{}, &MockRecipeService{})
+
+		w := httptest.NewRecorder()
+		ctrl.Login(w, postLogin(url.Values{"email": {"cook@example.com"}, "password": {"secret"}, "next": {"//evil.example.com"}}))
+
+		assert.Equal(t, "/pantry", w.Header().Get("Location"))
+	})
+
+	t.Run("it should show the form again on wrong credentials", func(t *testing.T) {
+		ctrl := newController(&MockIngredientService{}, &MockRecipeService{})
+
+		w := httptest.NewRecorder()
+		ctrl.Login(w, postLogin(url.Values{"email": {"cook@example.com"}, "password": {"wrong"}}))
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid email or password")
+		assert.Contains(t, w.Body.String(), `value="cook@example.com"`)
+		assert.Empty(t, w.Result().Cookies())
+	})
+}
+
+func TestPageController_AddIngredient(t *testing.T) {
+	t.Run("it should add the ingredient and redirect back to the pantry", func(t *testing.T) {
+		is := &MockIngredientService{}
+		ctrl := newController(is, &MockRecipeService{})
+
+		w := httptest.NewRecorder()
+		ctrl.AddIngredient(w, post("/pantry", url.Values{"name": {"Flour"}, "quantity": {"500"}, "measureType": {"g"}}))
+
+		assert.Equal(t, http.StatusSeeOther, w.Code)
+		assert.Equal(t, "/pantry", w.Header().Get("Location"))
+		assert.Equal(t, []ingredient.Ingredient{{Name: "Flour", Quantity: 500, MeasureType: "g"}}, is.added)
+	})
+
+	t.Run("it should keep the input and explain what is wrong", func(t *testing.T) {
+		is := &MockIngredientService{}
+		ctrl := newController(is, &MockRecipeService{})
+
+		w := httptest.NewRecorder()
+		ctrl.AddIngredient(w, post("/pantry", url.Values{"name": {"Flour"}, "quantity": {"lots"}, "measureType": {"g"}}))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Contains(t, w.Body.String(), "whole number")
+		assert.Contains(t, w.Body.String(), `value="Flour"`)
+		assert.Contains(t, w.Body.String(), "Egg")
+		assert.Empty(t, is.added)
+	})
+}
+
+func TestPageController_CreateRecipe(t *testing.T) {
+	t.Run("it should create the recipe from the filled ingredient rows", func(t *testing.T) {
+		rs := &MockRecipeService{}
+		ctrl := newController(&MockIngredientService{}, rs)
+
+		w := httptest.NewRecorder()
+		ctrl.CreateRecipe(w, post("/recipes", url.Values{
+			"name":                  {"Omelette"},
+			"visibility":            {"household"},
+			"ingredientName":        {"Egg", ""},
+			"ingredientQuantity":    {"2", ""},
+			"ingredientMeasureType": {"unit", "unit"},
+		}))
+
+		assert.Equal(t, http.StatusSeeOther, w.Code)
+		assert.Equal(t, []recipe.Recipe{{Name: "Omelette", Visibility: recipe.VisibilityHousehold, Ingredients: []ingredient.Ingredient{{Name: "Egg", Quantity: 2, MeasureType: "unit"}}}}, rs.created)
+	})
+}
+
+func TestPageController_Recipe(t *testing.T) {
+	t.Run("it should render the error page for a missing recipe", func(t *testing.T) {
+		ctrl := newController(&MockIngredientService{}, &MockRecipeService{})
+
+		req := httptest.NewRequest(http.MethodGet, "/recipes/9", nil)
+		req.SetPathValue("id", "9")
+		w := httptest.NewRecorder()
+		ctrl.Recipe(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), "Not Found")
+	})
+	t.Run("it should reject a malformed id like the API does", func(t *testing.T) {
+		ctrl := newController(&MockIngredientService{}, &MockRecipeService{})
+
+		req := httptest.NewRequest(http.MethodGet, "/recipes/abc", nil)
+		req.SetPathValue("id", "abc")
+		w := httptest.NewRecorder()
+		ctrl.Recipe(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "id parameter must be a positive integer")
+	})
+}
@@ -2,86 +2,62 @@ package controller
 
 import (
 	"encoding/json"
-	"errors"
+	"log/slog"
 	"net/http"
+	"q-q-tem-pra-hoje/internal/domain"
 	"q-q-tem-pra-hoje/internal/domain/ingredient"
-	"strconv"
+	"q-q-tem-pra-hoje/internal/server/dto"
+	"q-q-tem-pra-hoje/internal/server/problem"
 
 	"q-q-tem-pra-hoje/internal/domain/recipe"
 )
 
-var (
-	ErrInvalidRequestBody = errors.New("invalid request body")
-	ErrMethodNotAllowed   = errors.New("method not allowed")
-	ErrInvalidId          = errors.New("invalid id parameter")
-)
-
-type Response struct {
-	Message string `json:"message,omitempty"`
-	Data    any    `json:"data,omitempty"`
-}
-
 type RecipeController struct {
 	IngredientProvider ingredient.IngredientStorageProvider
 	RecipeProvider     recipe.RecipeProvider
+	Logger             *slog.Logger
 }
 
-func NewRecipeController(isp ingredient.IngredientStorageProvider, rp recipe.RecipeProvider) *RecipeController {
-	return &RecipeController{IngredientProvider: isp, RecipeProvider: rp}
+func NewRecipeController(isp ingredient.IngredientStorageProvider, rp recipe.RecipeProvider, logger *slog.Logger) *RecipeController {
+	return &RecipeController{IngredientProvider: isp, RecipeProvider: rp, Logger: logger}
 }
 
+// ServeHTTP dispatches the deprecated /recipe route, where DELETE takes the
+// id from the query string. The /api/v1 routes call the handlers directly.
 func (rc RecipeController) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	dto.LegacyQueryID(r)
 	if r.Method == "POST" {
 		rc.Add(w, r)
 		return
 	}
 	if r.Method == "GET" {
-		rc.GetRecipes(w, r)
+		rc.list(w, r, 0)
 		return
 	}
 	if r.Method == "DELETE" {
 		rc.Delete(w, r)
 		return
 	}
-	w.Header().Add("Content-Type", "application/json")
-	w.WriteHeader(http.StatusBadRequest)
-	json.NewEncoder(w).Encode(map[string]string{
-		"message": "Invalid HTTP Method",
-	})
+	rc.respondWithError(w, r, problem.MethodNotAllowed(http.MethodGet, http.MethodPost, http.MethodDelete))
 }
 
 func (rc RecipeController) Add(w http.ResponseWriter, r *http.Request) {
 
-	var recipeDTO struct {
-		Name        string                  `json:"name"`
-		Ingredients []ingredient.Ingredient `json:"ingredients"`
-	}
+	var input dto.RecipeInput
 
-	if err := json.NewDecoder(r.Body).Decode(&recipeDTO); err != nil {
-		w.Header().Add("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"message": "Invalid request body",
-		})
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		rc.respondWithError(w, r, problem.InvalidBody(err))
 		return
 	}
-	recipeCreated, err := recipe.NewRecipe(0, recipeDTO.Name, recipeDTO.Ingredients)
+	recipeCreated, err := input.ToDomain()
 
 	if err != nil {
-		w.Header().Add("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"message": "Invalid request body",
-		})
+		rc.respondWithError(w, r, err)
 		return
 	}
 
-	if err := rc.RecipeProvider.Create(recipe.Recipe(recipeCreated)); err != nil {
-		w.Header().Add("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{
-			"message": "Unexpected error",
-		})
+	if err := rc.RecipeProvider.Create(r.Context(), recipeCreated); err != nil {
+		rc.respondWithError(w, r, err)
 		return
 	}
 	w.Header().Add("Content-Type", "application/json")
@@ -90,43 +66,60 @@ func (rc RecipeController) Add(w http.ResponseWriter, r *http.Request) {
 }
 
 func (rc RecipeController) GetRecipes(w http.ResponseWriter, r *http.Request) {
+	rc.list(w, r, domain.DefaultPageSize)
+}
 
-	recipes, err := rc.RecipeProvider.FindRecipes()
+// list answers a list request, paging by defaultLimit unless it sets a
+// limit. The deprecated route passes 0 and keeps returning every match.
+func (rc RecipeController) list(w http.ResponseWriter, r *http.Request, defaultLimit int) {
+	opts, err := dto.ListOptions(r, defaultLimit, domain.SortByName, domain.SortByCreated)
 	if err != nil {
+		rc.respondWithError(w, r, err)
+		return
+	}
 
-		w.Header().Add("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]string{"message": "No recipes have been found"})
+	page, err := rc.RecipeProvider.ListRecipes(r.Context(), opts)
+	if err != nil {
+		rc.respondWithError(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(&recipes)
+	dto.SetNextPage(w, r, page.Next)
+	rc.respondWithJSON(w, http.StatusOK, dto.Render(w, r, &page.Items, dto.FromRecipes(page.Items)))
 }
 
-func (rc RecipeController) Delete(w http.ResponseWriter, r *http.Request) {
+func (rc RecipeController) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := dto.PathID(r, "id")
+	if err != nil {
+		rc.respondWithError(w, r, err)
+		return
+	}
 
-	idStr := r.URL.Query().Get("id")
-	if idStr == "" {
-		rc.respondWithError(w, http.StatusBadRequest, ErrInvalidId)
+	found, err := rc.RecipeProvider.FindRecipe(r.Context(), uint(id))
+	if err != nil {
+		rc.respondWithError(w, r, err)
 		return
 	}
 
-	id, err := strconv.ParseUint(idStr, 10, 32)
+	rc.respondWithJSON(w, http.StatusOK, dto.Render(w, r, &found, dto.FromRecipe(found)))
+}
+
+func (rc RecipeController) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := dto.PathID(r, "id")
 	if err != nil {
-		rc.respondWithError(w, http.StatusBadRequest, ErrInvalidId)
+		rc.respondWithError(w, r, err)
 		return
 	}
 
-	if err := rc.RecipeProvider.Delete(uint(id)); err != nil {
-		rc.respondWithError(w, http.StatusInternalServerError, errors.New("failed to delete recipe"))
+	if err := rc.RecipeProvider.Delete(r.Context(), uint(id)); err != nil {
+		rc.respondWithError(w, r, err)
 		return
 	}
 	rc.respondWithJSON(w, http.StatusNoContent, nil)
 }
 
-func (rc RecipeController) respondWithError(w http.ResponseWriter, code int, err error) {
-	rc.respondWithJSON(w, code, Response{Message: err.Error()})
+func (rc RecipeController) respondWithError(w http.ResponseWriter, r *http.Request, err error) {
+	problem.Respond(w, r, rc.Logger, err)
 }
 
 func (rc RecipeController) respondWithJSON(w http.ResponseWriter, code int, payload any) {
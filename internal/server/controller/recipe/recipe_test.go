@@ -2,18 +2,31 @@ package controller_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"q-q-tem-pra-hoje/internal/domain"
 	"q-q-tem-pra-hoje/internal/domain/ingredient"
 	"q-q-tem-pra-hoje/internal/domain/recipe"
 	"q-q-tem-pra-hoje/internal/server/controller/recipe"
+	"q-q-tem-pra-hoje/internal/server/dto"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
+var discardLogger = slog.New(slog.DiscardHandler)
+
+func expectedContentType(status int) string {
+	if status >= http.StatusBadRequest {
+		return "application/problem+json"
+	}
+	return "application/json"
+}
+
 type MockedRecipeService struct {
 	err                  func() error
 	hasRecommendations   bool
@@ -21,17 +34,30 @@ type MockedRecipeService struct {
 	mockedDeleteFunction error
 }
 
-func (mrs *MockedRecipeService) Create(rec recipe.Recipe) error {
+func (mrs *MockedRecipeService) Create(ctx context.Context, rec recipe.Recipe) error {
 	if err := mrs.err(); err != nil {
 		return err
 	}
 	return nil
 }
-func (mrs *MockedRecipeService) FindRecipes() ([]recipe.Recipe, error) {
+func (mrs *MockedRecipeService) FindRecipes(ctx context.Context) ([]recipe.Recipe, error) {
 	return mrs.recipes, nil
 }
 
-func (mrs *MockedRecipeService) Delete(uint) error {
+func (mrs *MockedRecipeService) ListRecipes(ctx context.Context, opts domain.ListOptions) (domain.Page[recipe.Recipe], error) {
+	return domain.Page[recipe.Recipe]{Items: mrs.recipes}, nil
+}
+
+func (mrs *MockedRecipeService) FindRecipe(ctx context.Context, id uint) (recipe.Recipe, error) {
+	for _, r := range mrs.recipes {
+		if r.Id != nil && uint(*r.Id) == id {
+			return r, nil
+		}
+	}
+	return recipe.Recipe{}, domain.NewNotFound("recipe", id)
+}
+
+func (mrs *MockedRecipeService) Delete(context.Context, uint) error {
 	return mrs.mockedDeleteFunction
 }
 
@@ -40,11 +66,11 @@ type MockerIngredientStorageService struct {
 	hasIngedients         bool
 }
 
-func (miss *MockerIngredientStorageService) Add(ingredient.Ingredient) error {
+func (miss *MockerIngredientStorageService) Add(context.Context, ingredient.Ingredient) error {
 	return nil
 }
 
-func (miss *MockerIngredientStorageService) FindIngredients() ([]ingredient.Ingredient, error) {
+func (miss *MockerIngredientStorageService) FindIngredients(ctx context.Context) ([]ingredient.Ingredient, error) {
 	miss.findIngredientsCalled = true
 	if miss.hasIngedients == true {
 
@@ -53,22 +79,28 @@ func (miss *MockerIngredientStorageService) FindIngredients() ([]ingredient.Ingr
 	return nil, errors.New("any error")
 }
 
-func (miss *MockerIngredientStorageService) Update(ingredient.Ingredient) error {
+func (miss *MockerIngredientStorageService) ListIngredients(ctx context.Context, opts domain.ListOptions) (domain.Page[ingredient.Ingredient], error) {
+	ingredients, err := miss.FindIngredients(ctx)
+	return domain.Page[ingredient.Ingredient]{Items: ingredients}, err
+}
+
+func (miss *MockerIngredientStorageService) Update(context.Context, ingredient.Ingredient) error {
 	return nil
 }
 
 func TestRecipeController_ServeHTTP(t *testing.T) {
-	t.Run("should return 400 for invalid http method", func(t *testing.T) {
+	t.Run("should return 405 for invalid http method", func(t *testing.T) {
 		service := MockedRecipeService{err: func() error { return nil }}
-		controller := controller.RecipeController{RecipeProvider: &service}
+		controller := controller.RecipeController{RecipeProvider: &service, Logger: discardLogger}
 
 		w := httptest.NewRecorder()
 		body := `{"name":"Rice", "ingredients": [{"name": "Onion", "measureType":"unit","quantity":1}]}`
 		r := httptest.NewRequest("PUT", "/recipe", bytes.NewBufferString(body))
 		controller.ServeHTTP(w, r)
 
-		assert.Equal(t, http.StatusBadRequest, w.Code)
-		assert.JSONEq(t, `{"message":"Invalid HTTP Method"}`, w.Body.String())
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, "GET, POST, DELETE", w.Header().Get("Allow"))
+		assert.JSONEq(t, `{"type":"about:blank","title":"Method Not Allowed","status":405,"detail":"method not allowed","instance":"/recipe"}`, w.Body.String())
 	})
 }
 
@@ -89,17 +121,31 @@ func TestRecipeController_Add(t *testing.T) {
 			serviceReturn: nil,
 		},
 		{
-			testCase:      "should return 400 and message when the input is not valid",
+			testCase:      "should return 400 and a problem when the body is malformed",
 			requestBody:   `{"name":, "ingredients": [{"measureType":"","quantity":1}]}`,
 			statusCode:    http.StatusBadRequest,
-			expectedBody:  `{"message":"Invalid request body"}`,
+			expectedBody:  `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid request body","instance":"/recipe"}`,
+			serviceReturn: nil,
+		},
+		{
+			testCase:      "should return 422 and the invalid fields when the recipe is not valid",
+			requestBody:   `{"name": "", "ingredients": [{"measureType":"unit","quantity":1}]}`,
+			statusCode:    http.StatusUnprocessableEntity,
+			expectedBody:  `{"type":"about:blank","title":"Unprocessable Entity","status":422,"detail":"validation failed","instance":"/recipe","errors":[{"field":"name","message":"recipe name cannot be empty"},{"field":"ingredients[0].name","message":"ingredient name cannot be empty"}]}`,
 			serviceReturn: nil,
 		},
+		{
+			testCase:      "should return 409 when the recipe already exists",
+			requestBody:   `{"name": "Rice", "ingredients": [{"name": "Rice", "measureType":"unit","quantity":1}]}`,
+			statusCode:    http.StatusConflict,
+			expectedBody:  `{"type":"about:blank","title":"Conflict","status":409,"detail":"a recipe named \"Rice\" already exists","instance":"/recipe"}`,
+			serviceReturn: domain.NewConflict("recipe", `a recipe named "Rice" already exists`),
+		},
 		{
 			testCase:      "should return 500 and message when unexpected error happens",
-			requestBody:   `{"name": "Rice", "ingredients": [{"measureType":"unit","quantity":1}]}`,
+			requestBody:   `{"name": "Rice", "ingredients": [{"name": "Rice", "measureType":"unit","quantity":1}]}`,
 			statusCode:    http.StatusInternalServerError,
-			expectedBody:  `{"message":"Unexpected error"}`,
+			expectedBody:  `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/recipe"}`,
 			serviceReturn: errors.New("unexpected error"),
 		},
 	}
@@ -108,7 +154,7 @@ func TestRecipeController_Add(t *testing.T) {
 		t.Run(test.testCase, func(t *testing.T) {
 			service := MockedRecipeService{err: func() error { return test.serviceReturn }}
 
-			controller := controller.RecipeController{RecipeProvider: &service}
+			controller := controller.RecipeController{RecipeProvider: &service, Logger: discardLogger}
 			w := httptest.NewRecorder()
 
 			r := httptest.NewRequest("POST", "/recipe", bytes.NewBufferString(test.requestBody))
@@ -118,7 +164,7 @@ func TestRecipeController_Add(t *testing.T) {
 				assert.JSONEq(t, test.expectedBody, w.Body.String())
 			}
 			assert.Equal(t, test.statusCode, w.Code)
-			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, expectedContentType(test.statusCode), w.Header().Get("Content-Type"))
 		})
 	}
 
@@ -145,13 +191,13 @@ func TestRecipeController_GetRecipes(t *testing.T) {
 			}},
 		}
 		recipeService := MockedRecipeService{recipes: expectedRecipes}
-		controller := controller.RecipeController{RecipeProvider: &recipeService}
+		controller := controller.RecipeController{RecipeProvider: &recipeService, Logger: discardLogger}
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest("GET", "/recipes", bytes.NewBufferString("{}"))
 		controller.GetRecipes(w, r)
 
-		recipeJSON, err := json.Marshal(expectedRecipes)
+		recipeJSON, err := json.Marshal(dto.FromRecipes(expectedRecipes))
 
 		if err != nil {
 			t.Errorf("fail to Marshal expectedRecipe %v", err)
@@ -159,56 +205,136 @@ func TestRecipeController_GetRecipes(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, w.Code)
 		assert.JSONEq(t, string(recipeJSON), w.Body.String())
+		assert.Contains(t, w.Body.String(), `"measureType":"unit"`)
+	})
+
+	t.Run("should return the legacy shape when version 1 is requested", func(t *testing.T) {
+		id := 1
+		recipes := []recipe.Recipe{
+			{Id: &id, Name: "Fries", Ingredients: []ingredient.Ingredient{
+				{Name: "Potato", MeasureType: "unit", Quantity: 2},
+			}},
+		}
+		recipeService := MockedRecipeService{recipes: recipes}
+		controller := controller.RecipeController{RecipeProvider: &recipeService, Logger: discardLogger}
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/recipes", nil)
+		r.Header.Set(dto.VersionHeader, dto.LegacyVersion)
+		controller.GetRecipes(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "true", w.Header().Get("Deprecation"))
+		assert.Equal(t, dto.LegacyVersion, w.Header().Get(dto.VersionHeader))
+		assert.JSONEq(t, `[{"Id":1,"Name":"Fries","Ingredients":[{"Id":null,"Name":"Potato","MeasureType":"unit","Quantity":2}]}]`, w.Body.String())
 
 	})
 
 }
 
+func TestRecipeController_Get(t *testing.T) {
+	id := 7
+	recipes := []recipe.Recipe{
+		{Id: &id, Name: "Fries", Ingredients: []ingredient.Ingredient{
+			{Name: "Potato", MeasureType: "unit", Quantity: 2},
+		}},
+	}
+
+	testCases := []struct {
+		name           string
+		id             string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Existing recipe",
+			id:             "7",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":7,"name":"Fries","ingredients":[{"id":null,"name":"Potato","measureType":"unit","quantity":2}],"visibility":"private"}`,
+		},
+		{
+			name:           "Recipe not found",
+			id:             "8",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"type":"about:blank","title":"Not Found","status":404,"detail":"recipe 8 not found","instance":"/api/v1/recipes/8"}`,
+		},
+		{
+			name:           "Invalid Id format",
+			id:             "abc",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type":"about:blank","title":"Bad Request","status":400,"detail":"id parameter must be a positive integer","instance":"/api/v1/recipes/abc"}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recipeService := MockedRecipeService{recipes: recipes}
+			controller := controller.RecipeController{RecipeProvider: &recipeService, Logger: discardLogger}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/recipes/"+tc.id, nil)
+			req.SetPathValue("id", tc.id)
+			w := httptest.NewRecorder()
+
+			controller.Get(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, expectedContentType(tc.expectedStatus), w.Header().Get("Content-Type"))
+			assert.JSONEq(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
 func TestRecipeController_Delete(t *testing.T) {
 	testCases := []struct {
 		name           string
-		urlPath        string
+		id             string
 		returnValue    error
 		expectedStatus int
 		expectedBody   string
 	}{
 		{
 			name:           "Successful deletion",
-			urlPath:        "/ingredient?id=42",
+			id:             "42",
 			expectedStatus: http.StatusNoContent,
 		},
 		{
 			name:           "Missing Id parameter",
-			urlPath:        "/recipe",
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"message":"invalid id parameter"}`,
+			expectedBody:   `{"type":"about:blank","title":"Bad Request","status":400,"detail":"id parameter is required","instance":"/recipe"}`,
 		},
 		{
 			name:           "Invalid Id format",
-			urlPath:        "/recipe?id=abc",
+			id:             "abc",
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"message":"invalid id parameter"}`,
+			expectedBody:   `{"type":"about:blank","title":"Bad Request","status":400,"detail":"id parameter must be a positive integer","instance":"/recipe"}`,
+		},
+		{
+			name:           "Recipe not found",
+			id:             "42",
+			returnValue:    domain.NewNotFound("recipe", 42),
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"type":"about:blank","title":"Not Found","status":404,"detail":"recipe 42 not found","instance":"/recipe"}`,
 		},
 		{
 			name:           "Service error",
-			urlPath:        "/recipe?id=42",
+			id:             "42",
 			returnValue:    errors.New("service error"),
 			expectedStatus: http.StatusInternalServerError,
-			expectedBody:   `{"message":"failed to delete recipe"}`,
+			expectedBody:   `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/recipe"}`,
 		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			recipeService := MockedRecipeService{mockedDeleteFunction: tc.returnValue}
-			controller := controller.RecipeController{RecipeProvider: &recipeService}
+			controller := controller.RecipeController{RecipeProvider: &recipeService, Logger: discardLogger}
 
-			req := httptest.NewRequest(http.MethodDelete, tc.urlPath, nil)
+			req := httptest.NewRequest(http.MethodDelete, "/recipe", nil)
+			req.SetPathValue("id", tc.id)
 			w := httptest.NewRecorder()
 
 			controller.Delete(w, req)
 
 			assert.Equal(t, tc.expectedStatus, w.Code)
-			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, expectedContentType(tc.expectedStatus), w.Header().Get("Content-Type"))
 
 			if tc.expectedBody != "" {
 				assert.JSONEq(t, tc.expectedBody, w.Body.String())
@@ -2,18 +2,22 @@ package controller
 
 import (
 	"encoding/json"
+	"log/slog"
 	"net/http"
 	"q-q-tem-pra-hoje/internal/domain/ingredient"
 	"q-q-tem-pra-hoje/internal/domain/recommendation"
+	"q-q-tem-pra-hoje/internal/server/dto"
+	"q-q-tem-pra-hoje/internal/server/problem"
 )
 
 type RecommendationController struct {
-	IngredientProvider ingredient.IngredientStorageProvider
-  RecommendationProvider recommendation.RecommendationProvider
+	IngredientProvider     ingredient.IngredientStorageProvider
+	RecommendationProvider recommendation.RecommendationProvider
+	Logger                 *slog.Logger
 }
 
-func NewRecommendationController(isp ingredient.IngredientStorageProvider, rp recommendation.RecommendationProvider) *RecommendationController {
-	return &RecommendationController{IngredientProvider: isp, RecommendationProvider: rp}
+func NewRecommendationController(isp ingredient.IngredientStorageProvider, rp recommendation.RecommendationProvider, logger *slog.Logger) *RecommendationController {
+	return &RecommendationController{IngredientProvider: isp, RecommendationProvider: rp, Logger: logger}
 }
 
 func (rc RecommendationController) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -21,37 +25,25 @@ func (rc RecommendationController) ServeHTTP(w http.ResponseWriter, r *http.Requ
 		rc.GetRecommendation(w, r)
 		return
 	}
-	w.Header().Add("Content-Type", "application/json")
-	w.WriteHeader(http.StatusBadRequest)
-	json.NewEncoder(w).Encode(map[string]string{
-		"message": "Invalid HTTP Method",
-	})
-	return
+	problem.Respond(w, r, rc.Logger, problem.MethodNotAllowed(http.MethodGet))
 }
 
 func (rc RecommendationController) GetRecommendation(w http.ResponseWriter, r *http.Request) {
 
-	ingredients, err := rc.IngredientProvider.FindIngredients()
+	ingredients, err := rc.IngredientProvider.FindIngredients(r.Context())
 	if err != nil {
-		w.Header().Add("Content-Type", "application/json")
-		w.WriteHeader(http.StatusUnprocessableEntity)
-		json.NewEncoder(w).Encode(map[string]string{"message": "No ingredients found"})
+		problem.Respond(w, r, rc.Logger, err)
 		return
-
 	}
 
-	recommendations, err := rc.RecommendationProvider.GetRecommendations(&ingredients)
+	recommendations, err := rc.RecommendationProvider.GetRecommendations(r.Context(), &ingredients)
 	if err != nil {
-
-		w.Header().Add("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]string{"message": "No recommendations have been created"})
+		problem.Respond(w, r, rc.Logger, err)
 		return
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&recommendations); err != nil {
-		w.Header().Add("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(&recommendations)
-		return
-	}
+	payload := dto.Render(w, r, &recommendations, dto.FromRecommendations(recommendations))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(payload)
 }
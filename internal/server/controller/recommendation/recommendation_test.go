@@ -2,10 +2,13 @@ package controller_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"q-q-tem-pra-hoje/internal/domain"
 	"q-q-tem-pra-hoje/internal/domain/ingredient"
 	"q-q-tem-pra-hoje/internal/domain/recommendation"
 	controller "q-q-tem-pra-hoje/internal/server/controller/recommendation"
@@ -14,14 +17,15 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+var discardLogger = slog.New(slog.DiscardHandler)
+
 type MockedRecommendationService struct {
 	err                func() error
 	recommendations    []recommendation.Recommendation
 	hasRecommendations bool
 }
 
-
-func (mrs *MockedRecommendationService) GetRecommendations(ingredient *[]ingredient.Ingredient) ([]recommendation.Recommendation, error) {
+func (mrs *MockedRecommendationService) GetRecommendations(ctx context.Context, ingredient *[]ingredient.Ingredient) ([]recommendation.Recommendation, error) {
 	if mrs.hasRecommendations != false {
 		return mrs.recommendations, nil
 	}
@@ -33,11 +37,11 @@ type MockerIngredientStorageService struct {
 	hasIngedients         bool
 }
 
-func (miss *MockerIngredientStorageService) Add(ingredient.Ingredient) error {
+func (miss *MockerIngredientStorageService) Add(context.Context, ingredient.Ingredient) error {
 	return nil
 }
 
-func (miss *MockerIngredientStorageService) FindIngredients() ([]ingredient.Ingredient, error) {
+func (miss *MockerIngredientStorageService) FindIngredients(ctx context.Context) ([]ingredient.Ingredient, error) {
 	miss.findIngredientsCalled = true
 	if miss.hasIngedients == true {
 
@@ -46,11 +50,16 @@ func (miss *MockerIngredientStorageService) FindIngredients() ([]ingredient.Ingr
 	return nil, errors.New("any error")
 }
 
-func (miss *MockerIngredientStorageService) Update(ingredient.Ingredient) error {
+func (miss *MockerIngredientStorageService) ListIngredients(ctx context.Context, opts domain.ListOptions) (domain.Page[ingredient.Ingredient], error) {
+	ingredients, err := miss.FindIngredients(ctx)
+	return domain.Page[ingredient.Ingredient]{Items: ingredients}, err
+}
+
+func (miss *MockerIngredientStorageService) Update(context.Context, ingredient.Ingredient) error {
 	return nil
 }
 
-func (miss *MockerIngredientStorageService) Delete(uint) error {
+func (miss *MockerIngredientStorageService) Delete(context.Context, uint) error {
 	return nil
 }
 
@@ -59,7 +68,7 @@ func TestRecommendationController_GetRecommendation(t *testing.T) {
 		recommendations := []recommendation.Recommendation{}
 		recommendationService := MockedRecommendationService{recommendations: recommendations, hasRecommendations: true}
 		ingredientService := MockerIngredientStorageService{findIngredientsCalled: false, hasIngedients: true}
-		controller := controller.RecommendationController{RecommendationProvider: &recommendationService, IngredientProvider: &ingredientService}
+		controller := controller.RecommendationController{RecommendationProvider: &recommendationService, IngredientProvider: &ingredientService, Logger: discardLogger}
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest("GET", "/recommendations", bytes.NewBufferString("{}"))
@@ -77,11 +86,11 @@ func TestRecommendationController_GetRecommendation(t *testing.T) {
 
 	})
 
-	t.Run("should return a message", func(t *testing.T) {
+	t.Run("should return a problem when the recommendations fail", func(t *testing.T) {
 		recommendations := []recommendation.Recommendation{}
 		recommendationService := MockedRecommendationService{recommendations: recommendations, hasRecommendations: false}
 		ingredientService := MockerIngredientStorageService{findIngredientsCalled: false, hasIngedients: true}
-		controller := controller.RecommendationController{RecommendationProvider: &recommendationService, IngredientProvider: &ingredientService}
+		controller := controller.RecommendationController{RecommendationProvider: &recommendationService, IngredientProvider: &ingredientService, Logger: discardLogger}
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest("GET", "/recommendations", bytes.NewBufferString("{}"))
@@ -89,24 +98,26 @@ func TestRecommendationController_GetRecommendation(t *testing.T) {
 
 		assert.True(t, ingredientService.findIngredientsCalled)
 
-		assert.Equal(t, http.StatusOK, w.Code)
-		assert.JSONEq(t, `{"message": "No recommendations have been created"}`, w.Body.String())
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/recommendations"}`, w.Body.String())
 
 	})
 
-	t.Run("should return ingredients not found", func(t *testing.T) {
+	t.Run("should return a problem when the ingredients fail", func(t *testing.T) {
 		recommendations := []recommendation.Recommendation{}
 		recommendationService := MockedRecommendationService{recommendations: recommendations}
 		ingredientService := MockerIngredientStorageService{findIngredientsCalled: false, hasIngedients: false}
-		controller := controller.RecommendationController{RecommendationProvider: &recommendationService, IngredientProvider: &ingredientService}
+		controller := controller.RecommendationController{RecommendationProvider: &recommendationService, IngredientProvider: &ingredientService, Logger: discardLogger}
 
 		w := httptest.NewRecorder()
 		r := httptest.NewRequest("GET", "/recommendations", bytes.NewBufferString("{}"))
 		controller.GetRecommendation(w, r)
 
 		assert.True(t, ingredientService.findIngredientsCalled)
-		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
-		assert.JSONEq(t, `{"message": "No ingredients found"}`, w.Body.String())
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/recommendations"}`, w.Body.String())
 
 	})
 
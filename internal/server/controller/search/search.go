@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"q-q-tem-pra-hoje/internal/domain/search"
+	"q-q-tem-pra-hoje/internal/server/dto"
+	"q-q-tem-pra-hoje/internal/server/problem"
+)
+
+type SearchController struct {
+	SearchProvider search.SearchProvider
+	Logger         *slog.Logger
+}
+
+func NewSearchController(sp search.SearchProvider, logger *slog.Logger) *SearchController {
+	return &SearchController{SearchProvider: sp, Logger: logger}
+}
+
+// Search returns the recipes and pantry ingredients matching q, most relevant
+// first.
+func (sc SearchController) Search(w http.ResponseWriter, r *http.Request) {
+	limit, err := dto.Limit(r)
+	if err != nil {
+		problem.Respond(w, r, sc.Logger, err)
+		return
+	}
+
+	results, err := sc.SearchProvider.Search(r.Context(), r.URL.Query().Get("q"), limit)
+	if err != nil {
+		problem.Respond(w, r, sc.Logger, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(dto.FromSearchResults(results))
+}
@@ -0,0 +1,89 @@
+package controller_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"q-q-tem-pra-hoje/internal/domain/search"
+	controller "q-q-tem-pra-hoje/internal/server/controller/search"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+type MockSearchService struct {
+	results   []search.Result
+	err       error
+	lastQuery string
+	lastLimit int
+}
+
+func (m *MockSearchService) Search(ctx context.Context, query string, limit int) ([]search.Result, error) {
+	m.lastQuery = query
+	m.lastLimit = limit
+	return m.results, m.err
+}
+
+func TestSearchController_Search(t *testing.T) {
+	testCases := []struct {
+		name           string
+		target         string
+		mock           *MockSearchService
+		expectedStatus int
+		expectedBody   string
+		expectedLimit  int
+	}{
+		{
+			name:           "Matching results",
+			target:         "/api/v1/search?q=berinjela&limit=5",
+			mock:           &MockSearchService{results: []search.Result{{Kind: search.KindRecipe, Id: 2, Name: "Berinjela à parmegiana", Rank: 1.5}}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[{"kind":"recipe","id":2,"name":"Berinjela à parmegiana","rank":1.5}]`,
+			expectedLimit:  5,
+		},
+		{
+			name:           "No results",
+			target:         "/api/v1/search?q=berinjela",
+			mock:           &MockSearchService{},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[]`,
+		},
+		{
+			name:           "Invalid limit",
+			target:         "/api/v1/search?q=berinjela&limit=abc",
+			mock:           &MockSearchService{},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "Service error",
+			target:         "/api/v1/search?q=berinjela",
+			mock:           &MockSearchService{err: errors.New("database error")},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/api/v1/search"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := controller.NewSearchController(tc.mock, discardLogger)
+
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			w := httptest.NewRecorder()
+
+			ctrl.Search(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedBody != "" {
+				assert.JSONEq(t, tc.expectedBody, w.Body.String())
+			}
+			if tc.expectedStatus == http.StatusOK {
+				assert.Equal(t, "berinjela", tc.mock.lastQuery)
+				assert.Equal(t, tc.expectedLimit, tc.mock.lastLimit)
+			}
+		})
+	}
+}
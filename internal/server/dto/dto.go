@@ -0,0 +1,313 @@
+package dto
+
+import (
+	"q-q-tem-pra-hoje/internal/domain/apikey"
+	"q-q-tem-pra-hoje/internal/domain/backup"
+	"q-q-tem-pra-hoje/internal/domain/household"
+	"q-q-tem-pra-hoje/internal/domain/ingredient"
+	"q-q-tem-pra-hoje/internal/domain/recipe"
+	"q-q-tem-pra-hoje/internal/domain/recommendation"
+	"q-q-tem-pra-hoje/internal/domain/search"
+	"q-q-tem-pra-hoje/internal/domain/user"
+	"time"
+)
+
+// IngredientInput is the body accepted when adding or updating an ingredient,
+// and the shape of each ingredient of a recipe input.
+type IngredientInput struct {
+	Name        string `json:"name"`
+	MeasureType string `json:"measureType"`
+	Quantity    int    `json:"quantity"`
+}
+
+type RecipeInput struct {
+	Name        string            `json:"name"`
+	Ingredients []IngredientInput `json:"ingredients"`
+	Visibility  string            `json:"visibility,omitempty"`
+}
+
+// CredentialsInput is the body accepted by register and login.
+type CredentialsInput struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type HouseholdInput struct {
+	Name string `json:"name"`
+}
+
+type InvitationInput struct {
+	Role string `json:"role"`
+}
+
+type APIKeyInput struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// JoinInput is the body accepted when joining a household by invitation.
+type JoinInput struct {
+	Code string `json:"code"`
+}
+
+// Backup is the document served by export and accepted by import. Its items
+// have the shape of the inputs, since ids are not carried over.
+type Backup struct {
+	Version     int               `json:"version"`
+	ExportedAt  time.Time         `json:"exportedAt"`
+	Household   string            `json:"household"`
+	Ingredients []IngredientInput `json:"ingredients"`
+	Recipes     []RecipeInput     `json:"recipes"`
+}
+
+type ImportCounts struct {
+	Added     int `json:"added"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	Deleted   int `json:"deleted"`
+}
+
+type ImportReport struct {
+	Mode        string       `json:"mode"`
+	DryRun      bool         `json:"dryRun"`
+	Ingredients ImportCounts `json:"ingredients"`
+	Recipes     ImportCounts `json:"recipes"`
+}
+
+type Ingredient struct {
+	ID          *int   `json:"id"`
+	Name        string `json:"name"`
+	MeasureType string `json:"measureType"`
+	Quantity    int    `json:"quantity"`
+}
+
+type Recipe struct {
+	ID          *int         `json:"id"`
+	Name        string       `json:"name"`
+	Ingredients []Ingredient `json:"ingredients"`
+	Visibility  string       `json:"visibility"`
+}
+
+type Recommendation struct {
+	Recommendation int    `json:"recommendation"`
+	Recipe         Recipe `json:"recipe"`
+}
+
+type SearchResult struct {
+	Kind string  `json:"kind"`
+	ID   int     `json:"id"`
+	Name string  `json:"name"`
+	Rank float64 `json:"rank"`
+}
+
+type User struct {
+	ID    int    `json:"id"`
+	Email string `json:"email"`
+}
+
+type Session struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Household is a household as seen by one of its members, with their role.
+type Household struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+type Member struct {
+	UserID int    `json:"userId"`
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+}
+
+// APIKey describes a key without its secret.
+type APIKey struct {
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"`
+	Scopes      []string   `json:"scopes"`
+	HouseholdID int        `json:"householdId"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	LastUsedAt  *time.Time `json:"lastUsedAt"`
+}
+
+// IssuedAPIKey is returned when a key is created or rotated, the only times
+// its secret is shown.
+type IssuedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type Invitation struct {
+	Code      string    `json:"code"`
+	Role      string    `json:"role"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func (i IngredientInput) ToDomain(id *int) ingredient.Ingredient {
+	return ingredient.NewIngredient(id, i.Name, i.MeasureType, i.Quantity)
+}
+
+// ToDomain builds a validated recipe that has not been stored yet.
+func (r RecipeInput) ToDomain() (recipe.Recipe, error) {
+	ingredients := make([]ingredient.Ingredient, len(r.Ingredients))
+	for i, input := range r.Ingredients {
+		ingredients[i] = input.ToDomain(nil)
+	}
+	id := 0
+	created := recipe.Recipe{Id: &id, Name: r.Name, Ingredients: ingredients, Visibility: recipe.Visibility(r.Visibility)}
+	if err := created.Validate(); err != nil {
+		return recipe.Recipe{}, err
+	}
+	return created, nil
+}
+
+func (k APIKeyInput) ToDomain() apikey.Key {
+	scopes := make([]apikey.Scope, len(k.Scopes))
+	for i, scope := range k.Scopes {
+		scopes[i] = apikey.Scope(scope)
+	}
+	return apikey.Key{Name: k.Name, Scopes: scopes}
+}
+
+func (h HouseholdInput) ToDomain() household.Household {
+	return household.Household{Name: h.Name}
+}
+
+func (c CredentialsInput) ToDomain() user.Credentials {
+	return user.Credentials{Email: c.Email, Password: c.Password}
+}
+
+// ToDomain leaves validation to the import, which reports every invalid
+// item at once.
+func (b Backup) ToDomain() backup.Document {
+	document := backup.Document{
+		Version:     b.Version,
+		ExportedAt:  b.ExportedAt,
+		Household:   b.Household,
+		Ingredients: make([]ingredient.Ingredient, len(b.Ingredients)),
+		Recipes:     make([]recipe.Recipe, len(b.Recipes)),
+	}
+	for i, input := range b.Ingredients {
+		document.Ingredients[i] = input.ToDomain(nil)
+	}
+	for i, input := range b.Recipes {
+		ingredients := make([]ingredient.Ingredient, len(input.Ingredients))
+		for j, ing := range input.Ingredients {
+			ingredients[j] = ing.ToDomain(nil)
+		}
+		document.Recipes[i] = recipe.Recipe{Name: input.Name, Ingredients: ingredients, Visibility: recipe.Visibility(input.Visibility)}
+	}
+	return document
+}
+
+func FromBackup(d backup.Document) Backup {
+	result := Backup{
+		Version:     d.Version,
+		ExportedAt:  d.ExportedAt,
+		Household:   d.Household,
+		Ingredients: fromIngredientInputs(d.Ingredients),
+		Recipes:     make([]RecipeInput, len(d.Recipes)),
+	}
+	for i, r := range d.Recipes {
+		result.Recipes[i] = RecipeInput{Name: r.Name, Ingredients: fromIngredientInputs(r.Ingredients), Visibility: string(r.VisibilityOrDefault())}
+	}
+	return result
+}
+
+func fromIngredientInputs(ingredients []ingredient.Ingredient) []IngredientInput {
+	result := make([]IngredientInput, len(ingredients))
+	for i, ing := range ingredients {
+		result[i] = IngredientInput{Name: ing.Name, MeasureType: ing.MeasureType, Quantity: ing.Quantity}
+	}
+	return result
+}
+
+func FromImportReport(r backup.Report) ImportReport {
+	return ImportReport{Mode: string(r.Mode), DryRun: r.DryRun, Ingredients: ImportCounts(r.Ingredients), Recipes: ImportCounts(r.Recipes)}
+}
+
+func FromIngredient(i ingredient.Ingredient) Ingredient {
+	return Ingredient{ID: i.Id, Name: i.Name, MeasureType: i.MeasureType, Quantity: i.Quantity}
+}
+
+// FromIngredients never returns nil, so empty lists are encoded as [].
+func FromIngredients(ingredients []ingredient.Ingredient) []Ingredient {
+	result := make([]Ingredient, len(ingredients))
+	for i, ing := range ingredients {
+		result[i] = FromIngredient(ing)
+	}
+	return result
+}
+
+func FromRecipe(r recipe.Recipe) Recipe {
+	return Recipe{ID: r.Id, Name: r.Name, Ingredients: FromIngredients(r.Ingredients), Visibility: string(r.VisibilityOrDefault())}
+}
+
+func FromRecipes(recipes []recipe.Recipe) []Recipe {
+	result := make([]Recipe, len(recipes))
+	for i, r := range recipes {
+		result[i] = FromRecipe(r)
+	}
+	return result
+}
+
+func FromRecommendations(recommendations []recommendation.Recommendation) []Recommendation {
+	result := make([]Recommendation, len(recommendations))
+	for i, rec := range recommendations {
+		result[i] = Recommendation{Recommendation: rec.Recommendation, Recipe: FromRecipe(rec.Recipe)}
+	}
+	return result
+}
+
+func FromSearchResults(results []search.Result) []SearchResult {
+	result := make([]SearchResult, len(results))
+	for i, r := range results {
+		result[i] = SearchResult{Kind: string(r.Kind), ID: r.Id, Name: r.Name, Rank: r.Rank}
+	}
+	return result
+}
+
+// FromUser never exposes the password hash.
+func FromUser(u user.User) User {
+	return User{ID: u.Id, Email: u.Email}
+}
+
+func FromMembership(m household.Membership) Household {
+	return Household{ID: m.Household.Id, Name: m.Household.Name, Role: string(m.Role)}
+}
+
+func FromMemberships(memberships []household.Membership) []Household {
+	result := make([]Household, len(memberships))
+	for i, m := range memberships {
+		result[i] = FromMembership(m)
+	}
+	return result
+}
+
+func FromMembers(members []household.Member) []Member {
+	result := make([]Member, len(members))
+	for i, m := range members {
+		result[i] = Member{UserID: m.UserId, Email: m.Email, Role: string(m.Role)}
+	}
+	return result
+}
+
+func FromAPIKey(k apikey.Key) APIKey {
+	scopes := make([]string, len(k.Scopes))
+	for i, scope := range k.Scopes {
+		scopes[i] = string(scope)
+	}
+	return APIKey{ID: k.Id, Name: k.Name, Prefix: k.Prefix, Scopes: scopes, HouseholdID: k.HouseholdId, CreatedAt: k.CreatedAt, LastUsedAt: k.LastUsedAt}
+}
+
+func FromAPIKeys(keys []apikey.Key) []APIKey {
+	result := make([]APIKey, len(keys))
+	for i, k := range keys {
+		result[i] = FromAPIKey(k)
+	}
+	return result
+}
@@ -0,0 +1,147 @@
+package dto_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/ingredient"
+	"q-q-tem-pra-hoje/internal/domain/recipe"
+	"q-q-tem-pra-hoje/internal/domain/recommendation"
+	"q-q-tem-pra-hoje/internal/server/dto"
+	"q-q-tem-pra-hoje/internal/server/problem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromRecommendations(t *testing.T) {
+	t.Run("should encode camelCase keys and empty lists", func(t *testing.T) {
+		id := 3
+		recommendations := []recommendation.Recommendation{
+			{Recommendation: 2, Recipe: recipe.Recipe{Id: &id, Name: "Toast"}},
+		}
+
+		body, err := json.Marshal(dto.FromRecommendations(recommendations))
+
+		assert.NoError(t, err)
+		assert.JSONEq(t, `[{"recommendation":2,"recipe":{"id":3,"name":"Toast","ingredients":[],"visibility":"private"}}]`, string(body))
+	})
+
+	t.Run("should encode a nil list as an empty array", func(t *testing.T) {
+		body, err := json.Marshal(dto.FromIngredients(nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "[]", string(body))
+	})
+}
+
+func TestRecipeInput_ToDomain(t *testing.T) {
+	t.Run("should map every ingredient", func(t *testing.T) {
+		input := dto.RecipeInput{Name: "Toast", Ingredients: []dto.IngredientInput{{Name: "Bread", MeasureType: "slice", Quantity: 2}}}
+
+		r, err := input.ToDomain()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Toast", r.Name)
+		assert.Equal(t, []ingredient.Ingredient{{Name: "Bread", MeasureType: "slice", Quantity: 2}}, r.Ingredients)
+	})
+
+	t.Run("should fail validation", func(t *testing.T) {
+		_, err := dto.RecipeInput{}.ToDomain()
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should reject an unknown visibility", func(t *testing.T) {
+		input := dto.RecipeInput{Name: "Toast", Visibility: "friends", Ingredients: []dto.IngredientInput{{Name: "Bread", MeasureType: "slice", Quantity: 2}}}
+
+		_, err := input.ToDomain()
+
+		var validation *domain.ValidationError
+		assert.ErrorAs(t, err, &validation)
+	})
+}
+
+func TestRender(t *testing.T) {
+	t.Run("should serve the current shape by default", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/recipe", nil)
+
+		assert.Equal(t, "current", dto.Render(w, r, "legacy", "current"))
+		assert.Equal(t, dto.CurrentVersion, w.Header().Get(dto.VersionHeader))
+		assert.Empty(t, w.Header().Get("Deprecation"))
+	})
+
+	t.Run("should serve the legacy shape on request", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/recipe", nil)
+		r.Header.Set(dto.VersionHeader, dto.LegacyVersion)
+
+		assert.Equal(t, "legacy", dto.Render(w, r, "legacy", "current"))
+		assert.Equal(t, "true", w.Header().Get("Deprecation"))
+	})
+}
+
+func TestListOptions(t *testing.T) {
+	t.Run("should round trip a cursor", func(t *testing.T) {
+		cursor := domain.Cursor{Key: "Black pepper", ID: 4}
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/recipes?sort=name&cursor="+cursor.Encode(), nil)
+
+		opts, err := dto.ListOptions(r, domain.DefaultPageSize, domain.SortByName)
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.SortByName, opts.Sort)
+		assert.Equal(t, &cursor, opts.After)
+	})
+
+	t.Run("should reject an unknown sort field", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/recipes?sort=quantity", nil)
+
+		_, err := dto.ListOptions(r, domain.DefaultPageSize, domain.SortByName, domain.SortByCreated)
+
+		var validation *domain.ValidationError
+		assert.ErrorAs(t, err, &validation)
+	})
+
+	t.Run("should reject a malformed cursor", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/recipes?cursor=%25%25", nil)
+
+		_, err := dto.ListOptions(r, domain.DefaultPageSize)
+
+		var validation *domain.ValidationError
+		assert.ErrorAs(t, err, &validation)
+	})
+}
+
+func TestPathID(t *testing.T) {
+	for _, tc := range []struct {
+		value    string
+		expected int
+		detail   string
+	}{
+		{value: "42", expected: 42},
+		{value: "", detail: "id parameter is required"},
+		{value: "abc", detail: "id parameter must be a positive integer"},
+		{value: "-1", detail: "id parameter must be a positive integer"},
+		{value: "2147483648", detail: "id parameter must be a positive integer"},
+	} {
+		t.Run(tc.value, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.SetPathValue("id", tc.value)
+
+			id, err := dto.PathID(req, "id")
+
+			if tc.detail == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, id)
+				return
+			}
+			var p *problem.Problem
+			if assert.ErrorAs(t, err, &p) {
+				assert.Equal(t, http.StatusBadRequest, p.Status)
+				assert.Equal(t, tc.detail, p.Detail)
+			}
+		})
+	}
+}
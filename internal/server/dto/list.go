@@ -0,0 +1,64 @@
+package dto
+
+import (
+	"fmt"
+	"net/http"
+	"q-q-tem-pra-hoje/internal/domain"
+	"strconv"
+)
+
+const NextCursorHeader = "X-Next-Cursor"
+
+// ListOptions reads the q, sort, limit and cursor query parameters of a list
+// request. sorts lists the fields the resource can be sorted by. Without a
+// limit, requests get defaultLimit items a page, or every match when it is 0.
+func ListOptions(r *http.Request, defaultLimit int, sorts ...domain.SortField) (domain.ListOptions, error) {
+	query := r.URL.Query()
+	opts := domain.ListOptions{Search: query.Get("q")}
+
+	var err error
+	if opts.Sort, opts.Descending, err = domain.ParseSort(query.Get("sort"), sorts...); err != nil {
+		return opts, err
+	}
+
+	if opts.Limit, err = Limit(r); err != nil {
+		return opts, err
+	}
+	if opts.Limit == 0 {
+		opts.Limit = defaultLimit
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		if opts.After, err = domain.DecodeCursor(cursor); err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+// Limit reads the optional limit query parameter, returning 0 when absent.
+func Limit(r *http.Request) (int, error) {
+	limit := r.URL.Query().Get("limit")
+	if limit == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(limit)
+	if err != nil || n < 1 || n > domain.MaxPageSize {
+		return 0, domain.NewValidation(domain.FieldError{Field: "limit", Message: fmt.Sprintf("limit must be between 1 and %d", domain.MaxPageSize)})
+	}
+	return n, nil
+}
+
+// SetNextPage advertises the next page, if any, both as a raw cursor and as
+// a Link to the same request resumed from it.
+func SetNextPage(w http.ResponseWriter, r *http.Request, next *domain.Cursor) {
+	if next == nil {
+		return
+	}
+	cursor := next.Encode()
+	query := r.URL.Query()
+	query.Set("cursor", cursor)
+
+	w.Header().Set(NextCursorHeader, cursor)
+	w.Header().Add("Link", fmt.Sprintf("<%s?%s>; rel=\"next\"", r.URL.Path, query.Encode()))
+}
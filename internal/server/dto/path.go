@@ -0,0 +1,30 @@
+package dto
+
+import (
+	"net/http"
+	"q-q-tem-pra-hoje/internal/server/problem"
+	"strconv"
+)
+
+// PathID reads the id path parameter called name. Ids are serial integers,
+// so anything beyond 2^31-1 is as malformed as a word.
+func PathID(r *http.Request, name string) (int, error) {
+	value := r.PathValue(name)
+	if value == "" {
+		return 0, problem.BadRequest(name + " parameter is required")
+	}
+
+	id, err := strconv.ParseUint(value, 10, 31)
+	if err != nil {
+		return 0, problem.BadRequest(name + " parameter must be a positive integer")
+	}
+	return int(id), nil
+}
+
+// LegacyQueryID lets the deprecated routes, which take the id from the query
+// string, be read with PathID like the /api/v1 ones.
+func LegacyQueryID(r *http.Request) {
+	if r.PathValue("id") == "" {
+		r.SetPathValue("id", r.URL.Query().Get("id"))
+	}
+}
@@ -0,0 +1,28 @@
+package dto
+
+import "net/http"
+
+const (
+	// VersionHeader lets clients pick the response shape. It is echoed on
+	// responses that honour it.
+	VersionHeader = "X-API-Version"
+
+	// LegacyVersion serves the domain structs as they were encoded before
+	// the DTOs existed, with capitalized keys. It will be removed in the
+	// next release.
+	LegacyVersion = "1"
+	// CurrentVersion serves the camelCase DTOs. It is the default.
+	CurrentVersion = "2"
+)
+
+// Render picks the payload for the version requested by r and marks legacy
+// responses as deprecated.
+func Render(w http.ResponseWriter, r *http.Request, legacy any, current any) any {
+	if r.Header.Get(VersionHeader) == LegacyVersion {
+		w.Header().Set(VersionHeader, LegacyVersion)
+		w.Header().Set("Deprecation", "true")
+		return legacy
+	}
+	w.Header().Set(VersionHeader, CurrentVersion)
+	return current
+}
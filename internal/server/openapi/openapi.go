@@ -0,0 +1,29 @@
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+//go:embed openapi.json
+var spec []byte
+
+//go:embed docs.html
+var docs []byte
+
+// Spec returns the OpenAPI document describing every route of the server.
+func Spec() []byte {
+	return spec
+}
+
+func SpecHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(spec)
+}
+
+// DocsHandler serves a self-contained page that renders the document
+// fetched from /openapi.json.
+func DocsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(docs)
+}
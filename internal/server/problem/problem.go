@@ -0,0 +1,109 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/logging"
+	"strings"
+)
+
+const ContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details object. It also implements error so
+// controllers can return HTTP-specific failures through the same path as
+// domain errors.
+type Problem struct {
+	Type      string              `json:"type"`
+	Title     string              `json:"title"`
+	Status    int                 `json:"status"`
+	Detail    string              `json:"detail,omitempty"`
+	Instance  string              `json:"instance,omitempty"`
+	RequestID string              `json:"requestId,omitempty"`
+	Errors    []domain.FieldError `json:"errors,omitempty"`
+
+	allow []string
+}
+
+func (p *Problem) Error() string {
+	return p.Detail
+}
+
+func New(status int, detail string) *Problem {
+	return &Problem{Type: "about:blank", Title: http.StatusText(status), Status: status, Detail: detail}
+}
+
+func BadRequest(detail string) *Problem {
+	return New(http.StatusBadRequest, detail)
+}
+
+// InvalidBody reports a request body that could not be decoded, telling a
+// body over the size limit apart from a malformed one.
+func InvalidBody(err error) *Problem {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return New(http.StatusRequestEntityTooLarge, fmt.Sprintf("request body must be at most %d bytes", tooLarge.Limit))
+	}
+	return BadRequest("invalid request body")
+}
+
+func MethodNotAllowed(allow ...string) *Problem {
+	p := New(http.StatusMethodNotAllowed, "method not allowed")
+	p.allow = allow
+	return p
+}
+
+// FromError maps domain errors to their HTTP status. Anything unknown is an
+// internal error whose message is not leaked to the client.
+func FromError(err error) *Problem {
+	var p *Problem
+	var notFound *domain.NotFoundError
+	var conflict *domain.ConflictError
+	var validation *domain.ValidationError
+	var unauthenticated *domain.UnauthenticatedError
+	var forbidden *domain.ForbiddenError
+
+	switch {
+	case errors.As(err, &p):
+		return p
+	case errors.As(err, &notFound):
+		return New(http.StatusNotFound, notFound.Error())
+	case errors.As(err, &unauthenticated):
+		return New(http.StatusUnauthorized, unauthenticated.Error())
+	case errors.As(err, &forbidden):
+		return New(http.StatusForbidden, forbidden.Error())
+	case errors.As(err, &conflict):
+		return New(http.StatusConflict, conflict.Error())
+	case errors.As(err, &validation):
+		p := New(http.StatusUnprocessableEntity, "validation failed")
+		p.Errors = validation.Fields
+		return p
+	default:
+		return New(http.StatusInternalServerError, "internal server error")
+	}
+}
+
+// Respond writes err as application/problem+json. Internal errors are logged
+// with their cause since the response deliberately hides it.
+func Respond(w http.ResponseWriter, r *http.Request, logger *slog.Logger, err error) {
+	p := *FromError(err)
+	p.Instance = r.URL.Path
+	p.RequestID = logging.RequestIDFromContext(r.Context())
+
+	if p.Status >= http.StatusInternalServerError {
+		logger.ErrorContext(r.Context(), "request failed", "method", r.Method, "path", r.URL.Path, "error", err)
+	}
+	if len(p.allow) > 0 {
+		w.Header().Set("Allow", strings.Join(p.allow, ", "))
+	}
+	if p.Status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
@@ -0,0 +1,78 @@
+package problem_test
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/logging"
+	"q-q-tem-pra-hoje/internal/server/problem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromError(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedDetail string
+	}{
+		{"not found", domain.NewNotFound("recipe", 1), http.StatusNotFound, "recipe 1 not found"},
+		{"wrapped not found", fmt.Errorf("deleting: %w", domain.NewNotFound("ingredient", 2)), http.StatusNotFound, "ingredient 2 not found"},
+		{"conflict", domain.NewConflict("recipe", "already exists"), http.StatusConflict, "already exists"},
+		{"validation", domain.NewValidation(domain.FieldError{Field: "name", Message: "required"}), http.StatusUnprocessableEntity, "validation failed"},
+		{"unauthenticated", domain.NewUnauthenticated("session expired"), http.StatusUnauthorized, "session expired"},
+		{"forbidden", domain.NewForbidden("viewers cannot change the pantry"), http.StatusForbidden, "viewers cannot change the pantry"},
+		{"problem", problem.BadRequest("bad"), http.StatusBadRequest, "bad"},
+		{"malformed body", problem.InvalidBody(errors.New("unexpected EOF")), http.StatusBadRequest, "invalid request body"},
+		{"body too large", problem.InvalidBody(fmt.Errorf("decoding: %w", &http.MaxBytesError{Limit: 1024})), http.StatusRequestEntityTooLarge, "request body must be at most 1024 bytes"},
+		{"unknown", errors.New("pq: connection refused"), http.StatusInternalServerError, "internal server error"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := problem.FromError(tc.err)
+
+			assert.Equal(t, tc.expectedStatus, p.Status)
+			assert.Equal(t, http.StatusText(tc.expectedStatus), p.Title)
+			assert.Equal(t, tc.expectedDetail, p.Detail)
+		})
+	}
+}
+
+func TestRespond(t *testing.T) {
+	t.Run("should write the problem with the request id", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/recipe", nil)
+		r = r.WithContext(logging.WithRequestID(r.Context(), "abc"))
+		w := httptest.NewRecorder()
+
+		problem.Respond(w, r, slog.New(slog.DiscardHandler), domain.NewValidation(domain.FieldError{Field: "name", Message: "recipe name cannot be empty"}))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"type":"about:blank","title":"Unprocessable Entity","status":422,"detail":"validation failed","instance":"/recipe","requestId":"abc","errors":[{"field":"name","message":"recipe name cannot be empty"}]}`, w.Body.String())
+	})
+
+	t.Run("should set the Allow header for 405", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPut, "/recipe", nil)
+		w := httptest.NewRecorder()
+
+		problem.Respond(w, r, slog.New(slog.DiscardHandler), problem.MethodNotAllowed("GET", "POST"))
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, "GET, POST", w.Header().Get("Allow"))
+	})
+	t.Run("should challenge for a bearer token on 401", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/recipes", nil)
+		w := httptest.NewRecorder()
+
+		problem.Respond(w, r, slog.New(slog.DiscardHandler), domain.NewUnauthenticated("authentication required"))
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+	})
+}
@@ -0,0 +1,164 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/apikey"
+	"q-q-tem-pra-hoje/internal/domain/household"
+	"q-q-tem-pra-hoje/internal/domain/user"
+	"q-q-tem-pra-hoje/internal/tracing"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// lastUsedResolution is how stale last-used tracking may get, so a busy
+// script does not write on every request.
+const lastUsedResolution = time.Minute
+
+// prefixLength is how much of a key is kept in clear to identify it.
+const prefixLength = len(apikey.TokenPrefix) + 6
+
+var errInvalidKey = domain.NewUnauthenticated("invalid API key")
+
+type APIKeyService struct {
+	keys   apikey.APIKeyManager
+	logger *slog.Logger
+}
+
+func NewAPIKeyService(km apikey.APIKeyManager, logger *slog.Logger) *APIKeyService {
+	return &APIKeyService{keys: km, logger: logger}
+}
+
+func (ks *APIKeyService) Create(ctx context.Context, key apikey.Key) (created apikey.Key, token string, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "APIKeyService.Create")
+	defer func() { tracing.End(span, err) }()
+
+	userID, ok := user.UserIDFromContext(ctx)
+	if !ok {
+		return apikey.Key{}, "", domain.NewUnauthenticated("authentication required")
+	}
+	membership, ok := household.MembershipFromContext(ctx)
+	if !ok {
+		return apikey.Key{}, "", domain.NewForbidden("API keys must belong to a household")
+	}
+
+	key.Name = strings.TrimSpace(key.Name)
+	if err := key.Validate(); err != nil {
+		return apikey.Key{}, "", err
+	}
+
+	token, err = newToken()
+	if err != nil {
+		return apikey.Key{}, "", err
+	}
+	key.UserId = userID
+	key.HouseholdId = membership.Household.Id
+	key.Prefix = token[:prefixLength]
+	key.Hash = hashToken(token)
+
+	created, err = ks.keys.AddKey(ctx, key)
+	if err != nil {
+		return apikey.Key{}, "", err
+	}
+	ks.logger.InfoContext(ctx, "api key created", "user_id", userID, "key_id", created.Id, "scopes", created.Scopes)
+	return created, token, nil
+}
+
+func (ks *APIKeyService) FindKeys(ctx context.Context) (keys []apikey.Key, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "APIKeyService.FindKeys")
+	defer func() { tracing.End(span, err) }()
+
+	userID, ok := user.UserIDFromContext(ctx)
+	if !ok {
+		return nil, domain.NewUnauthenticated("authentication required")
+	}
+	return ks.keys.FindKeys(ctx, userID)
+}
+
+// Rotate gives a key a new secret. The old one stops working at once.
+func (ks *APIKeyService) Rotate(ctx context.Context, id int) (rotated apikey.Key, token string, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "APIKeyService.Rotate")
+	defer func() { tracing.End(span, err) }()
+
+	userID, ok := user.UserIDFromContext(ctx)
+	if !ok {
+		return apikey.Key{}, "", domain.NewUnauthenticated("authentication required")
+	}
+
+	token, err = newToken()
+	if err != nil {
+		return apikey.Key{}, "", err
+	}
+	rotated, err = ks.keys.ReplaceHash(ctx, id, userID, token[:prefixLength], hashToken(token))
+	if err != nil {
+		return apikey.Key{}, "", err
+	}
+	ks.logger.InfoContext(ctx, "api key rotated", "user_id", userID, "key_id", id)
+	return rotated, token, nil
+}
+
+func (ks *APIKeyService) Revoke(ctx context.Context, id int) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "APIKeyService.Revoke")
+	defer func() { tracing.End(span, err) }()
+
+	userID, ok := user.UserIDFromContext(ctx)
+	if !ok {
+		return domain.NewUnauthenticated("authentication required")
+	}
+	if err := ks.keys.DeleteKey(ctx, id, userID); err != nil {
+		return err
+	}
+	ks.logger.InfoContext(ctx, "api key revoked", "user_id", userID, "key_id", id)
+	return nil
+}
+
+// Authenticate returns the key a token belongs to and records its use.
+func (ks *APIKeyService) Authenticate(ctx context.Context, token string) (key apikey.Key, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "APIKeyService.Authenticate")
+	defer func() { tracing.End(span, err) }()
+
+	if !apikey.IsKey(token) {
+		return apikey.Key{}, errInvalidKey
+	}
+	key, err = ks.keys.FindKeyByHash(ctx, hashToken(token))
+	var notFound *domain.NotFoundError
+	if errors.As(err, &notFound) {
+		return apikey.Key{}, errInvalidKey
+	}
+	if err != nil {
+		return apikey.Key{}, err
+	}
+	span.SetAttributes(attribute.Int("api_key.id", key.Id))
+
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		if err := ks.keys.TouchKey(ctx, key.Id, now); err != nil {
+			ks.logger.WarnContext(ctx, "failed to record api key use", "key_id", key.Id, "error", err)
+		} else {
+			key.LastUsedAt = &now
+		}
+	}
+	return key, nil
+}
+
+func newToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return apikey.TokenPrefix + base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// hashToken is what keys are stored under, like session tokens.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,149 @@
+package apikey_test
+
+import (
+	"context"
+	"log/slog"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/apikey"
+	"q-q-tem-pra-hoje/internal/domain/household"
+	"q-q-tem-pra-hoje/internal/domain/user"
+	"q-q-tem-pra-hoje/internal/repository/in_memory_repository"
+	apiKeyService "q-q-tem-pra-hoje/internal/service/apikey"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// asMember acts as userID within household 3.
+func asMember(userID int) context.Context {
+	membership := household.Membership{Household: household.Household{Id: 3, Name: "Home"}, UserId: userID, Role: household.RoleMember}
+	return household.WithMembership(user.WithUserID(context.Background(), userID), membership)
+}
+
+var scanner = apikey.Key{Name: " Barcode scanner ", Scopes: []apikey.Scope{apikey.ScopePantryWrite}}
+
+func TestAPIKeyService_Create(t *testing.T) {
+	t.Run("it should store only the hash of a key bound to the household", func(t *testing.T) {
+		keys := in_memory_repository.NewAPIKeyManager()
+		service := apiKeyService.NewAPIKeyService(keys, discardLogger)
+
+		created, token, err := service.Create(asMember(1), scanner)
+
+		require.NoError(t, err)
+		assert.True(t, apikey.IsKey(token))
+		assert.True(t, strings.HasPrefix(token, created.Prefix))
+		assert.Equal(t, "Barcode scanner", created.Name)
+		assert.Equal(t, 1, created.UserId)
+		assert.Equal(t, 3, created.HouseholdId)
+		assert.NotEqual(t, token, keys.Keys[0].Hash)
+	})
+
+	t.Run("it should reject an unknown scope", func(t *testing.T) {
+		service := apiKeyService.NewAPIKeyService(in_memory_repository.NewAPIKeyManager(), discardLogger)
+
+		_, _, err := service.Create(asMember(1), apikey.Key{Name: "Importer", Scopes: []apikey.Scope{"pantry:admin"}})
+
+		var validation *domain.ValidationError
+		require.ErrorAs(t, err, &validation)
+	})
+
+	t.Run("it should need a household", func(t *testing.T) {
+		service := apiKeyService.NewAPIKeyService(in_memory_repository.NewAPIKeyManager(), discardLogger)
+
+		_, _, err := service.Create(user.WithUserID(context.Background(), 1), scanner)
+
+		var forbidden *domain.ForbiddenError
+		assert.ErrorAs(t, err, &forbidden)
+	})
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	t.Run("it should find the key of a token and record its use", func(t *testing.T) {
+		keys := in_memory_repository.NewAPIKeyManager()
+		service := apiKeyService.NewAPIKeyService(keys, discardLogger)
+		created, token, err := service.Create(asMember(1), scanner)
+		require.NoError(t, err)
+
+		key, err := service.Authenticate(context.Background(), token)
+
+		require.NoError(t, err)
+		assert.Equal(t, created.Id, key.Id)
+		require.NotNil(t, keys.Keys[0].LastUsedAt)
+		assert.WithinDuration(t, time.Now(), *keys.Keys[0].LastUsedAt, time.Second)
+	})
+
+	t.Run("it should not record every use within a minute", func(t *testing.T) {
+		keys := in_memory_repository.NewAPIKeyManager()
+		service := apiKeyService.NewAPIKeyService(keys, discardLogger)
+		_, token, err := service.Create(asMember(1), scanner)
+		require.NoError(t, err)
+		recent := time.Now().Add(-30 * time.Second)
+		keys.Keys[0].LastUsedAt = &recent
+
+		_, err = service.Authenticate(context.Background(), token)
+
+		require.NoError(t, err)
+		assert.Equal(t, recent, *keys.Keys[0].LastUsedAt)
+	})
+
+	for _, token := range []string{"qqk_unknown", "session-token"} {
+		t.Run("it should reject "+token, func(t *testing.T) {
+			service := apiKeyService.NewAPIKeyService(in_memory_repository.NewAPIKeyManager(), discardLogger)
+
+			_, err := service.Authenticate(context.Background(), token)
+
+			var unauthenticated *domain.UnauthenticatedError
+			assert.ErrorAs(t, err, &unauthenticated)
+		})
+	}
+}
+
+func TestAPIKeyService_Rotate(t *testing.T) {
+	t.Run("it should replace the token of a key", func(t *testing.T) {
+		service := apiKeyService.NewAPIKeyService(in_memory_repository.NewAPIKeyManager(), discardLogger)
+		created, old, err := service.Create(asMember(1), scanner)
+		require.NoError(t, err)
+
+		rotated, token, err := service.Rotate(asMember(1), created.Id)
+
+		require.NoError(t, err)
+		assert.NotEqual(t, old, token)
+		assert.Equal(t, created.Scopes, rotated.Scopes)
+		_, err = service.Authenticate(context.Background(), old)
+		assert.Error(t, err)
+		_, err = service.Authenticate(context.Background(), token)
+		assert.NoError(t, err)
+	})
+
+	t.Run("it should not rotate the key of someone else", func(t *testing.T) {
+		service := apiKeyService.NewAPIKeyService(in_memory_repository.NewAPIKeyManager(), discardLogger)
+		created, _, err := service.Create(asMember(1), scanner)
+		require.NoError(t, err)
+
+		_, _, err = service.Rotate(asMember(2), created.Id)
+
+		var notFound *domain.NotFoundError
+		assert.ErrorAs(t, err, &notFound)
+	})
+}
+
+func TestAPIKeyService_Revoke(t *testing.T) {
+	t.Run("it should stop a revoked key from authenticating", func(t *testing.T) {
+		service := apiKeyService.NewAPIKeyService(in_memory_repository.NewAPIKeyManager(), discardLogger)
+		created, token, err := service.Create(asMember(1), scanner)
+		require.NoError(t, err)
+
+		require.NoError(t, service.Revoke(asMember(1), created.Id))
+
+		_, err = service.Authenticate(context.Background(), token)
+		assert.Error(t, err)
+		keys, err := service.FindKeys(asMember(1))
+		require.NoError(t, err)
+		assert.Empty(t, keys)
+	})
+}
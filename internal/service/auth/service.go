@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/user"
+	"q-q-tem-pra-hoje/internal/tracing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var errInvalidCredentials = domain.NewUnauthenticated("invalid email or password")
+
+// dummyHash is compared against when the email is unknown, so a failed login
+// takes as long whether or not the account exists.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not a real password"), bcrypt.DefaultCost)
+
+type AuthService struct {
+	users    user.UserManager
+	sessions user.SessionManager
+	ttl      time.Duration
+	logger   *slog.Logger
+}
+
+// NewAuthService issues sessions that stay valid for ttl after login.
+func NewAuthService(um user.UserManager, sm user.SessionManager, ttl time.Duration, logger *slog.Logger) *AuthService {
+	return &AuthService{users: um, sessions: sm, ttl: ttl, logger: logger}
+}
+
+func (as *AuthService) Register(ctx context.Context, credentials user.Credentials) (registered user.User, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "AuthService.Register")
+	defer func() { tracing.End(span, err) }()
+
+	credentials = credentials.Normalize()
+	if err := credentials.Validate(); err != nil {
+		return user.User{}, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(credentials.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return user.User{}, err
+	}
+
+	registered, err = as.users.AddUser(ctx, user.User{Email: credentials.Email, PasswordHash: string(hash)})
+	if err != nil {
+		return user.User{}, err
+	}
+	as.logger.InfoContext(ctx, "user registered", "user_id", registered.Id)
+	return registered, nil
+}
+
+func (as *AuthService) Login(ctx context.Context, credentials user.Credentials) (token string, expiresAt time.Time, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "AuthService.Login")
+	defer func() { tracing.End(span, err) }()
+
+	credentials = credentials.Normalize()
+	found, err := as.users.FindUserByEmail(ctx, credentials.Email)
+	var notFound *domain.NotFoundError
+	if errors.As(err, &notFound) {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(credentials.Password))
+		return "", time.Time{}, errInvalidCredentials
+	}
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(found.PasswordHash), []byte(credentials.Password)) != nil {
+		return "", time.Time{}, errInvalidCredentials
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", time.Time{}, err
+	}
+	token = base64.RawURLEncoding.EncodeToString(raw)
+	expiresAt = time.Now().Add(as.ttl)
+
+	if err := as.sessions.AddSession(ctx, user.Session{TokenHash: hashToken(token), UserId: found.Id, ExpiresAt: expiresAt}); err != nil {
+		return "", time.Time{}, err
+	}
+	span.SetAttributes(attribute.Int("user.id", found.Id))
+	as.logger.InfoContext(ctx, "user logged in", "user_id", found.Id)
+	return token, expiresAt, nil
+}
+
+func (as *AuthService) Logout(ctx context.Context, token string) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "AuthService.Logout")
+	defer func() { tracing.End(span, err) }()
+
+	return as.sessions.DeleteSession(ctx, hashToken(token))
+}
+
+// Authenticate returns the user a bearer token was issued to. Expired
+// sessions are deleted on sight.
+func (as *AuthService) Authenticate(ctx context.Context, token string) (userID int, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "AuthService.Authenticate")
+	defer func() { tracing.End(span, err) }()
+
+	session, err := as.sessions.FindSession(ctx, hashToken(token))
+	var notFound *domain.NotFoundError
+	if errors.As(err, &notFound) {
+		return 0, domain.NewUnauthenticated("invalid or expired token")
+	}
+	if err != nil {
+		return 0, err
+	}
+	if !time.Now().Before(session.ExpiresAt) {
+		if err := as.sessions.DeleteSession(ctx, session.TokenHash); err != nil {
+			as.logger.WarnContext(ctx, "failed to delete expired session", "error", err)
+		}
+		return 0, domain.NewUnauthenticated("invalid or expired token")
+	}
+	return session.UserId, nil
+}
+
+// CurrentUser returns the user ctx acts for.
+func (as *AuthService) CurrentUser(ctx context.Context) (user.User, error) {
+	id, ok := user.UserIDFromContext(ctx)
+	if !ok {
+		return user.User{}, domain.NewUnauthenticated("authentication required")
+	}
+	return as.users.FindUser(ctx, id)
+}
+
+// hashToken is what sessions are stored under, so a leaked table does not
+// leak usable tokens. Tokens are random, so a fast hash is enough.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
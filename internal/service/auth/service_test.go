@@ -0,0 +1,118 @@
+package auth_test
+
+import (
+	"context"
+	"log/slog"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/user"
+	"q-q-tem-pra-hoje/internal/repository/in_memory_repository"
+	authService "q-q-tem-pra-hoje/internal/service/auth"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+var credentials = user.Credentials{Email: "Cook@Example.com ", Password: "correct horse"}
+
+func TestAuthService_Register(t *testing.T) {
+	t.Run("it should store a normalized email and a bcrypt hash", func(t *testing.T) {
+		users := in_memory_repository.NewUserManager()
+		service := authService.NewAuthService(users, in_memory_repository.NewSessionManager(), time.Hour, discardLogger)
+
+		registered, err := service.Register(context.Background(), credentials)
+
+		require.NoError(t, err)
+		assert.Equal(t, "cook@example.com", registered.Email)
+		assert.NotContains(t, users.Users[0].PasswordHash, credentials.Password)
+		assert.Contains(t, users.Users[0].PasswordHash, "$2a$")
+	})
+
+	t.Run("it should reject a taken email", func(t *testing.T) {
+		service := authService.NewAuthService(in_memory_repository.NewUserManager(), in_memory_repository.NewSessionManager(), time.Hour, discardLogger)
+		_, err := service.Register(context.Background(), credentials)
+		require.NoError(t, err)
+
+		_, err = service.Register(context.Background(), user.Credentials{Email: "cook@example.com", Password: "another password"})
+
+		var conflict *domain.ConflictError
+		assert.ErrorAs(t, err, &conflict)
+	})
+
+	t.Run("it should validate the credentials", func(t *testing.T) {
+		service := authService.NewAuthService(in_memory_repository.NewUserManager(), in_memory_repository.NewSessionManager(), time.Hour, discardLogger)
+
+		_, err := service.Register(context.Background(), user.Credentials{Email: "cook", Password: "short"})
+
+		var validation *domain.ValidationError
+		require.ErrorAs(t, err, &validation)
+		assert.Len(t, validation.Fields, 2)
+	})
+
+	t.Run("it should reject a password bcrypt cannot hash", func(t *testing.T) {
+		service := authService.NewAuthService(in_memory_repository.NewUserManager(), in_memory_repository.NewSessionManager(), time.Hour, discardLogger)
+
+		_, err := service.Register(context.Background(), user.Credentials{Email: "long@example.com", Password: strings.Repeat("é", 37)})
+
+		var validation *domain.ValidationError
+		require.ErrorAs(t, err, &validation)
+		assert.Equal(t, []domain.FieldError{{Field: "password", Message: "password must have at most 72 bytes"}}, validation.Fields)
+
+		_, err = service.Register(context.Background(), user.Credentials{Email: "long@example.com", Password: strings.Repeat("é", 36)})
+		assert.NoError(t, err)
+	})
+}
+
+func TestAuthService_Login(t *testing.T) {
+	newService := func(t *testing.T, ttl time.Duration) *authService.AuthService {
+		service := authService.NewAuthService(in_memory_repository.NewUserManager(), in_memory_repository.NewSessionManager(), ttl, discardLogger)
+		_, err := service.Register(context.Background(), credentials)
+		require.NoError(t, err)
+		return service
+	}
+
+	t.Run("it should issue a token that authenticates the user until logout", func(t *testing.T) {
+		service := newService(t, time.Hour)
+
+		token, expiresAt, err := service.Login(context.Background(), user.Credentials{Email: "COOK@example.com", Password: credentials.Password})
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Minute)
+
+		userID, err := service.Authenticate(context.Background(), token)
+		require.NoError(t, err)
+		current, err := service.CurrentUser(user.WithUserID(context.Background(), userID))
+		require.NoError(t, err)
+		assert.Equal(t, "cook@example.com", current.Email)
+
+		require.NoError(t, service.Logout(context.Background(), token))
+		_, err = service.Authenticate(context.Background(), token)
+		var unauthenticated *domain.UnauthenticatedError
+		assert.ErrorAs(t, err, &unauthenticated)
+	})
+
+	t.Run("it should reject a wrong password or unknown email alike", func(t *testing.T) {
+		service := newService(t, time.Hour)
+
+		_, _, wrongPassword := service.Login(context.Background(), user.Credentials{Email: credentials.Email, Password: "wrong password"})
+		_, _, unknownEmail := service.Login(context.Background(), user.Credentials{Email: "nobody@example.com", Password: credentials.Password})
+
+		var unauthenticated *domain.UnauthenticatedError
+		assert.ErrorAs(t, wrongPassword, &unauthenticated)
+		assert.Equal(t, wrongPassword, unknownEmail)
+	})
+
+	t.Run("it should reject an expired token", func(t *testing.T) {
+		service := newService(t, -time.Minute)
+
+		token, _, err := service.Login(context.Background(), credentials)
+		require.NoError(t, err)
+
+		_, err = service.Authenticate(context.Background(), token)
+		var unauthenticated *domain.UnauthenticatedError
+		assert.ErrorAs(t, err, &unauthenticated)
+	})
+}
@@ -0,0 +1,246 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/backup"
+	"q-q-tem-pra-hoje/internal/domain/household"
+	"q-q-tem-pra-hoje/internal/domain/ingredient"
+	"q-q-tem-pra-hoje/internal/domain/recipe"
+	"q-q-tem-pra-hoje/internal/domain/user"
+	"q-q-tem-pra-hoje/internal/tracing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// errDryRun rolls back the transaction of a dry run once it has been
+// reported.
+var errDryRun = errors.New("dry run")
+
+type BackupService struct {
+	ingredients ingredient.IngredientStorageManager
+	recipes     recipe.RecipeManager
+	transactor  domain.Transactor
+	logger      *slog.Logger
+	now         func() time.Time
+}
+
+func NewBackupService(ism ingredient.IngredientStorageManager, rm recipe.RecipeManager, transactor domain.Transactor, logger *slog.Logger) *BackupService {
+	return &BackupService{ingredients: ism, recipes: rm, transactor: transactor, logger: logger, now: time.Now}
+}
+
+// Export returns the pantry of the household ctx acts within and the
+// recipes of the user it acts for. Recipes shared by others are theirs to
+// export.
+func (bs *BackupService) Export(ctx context.Context) (document backup.Document, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "BackupService.Export")
+	defer func() {
+		span.SetAttributes(
+			attribute.Int("ingredients.count", len(document.Ingredients)),
+			attribute.Int("recipes.count", len(document.Recipes)),
+		)
+		tracing.End(span, err)
+	}()
+
+	pantry, err := bs.ingredients.FindIngredients(ctx)
+	if err != nil {
+		return backup.Document{}, err
+	}
+	recipes, err := bs.recipes.GetAllRecipes(ctx)
+	if err != nil {
+		return backup.Document{}, err
+	}
+
+	document = backup.Document{
+		Version:     backup.Version,
+		ExportedAt:  bs.now().UTC(),
+		Ingredients: make([]ingredient.Ingredient, len(pantry)),
+		Recipes:     []recipe.Recipe{},
+	}
+	if m, ok := household.MembershipFromContext(ctx); ok {
+		document.Household = m.Household.Name
+	}
+	for i, ing := range pantry {
+		ing.Id = nil
+		document.Ingredients[i] = ing
+	}
+	for _, r := range recipes {
+		if authoredBy(ctx, r) {
+			r.Id, r.UserId = nil, nil
+			document.Recipes = append(document.Recipes, r)
+		}
+	}
+	return document, nil
+}
+
+// Import loads the document into the household ctx acts within, as recipes
+// of the user it acts for, in a single transaction: either all of it is
+// kept or none. Items are matched by name. A dry run reports the same
+// counts and then rolls back.
+func (bs *BackupService) Import(ctx context.Context, document backup.Document, opts backup.ImportOptions) (report backup.Report, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "BackupService.Import")
+	defer func() {
+		span.SetAttributes(attribute.String("import.mode", string(opts.Mode)), attribute.Bool("import.dry_run", opts.DryRun))
+		tracing.End(span, err)
+	}()
+
+	if opts.Mode == "" {
+		opts.Mode = backup.ModeMerge
+	}
+	if !opts.Mode.Valid() {
+		return backup.Report{}, domain.NewValidation(domain.FieldError{Field: "mode", Message: "mode must be one of merge, replace"})
+	}
+	if err := document.Validate(); err != nil {
+		return backup.Report{}, err
+	}
+	if err := household.RequireEditor(ctx); err != nil {
+		return backup.Report{}, err
+	}
+
+	report = backup.Report{Mode: opts.Mode, DryRun: opts.DryRun}
+	err = bs.transactor.Transaction(ctx, func(ctx context.Context) error {
+		if report.Ingredients, err = bs.importIngredients(ctx, document.Ingredients, opts.Mode); err != nil {
+			return err
+		}
+		if report.Recipes, err = bs.importRecipes(ctx, document.Recipes, opts.Mode); err != nil {
+			return err
+		}
+		if opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return backup.Report{}, err
+	}
+
+	bs.logger.InfoContext(ctx, "backup imported", "mode", opts.Mode, "dry_run", opts.DryRun,
+		"ingredients_added", report.Ingredients.Added, "ingredients_updated", report.Ingredients.Updated, "ingredients_deleted", report.Ingredients.Deleted,
+		"recipes_added", report.Recipes.Added, "recipes_updated", report.Recipes.Updated, "recipes_deleted", report.Recipes.Deleted)
+	return report, nil
+}
+
+// importIngredients sets the pantry's quantity of every ingredient of the
+// document to the document's, rather than adding to it, so importing the
+// same document twice changes nothing.
+func (bs *BackupService) importIngredients(ctx context.Context, ingredients []ingredient.Ingredient, mode backup.Mode) (counts backup.Counts, err error) {
+	pantry, err := bs.ingredients.FindIngredients(ctx)
+	if err != nil {
+		return counts, err
+	}
+	existing := make(map[string]ingredient.Ingredient, len(pantry))
+	for _, ing := range pantry {
+		existing[ing.Name] = ing
+	}
+
+	imported := make(map[string]bool, len(ingredients))
+	for _, ing := range ingredients {
+		imported[ing.Name] = true
+		found, ok := existing[ing.Name]
+		switch {
+		case !ok:
+			ing.Id = nil
+			err = bs.ingredients.AddIngredient(ctx, ing)
+			counts.Added++
+		case found.MeasureType == ing.MeasureType && found.Quantity == ing.Quantity:
+			counts.Unchanged++
+		default:
+			ing.Id = found.Id
+			err = bs.ingredients.Update(ctx, ing)
+			counts.Updated++
+		}
+		if err != nil {
+			return counts, err
+		}
+	}
+
+	if mode == backup.ModeReplace {
+		for _, ing := range pantry {
+			if imported[ing.Name] {
+				continue
+			}
+			if err := bs.ingredients.Delete(ctx, uint(*ing.Id)); err != nil {
+				return counts, err
+			}
+			counts.Deleted++
+		}
+	}
+	return counts, nil
+}
+
+func (bs *BackupService) importRecipes(ctx context.Context, recipes []recipe.Recipe, mode backup.Mode) (counts backup.Counts, err error) {
+	visible, err := bs.recipes.GetAllRecipes(ctx)
+	if err != nil {
+		return counts, err
+	}
+	existing := map[string]recipe.Recipe{}
+	for _, r := range visible {
+		if authoredBy(ctx, r) {
+			existing[r.Name] = r
+		}
+	}
+
+	imported := make(map[string]bool, len(recipes))
+	for _, r := range recipes {
+		imported[r.Name] = true
+		if found, ok := existing[r.Name]; ok && sameRecipe(found, r) {
+			counts.Unchanged++
+			continue
+		}
+		r.Id = nil
+		added, err := bs.recipes.UpsertRecipe(ctx, r)
+		if err != nil {
+			return counts, err
+		}
+		if added {
+			counts.Added++
+		} else {
+			counts.Updated++
+		}
+	}
+
+	if mode == backup.ModeReplace {
+		for _, r := range existing {
+			if imported[r.Name] {
+				continue
+			}
+			if err := bs.recipes.DeleteRecipe(ctx, uint(*r.Id)); err != nil {
+				return counts, err
+			}
+			counts.Deleted++
+		}
+	}
+	return counts, nil
+}
+
+// authoredBy tells whether ctx acts for the author of r. System scope acts
+// for everyone.
+func authoredBy(ctx context.Context, r recipe.Recipe) bool {
+	id, ok := user.UserIDFromContext(ctx)
+	if !ok {
+		return true
+	}
+	return r.UserId != nil && *r.UserId == id
+}
+
+// sameRecipe compares what an import would write. Repositories do not keep
+// the order of ingredients.
+func sameRecipe(stored recipe.Recipe, imported recipe.Recipe) bool {
+	if stored.VisibilityOrDefault() != imported.VisibilityOrDefault() || len(stored.Ingredients) != len(imported.Ingredients) {
+		return false
+	}
+	byName := make(map[string]ingredient.Ingredient, len(stored.Ingredients))
+	for _, ing := range stored.Ingredients {
+		byName[ing.Name] = ing
+	}
+	for _, ing := range imported.Ingredients {
+		found, ok := byName[ing.Name]
+		if !ok || found.MeasureType != ing.MeasureType || found.Quantity != ing.Quantity {
+			return false
+		}
+	}
+	return true
+}
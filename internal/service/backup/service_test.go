@@ -0,0 +1,244 @@
+package backup_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/backup"
+	"q-q-tem-pra-hoje/internal/domain/household"
+	"q-q-tem-pra-hoje/internal/domain/ingredient"
+	"q-q-tem-pra-hoje/internal/domain/recipe"
+	"q-q-tem-pra-hoje/internal/domain/user"
+	"q-q-tem-pra-hoje/internal/repository/in_memory_repository"
+	backupService "q-q-tem-pra-hoje/internal/service/backup"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// actAs acts as userID with role within household 3.
+func actAs(userID int, role household.Role) context.Context {
+	membership := household.Membership{Household: household.Household{Id: 3, Name: "Home"}, UserId: userID, Role: role}
+	return household.WithMembership(user.WithUserID(context.Background(), userID), membership)
+}
+
+var (
+	asAlice = actAs(1, household.RoleOwner)
+	asBob   = actAs(2, household.RoleMember)
+	asCarol = actAs(3, household.RoleViewer)
+
+	rice  = ingredient.Ingredient{Name: "Rice", MeasureType: "g", Quantity: 500}
+	beans = ingredient.Ingredient{Name: "Beans", MeasureType: "g", Quantity: 250}
+
+	plainRice     = recipe.Recipe{Name: "Plain rice", Ingredients: []ingredient.Ingredient{{Name: "Rice", MeasureType: "g", Quantity: 100}}, Visibility: recipe.VisibilityPrivate}
+	riceAndBeans  = recipe.Recipe{Name: "Rice and beans", Ingredients: []ingredient.Ingredient{{Name: "Rice", MeasureType: "g", Quantity: 100}, {Name: "Beans", MeasureType: "g", Quantity: 100}}, Visibility: recipe.VisibilityHousehold}
+	bobsFavourite = recipe.Recipe{Name: "Bob's beans", Ingredients: []ingredient.Ingredient{{Name: "Beans", MeasureType: "g", Quantity: 200}}, Visibility: recipe.VisibilityPublic}
+)
+
+type fixture struct {
+	ingredients ingredient.IngredientStorageManager
+	recipes     recipe.RecipeManager
+	service     *backupService.BackupService
+}
+
+// newFixture stores Alice's rice and plain rice recipe, and Bob's public
+// recipe.
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+	ism := in_memory_repository.NewIngredientStorageManager()
+	rm := in_memory_repository.NewRecipeManager([]recipe.Recipe{})
+	require.NoError(t, ism.AddIngredient(asAlice, rice))
+	require.NoError(t, rm.AddRecipe(asAlice, plainRice))
+	require.NoError(t, rm.AddRecipe(asBob, bobsFavourite))
+	return fixture{ingredients: ism, recipes: rm, service: backupService.NewBackupService(ism, rm, in_memory_repository.NewTransactor(), discardLogger)}
+}
+
+func (f fixture) pantry(t *testing.T) []ingredient.Ingredient {
+	t.Helper()
+	pantry, err := f.ingredients.FindIngredients(asAlice)
+	require.NoError(t, err)
+	for i := range pantry {
+		pantry[i].Id = nil
+	}
+	return pantry
+}
+
+func (f fixture) recipeNames(t *testing.T) []string {
+	t.Helper()
+	recipes, err := f.recipes.GetAllRecipes(asAlice)
+	require.NoError(t, err)
+	names := make([]string, len(recipes))
+	for i, r := range recipes {
+		names[i] = r.Name
+	}
+	return names
+}
+
+func TestBackupService_Export(t *testing.T) {
+	t.Run("it should export the pantry and the caller's own recipes without ids", func(t *testing.T) {
+		f := newFixture(t)
+
+		document, err := f.service.Export(asAlice)
+
+		require.NoError(t, err)
+		assert.Equal(t, backup.Version, document.Version)
+		assert.Equal(t, "Home", document.Household)
+		assert.False(t, document.ExportedAt.IsZero())
+		assert.Equal(t, []ingredient.Ingredient{rice}, document.Ingredients)
+		assert.Equal(t, []recipe.Recipe{plainRice}, document.Recipes)
+	})
+
+	t.Run("it should be importable as is", func(t *testing.T) {
+		f := newFixture(t)
+		document, err := f.service.Export(asAlice)
+		require.NoError(t, err)
+
+		report, err := f.service.Import(asAlice, document, backup.ImportOptions{})
+
+		require.NoError(t, err)
+		assert.Equal(t, backup.Counts{Unchanged: 1}, report.Ingredients)
+		assert.Equal(t, backup.Counts{Unchanged: 1}, report.Recipes)
+	})
+}
+
+func TestBackupService_Import(t *testing.T) {
+	document := backup.Document{
+		Version:     backup.Version,
+		Ingredients: []ingredient.Ingredient{{Name: "Rice", MeasureType: "g", Quantity: 750}, beans},
+		Recipes:     []recipe.Recipe{riceAndBeans},
+	}
+
+	t.Run("it should merge the document into what is stored", func(t *testing.T) {
+		f := newFixture(t)
+
+		report, err := f.service.Import(asAlice, document, backup.ImportOptions{Mode: backup.ModeMerge})
+
+		require.NoError(t, err)
+		assert.Equal(t, backup.Report{
+			Mode:        backup.ModeMerge,
+			Ingredients: backup.Counts{Added: 1, Updated: 1},
+			Recipes:     backup.Counts{Added: 1},
+		}, report)
+		assert.ElementsMatch(t, document.Ingredients, f.pantry(t))
+		assert.ElementsMatch(t, []string{"Plain rice", "Rice and beans", "Bob's beans"}, f.recipeNames(t))
+	})
+
+	t.Run("it should change nothing when the same document is imported twice", func(t *testing.T) {
+		f := newFixture(t)
+		_, err := f.service.Import(asAlice, document, backup.ImportOptions{})
+		require.NoError(t, err)
+
+		report, err := f.service.Import(asAlice, document, backup.ImportOptions{})
+
+		require.NoError(t, err)
+		assert.Equal(t, backup.Counts{Unchanged: 2}, report.Ingredients)
+		assert.Equal(t, backup.Counts{Unchanged: 1}, report.Recipes)
+		assert.ElementsMatch(t, document.Ingredients, f.pantry(t))
+	})
+
+	t.Run("it should delete what the document does not hold when replacing, except others' recipes", func(t *testing.T) {
+		f := newFixture(t)
+		onlyBeans := backup.Document{Version: backup.Version, Ingredients: []ingredient.Ingredient{beans}, Recipes: []recipe.Recipe{riceAndBeans}}
+
+		report, err := f.service.Import(asAlice, onlyBeans, backup.ImportOptions{Mode: backup.ModeReplace})
+
+		require.NoError(t, err)
+		assert.Equal(t, backup.Counts{Added: 1, Deleted: 1}, report.Ingredients)
+		assert.Equal(t, backup.Counts{Added: 1, Deleted: 1}, report.Recipes)
+		assert.Equal(t, []ingredient.Ingredient{beans}, f.pantry(t))
+		assert.ElementsMatch(t, []string{"Rice and beans", "Bob's beans"}, f.recipeNames(t))
+	})
+
+	t.Run("it should report a dry run without keeping anything", func(t *testing.T) {
+		f := newFixture(t)
+
+		report, err := f.service.Import(asAlice, document, backup.ImportOptions{Mode: backup.ModeReplace, DryRun: true})
+
+		require.NoError(t, err)
+		assert.True(t, report.DryRun)
+		assert.Equal(t, backup.Counts{Added: 1, Updated: 1}, report.Ingredients)
+		assert.Equal(t, backup.Counts{Added: 1, Deleted: 1}, report.Recipes)
+		assert.Equal(t, []ingredient.Ingredient{rice}, f.pantry(t))
+		assert.ElementsMatch(t, []string{"Plain rice", "Bob's beans"}, f.recipeNames(t))
+	})
+
+	t.Run("it should report every invalid item and store none", func(t *testing.T) {
+		f := newFixture(t)
+		invalid := backup.Document{
+			Version:     backup.Version,
+			Ingredients: []ingredient.Ingredient{beans, beans},
+			Recipes:     []recipe.Recipe{{Name: "Soup", Ingredients: []ingredient.Ingredient{{MeasureType: "g", Quantity: 1}}}},
+		}
+
+		_, err := f.service.Import(asAlice, invalid, backup.ImportOptions{})
+
+		var validation *domain.ValidationError
+		require.ErrorAs(t, err, &validation)
+		fields := make([]string, len(validation.Fields))
+		for i, field := range validation.Fields {
+			fields[i] = field.Field
+		}
+		assert.Equal(t, []string{"ingredients[1].name", "recipes[0].ingredients[0].name"}, fields)
+		assert.Equal(t, []ingredient.Ingredient{rice}, f.pantry(t))
+	})
+
+	t.Run("it should reject an unsupported version", func(t *testing.T) {
+		f := newFixture(t)
+
+		_, err := f.service.Import(asAlice, backup.Document{Version: 2}, backup.ImportOptions{})
+
+		var validation *domain.ValidationError
+		require.ErrorAs(t, err, &validation)
+		assert.Equal(t, "version", validation.Fields[0].Field)
+	})
+
+	t.Run("it should reject an unknown mode", func(t *testing.T) {
+		f := newFixture(t)
+
+		_, err := f.service.Import(asAlice, document, backup.ImportOptions{Mode: "overwrite"})
+
+		var validation *domain.ValidationError
+		require.ErrorAs(t, err, &validation)
+		assert.Equal(t, "mode", validation.Fields[0].Field)
+	})
+
+	t.Run("it should not let viewers import", func(t *testing.T) {
+		f := newFixture(t)
+
+		_, err := f.service.Import(asCarol, document, backup.ImportOptions{})
+
+		var forbidden *domain.ForbiddenError
+		assert.ErrorAs(t, err, &forbidden)
+	})
+
+	t.Run("it should undo the whole import when a part of it fails", func(t *testing.T) {
+		ism := in_memory_repository.NewIngredientStorageManager()
+		rm := in_memory_repository.NewRecipeManager([]recipe.Recipe{})
+		require.NoError(t, ism.AddIngredient(asAlice, rice))
+		service := backupService.NewBackupService(ism, failingRecipeManager{rm}, in_memory_repository.NewTransactor(), discardLogger)
+
+		_, err := service.Import(asAlice, document, backup.ImportOptions{})
+
+		assert.ErrorIs(t, err, errStorage)
+		pantry, err := ism.FindIngredients(asAlice)
+		require.NoError(t, err)
+		require.Len(t, pantry, 1)
+		assert.Equal(t, 500, pantry[0].Quantity)
+	})
+}
+
+var errStorage = errors.New("storage is down")
+
+// failingRecipeManager fails to store recipes, after the pantry has been
+// imported.
+type failingRecipeManager struct {
+	recipe.RecipeManager
+}
+
+func (failingRecipeManager) UpsertRecipe(ctx context.Context, r recipe.Recipe) (bool, error) {
+	return false, errStorage
+}
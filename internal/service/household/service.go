@@ -0,0 +1,199 @@
+package household
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/household"
+	"q-q-tem-pra-hoje/internal/domain/user"
+	"q-q-tem-pra-hoje/internal/tracing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var errInvalidInvitation = domain.NewNotFound("invitation", "code")
+
+type HouseholdService struct {
+	households    household.HouseholdManager
+	invitationTTL time.Duration
+	logger        *slog.Logger
+}
+
+// NewHouseholdService issues invitation codes that stay valid for
+// invitationTTL.
+func NewHouseholdService(hm household.HouseholdManager, invitationTTL time.Duration, logger *slog.Logger) *HouseholdService {
+	return &HouseholdService{households: hm, invitationTTL: invitationTTL, logger: logger}
+}
+
+// Resolve returns the membership a request of userID acts with. A user who
+// belongs to no household gets a personal one, so there is always a pantry.
+func (hs *HouseholdService) Resolve(ctx context.Context, userID int, householdID *int) (m household.Membership, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "HouseholdService.Resolve")
+	defer func() {
+		span.SetAttributes(attribute.Int("household.id", m.Household.Id))
+		tracing.End(span, err)
+	}()
+
+	if householdID != nil {
+		return hs.households.FindMembership(ctx, *householdID, userID)
+	}
+
+	m, added, err := hs.households.EnsureMembership(ctx, userID, household.PersonalName)
+	if err != nil {
+		return household.Membership{}, err
+	}
+	if added {
+		hs.logger.InfoContext(ctx, "personal household created", "user_id", userID, "household_id", m.Household.Id)
+	}
+	return m, nil
+}
+
+func (hs *HouseholdService) Create(ctx context.Context, h household.Household) (m household.Membership, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "HouseholdService.Create")
+	defer func() { tracing.End(span, err) }()
+
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return household.Membership{}, err
+	}
+	h = h.Normalize()
+	if err := h.Validate(); err != nil {
+		return household.Membership{}, err
+	}
+
+	created, err := hs.households.AddHousehold(ctx, h, userID)
+	if err != nil {
+		return household.Membership{}, err
+	}
+	hs.logger.InfoContext(ctx, "household created", "user_id", userID, "household_id", created.Id)
+	return household.Membership{Household: created, UserId: userID, Role: household.RoleOwner}, nil
+}
+
+func (hs *HouseholdService) FindMemberships(ctx context.Context) (memberships []household.Membership, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "HouseholdService.FindMemberships")
+	defer func() { tracing.End(span, err) }()
+
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return hs.households.FindMemberships(ctx, userID)
+}
+
+// FindMembers lists the members of a household the current user belongs to.
+// Other households are reported as not found.
+func (hs *HouseholdService) FindMembers(ctx context.Context, householdID int) (members []household.Member, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "HouseholdService.FindMembers")
+	defer func() { tracing.End(span, err) }()
+
+	if _, err := hs.membership(ctx, householdID); err != nil {
+		return nil, err
+	}
+	return hs.households.FindMembers(ctx, householdID)
+}
+
+// Invite returns a one-time code that lets its holder join the household as
+// role. Only owners may invite.
+func (hs *HouseholdService) Invite(ctx context.Context, householdID int, role household.Role) (code string, expiresAt time.Time, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "HouseholdService.Invite")
+	defer func() { tracing.End(span, err) }()
+
+	m, err := hs.membership(ctx, householdID)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if m.Role != household.RoleOwner {
+		return "", time.Time{}, domain.NewForbidden("only owners can invite to the household")
+	}
+	if !role.Valid() {
+		return "", time.Time{}, domain.NewValidation(domain.FieldError{Field: "role", Message: "role must be one of owner, member, viewer"})
+	}
+
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", time.Time{}, err
+	}
+	code = base64.RawURLEncoding.EncodeToString(raw)
+	expiresAt = time.Now().Add(hs.invitationTTL)
+
+	invitation := household.Invitation{CodeHash: hashCode(code), HouseholdId: householdID, Role: role, ExpiresAt: expiresAt}
+	if err := hs.households.AddInvitation(ctx, invitation); err != nil {
+		return "", time.Time{}, err
+	}
+	hs.logger.InfoContext(ctx, "household invitation created", "household_id", householdID, "role", role)
+	return code, expiresAt, nil
+}
+
+// Join consumes an invitation code and adds the current user to its
+// household. Unknown, used and expired codes are all reported alike. A code
+// that fails to add the user stays valid.
+func (hs *HouseholdService) Join(ctx context.Context, code string) (m household.Membership, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "HouseholdService.Join")
+	defer func() { tracing.End(span, err) }()
+
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return household.Membership{}, err
+	}
+
+	invitation, err := hs.households.AcceptInvitation(ctx, hashCode(code), userID, time.Now())
+	var notFound *domain.NotFoundError
+	if errors.As(err, &notFound) {
+		return household.Membership{}, errInvalidInvitation
+	}
+	if err != nil {
+		return household.Membership{}, err
+	}
+	hs.logger.InfoContext(ctx, "household joined", "user_id", userID, "household_id", invitation.HouseholdId, "role", invitation.Role)
+	return hs.households.FindMembership(ctx, invitation.HouseholdId, userID)
+}
+
+// RemoveMember lets owners remove anyone and members leave. The last owner
+// cannot go, so a household is never left unmanaged.
+func (hs *HouseholdService) RemoveMember(ctx context.Context, householdID int, userID int) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "HouseholdService.RemoveMember")
+	defer func() { tracing.End(span, err) }()
+
+	m, err := hs.membership(ctx, householdID)
+	if err != nil {
+		return err
+	}
+	if m.UserId != userID && m.Role != household.RoleOwner {
+		return domain.NewForbidden("only owners can remove other members")
+	}
+
+	if err := hs.households.RemoveMember(ctx, householdID, userID); err != nil {
+		return err
+	}
+	hs.logger.InfoContext(ctx, "household member removed", "household_id", householdID, "user_id", userID)
+	return nil
+}
+
+// membership returns the membership of the current user in householdID.
+func (hs *HouseholdService) membership(ctx context.Context, householdID int) (household.Membership, error) {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return household.Membership{}, err
+	}
+	return hs.households.FindMembership(ctx, householdID, userID)
+}
+
+func currentUser(ctx context.Context) (int, error) {
+	id, ok := user.UserIDFromContext(ctx)
+	if !ok {
+		return 0, domain.NewUnauthenticated("authentication required")
+	}
+	return id, nil
+}
+
+// hashCode is what invitations are stored under, like session tokens.
+func hashCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
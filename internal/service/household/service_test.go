@@ -0,0 +1,154 @@
+package household_test
+
+import (
+	"context"
+	"log/slog"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/household"
+	"q-q-tem-pra-hoje/internal/domain/user"
+	"q-q-tem-pra-hoje/internal/repository/in_memory_repository"
+	householdService "q-q-tem-pra-hoje/internal/service/household"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// newService returns a service over two registered users, alice (1) and bob (2).
+func newService(t *testing.T, invitationTTL time.Duration) *householdService.HouseholdService {
+	t.Helper()
+	users := in_memory_repository.NewUserManager()
+	for _, email := range []string{"alice@example.com", "bob@example.com"} {
+		_, err := users.AddUser(context.Background(), user.User{Email: email})
+		require.NoError(t, err)
+	}
+	return householdService.NewHouseholdService(in_memory_repository.NewHouseholdManager(users), invitationTTL, discardLogger)
+}
+
+var (
+	asAlice = user.WithUserID(context.Background(), 1)
+	asBob   = user.WithUserID(context.Background(), 2)
+)
+
+func TestHouseholdService_Resolve(t *testing.T) {
+	t.Run("it should create a personal household once", func(t *testing.T) {
+		service := newService(t, time.Hour)
+
+		first, err := service.Resolve(context.Background(), 1, nil)
+		require.NoError(t, err)
+		second, err := service.Resolve(context.Background(), 1, nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, household.PersonalName, first.Household.Name)
+		assert.Equal(t, household.RoleOwner, first.Role)
+		assert.Equal(t, first, second)
+	})
+
+	t.Run("it should not resolve a household of someone else", func(t *testing.T) {
+		service := newService(t, time.Hour)
+		alice, err := service.Resolve(context.Background(), 1, nil)
+		require.NoError(t, err)
+
+		_, err = service.Resolve(context.Background(), 2, &alice.Household.Id)
+
+		var notFound *domain.NotFoundError
+		assert.ErrorAs(t, err, &notFound)
+	})
+}
+
+func TestHouseholdService_Create(t *testing.T) {
+	t.Run("it should validate the name", func(t *testing.T) {
+		_, err := newService(t, time.Hour).Create(asAlice, household.Household{Name: "  "})
+
+		var validation *domain.ValidationError
+		assert.ErrorAs(t, err, &validation)
+	})
+
+	t.Run("it should require a user", func(t *testing.T) {
+		_, err := newService(t, time.Hour).Create(context.Background(), household.Household{Name: "Kitchen"})
+
+		var unauthenticated *domain.UnauthenticatedError
+		assert.ErrorAs(t, err, &unauthenticated)
+	})
+}
+
+func TestHouseholdService_Invite(t *testing.T) {
+	t.Run("it should let the invited user join with the role", func(t *testing.T) {
+		service := newService(t, time.Hour)
+		kitchen, err := service.Create(asAlice, household.Household{Name: "Kitchen"})
+		require.NoError(t, err)
+
+		code, expiresAt, err := service.Invite(asAlice, kitchen.Household.Id, household.RoleMember)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Minute)
+
+		joined, err := service.Join(asBob, code)
+		require.NoError(t, err)
+		assert.Equal(t, household.Membership{Household: kitchen.Household, UserId: 2, Role: household.RoleMember}, joined)
+	})
+
+	t.Run("it should reject an unknown role", func(t *testing.T) {
+		service := newService(t, time.Hour)
+		kitchen, err := service.Create(asAlice, household.Household{Name: "Kitchen"})
+		require.NoError(t, err)
+
+		_, _, err = service.Invite(asAlice, kitchen.Household.Id, "chef")
+
+		var validation *domain.ValidationError
+		assert.ErrorAs(t, err, &validation)
+	})
+
+	t.Run("it should reject an expired code", func(t *testing.T) {
+		service := newService(t, -time.Minute)
+		kitchen, err := service.Create(asAlice, household.Household{Name: "Kitchen"})
+		require.NoError(t, err)
+		code, _, err := service.Invite(asAlice, kitchen.Household.Id, household.RoleMember)
+		require.NoError(t, err)
+
+		_, err = service.Join(asBob, code)
+
+		var notFound *domain.NotFoundError
+		assert.ErrorAs(t, err, &notFound)
+	})
+}
+
+func TestHouseholdService_RemoveMember(t *testing.T) {
+	setUp := func(t *testing.T, role household.Role) (*householdService.HouseholdService, int) {
+		service := newService(t, time.Hour)
+		kitchen, err := service.Create(asAlice, household.Household{Name: "Kitchen"})
+		require.NoError(t, err)
+		code, _, err := service.Invite(asAlice, kitchen.Household.Id, role)
+		require.NoError(t, err)
+		_, err = service.Join(asBob, code)
+		require.NoError(t, err)
+		return service, kitchen.Household.Id
+	}
+
+	t.Run("it should not let members remove others", func(t *testing.T) {
+		service, id := setUp(t, household.RoleMember)
+
+		err := service.RemoveMember(asBob, id, 1)
+
+		var forbidden *domain.ForbiddenError
+		assert.ErrorAs(t, err, &forbidden)
+	})
+
+	t.Run("it should let an owner leave when another owner stays", func(t *testing.T) {
+		service, id := setUp(t, household.RoleOwner)
+
+		assert.NoError(t, service.RemoveMember(asAlice, id, 1))
+	})
+
+	t.Run("it should let owners remove members", func(t *testing.T) {
+		service, id := setUp(t, household.RoleViewer)
+
+		require.NoError(t, service.RemoveMember(asAlice, id, 2))
+
+		members, err := service.FindMembers(asAlice, id)
+		require.NoError(t, err)
+		assert.Len(t, members, 1)
+	})
+}
@@ -1,28 +1,84 @@
 package ingredient
 
-import "q-q-tem-pra-hoje/internal/domain/ingredient"
+import (
+	"context"
+	"log/slog"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/household"
+	"q-q-tem-pra-hoje/internal/domain/ingredient"
+	"q-q-tem-pra-hoje/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
 
 type IngredientStorageService struct {
 	ingredientStorageManager ingredient.IngredientStorageManager
+	logger                   *slog.Logger
 }
 
 // Instance
-func NewService(ingredientStorageManager ingredient.IngredientStorageManager) *IngredientStorageService {
-	return &IngredientStorageService{ingredientStorageManager}
+func NewService(ingredientStorageManager ingredient.IngredientStorageManager, logger *slog.Logger) *IngredientStorageService {
+	return &IngredientStorageService{ingredientStorageManager, logger}
 }
 
-func (iss *IngredientStorageService) Add(ingredient ingredient.Ingredient) error {
-	return iss.ingredientStorageManager.AddIngredient(ingredient)
+func (iss *IngredientStorageService) Add(ctx context.Context, ingredient ingredient.Ingredient) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "IngredientStorageService.Add")
+	defer func() { tracing.End(span, err) }()
+
+	if err := ingredient.Validate(); err != nil {
+		return err
+	}
+	if err := household.RequireEditor(ctx); err != nil {
+		return err
+	}
+
+	iss.logger.DebugContext(ctx, "adding ingredient", "name", ingredient.Name, "quantity", ingredient.Quantity)
+	return iss.ingredientStorageManager.AddIngredient(ctx, ingredient)
 }
 
-func (iss *IngredientStorageService) FindIngredients() ([]ingredient.Ingredient, error) {
-	return iss.ingredientStorageManager.FindIngredients()
+func (iss *IngredientStorageService) FindIngredients(ctx context.Context) (ingredients []ingredient.Ingredient, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "IngredientStorageService.FindIngredients")
+	defer func() {
+		span.SetAttributes(attribute.Int("ingredients.count", len(ingredients)))
+		tracing.End(span, err)
+	}()
+
+	return iss.ingredientStorageManager.FindIngredients(ctx)
 }
 
-func (iss *IngredientStorageService) Update(ingredient ingredient.Ingredient) error {
-	return iss.ingredientStorageManager.Update(ingredient)
+func (iss *IngredientStorageService) ListIngredients(ctx context.Context, opts domain.ListOptions) (page domain.Page[ingredient.Ingredient], err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "IngredientStorageService.ListIngredients")
+	defer func() {
+		span.SetAttributes(attribute.Int("ingredients.count", len(page.Items)))
+		tracing.End(span, err)
+	}()
+
+	return iss.ingredientStorageManager.ListIngredients(ctx, opts)
 }
 
-func (iss *IngredientStorageService) Delete(id uint) error {
-	return iss.ingredientStorageManager.Delete(id)
+func (iss *IngredientStorageService) Update(ctx context.Context, ingredient ingredient.Ingredient) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "IngredientStorageService.Update")
+	defer func() { tracing.End(span, err) }()
+
+	if err := ingredient.Validate(); err != nil {
+		return err
+	}
+	if err := household.RequireEditor(ctx); err != nil {
+		return err
+	}
+
+	iss.logger.DebugContext(ctx, "updating ingredient", "name", ingredient.Name, "quantity", ingredient.Quantity)
+	return iss.ingredientStorageManager.Update(ctx, ingredient)
+}
+
+func (iss *IngredientStorageService) Delete(ctx context.Context, id uint) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "IngredientStorageService.Delete")
+	defer func() { tracing.End(span, err) }()
+
+	if err := household.RequireEditor(ctx); err != nil {
+		return err
+	}
+
+	iss.logger.DebugContext(ctx, "deleting ingredient", "id", id)
+	return iss.ingredientStorageManager.Delete(ctx, id)
 }
@@ -1,6 +1,8 @@
 package ingredient_test
 
 import (
+	"context"
+	"log/slog"
 	"q-q-tem-pra-hoje/internal/domain/ingredient"
 	"q-q-tem-pra-hoje/internal/repository/in_memory_repository"
 	ingredientService "q-q-tem-pra-hoje/internal/service/ingredient"
@@ -9,33 +11,38 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+var discardLogger = slog.New(slog.DiscardHandler)
+
 func TestIngredientService_Add(t *testing.T) {
 	t.Run("it should add ingredients to inventory", func(t *testing.T) {
 
 		repository := in_memory_repository.NewIngredientStorageManager()
-		ingredientService := ingredientService.NewService(&repository)
+		ingredientService := ingredientService.NewService(repository, discardLogger)
 
-		ingredient := ingredient.Ingredient{Name: "onion", Quantity: 10, MeasureType: "unit"}
-		ingredientService.Add(ingredient)
+		ingredientService.Add(context.Background(), ingredient.Ingredient{Name: "onion", Quantity: 10, MeasureType: "unit"})
 
-		assert.Contains(t, repository.Ingredients, ingredient, "Ingredient should be added to inventory")
+		id := 1
+		ingredients, err := repository.FindIngredients(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []ingredient.Ingredient{{Id: &id, Name: "onion", Quantity: 10, MeasureType: "unit"}}, ingredients, "Ingredient should be added to inventory")
 	})
 }
 
 func TestIngredientService_FindIngredients(t *testing.T) {
 	t.Run("it should find all ingredients in the storage and aggregate them", func(t *testing.T) {
 		repository := in_memory_repository.NewIngredientStorageManager()
-		ingredientService := ingredientService.NewService(&repository)
+		ingredientService := ingredientService.NewService(repository, discardLogger)
 
-		ingredientService.Add(ingredient.Ingredient{Name: "onion", Quantity: 10, MeasureType: "unit"})
-		ingredientService.Add(ingredient.Ingredient{Name: "garlic", Quantity: 2, MeasureType: "unit"})
-		ingredientService.Add(ingredient.Ingredient{Name: "onion", Quantity: 10, MeasureType: "unit"})
+		ingredientService.Add(context.Background(), ingredient.Ingredient{Name: "onion", Quantity: 10, MeasureType: "unit"})
+		ingredientService.Add(context.Background(), ingredient.Ingredient{Name: "garlic", Quantity: 2, MeasureType: "unit"})
+		ingredientService.Add(context.Background(), ingredient.Ingredient{Name: "onion", Quantity: 10, MeasureType: "unit"})
 
-		ingredients, err := ingredientService.FindIngredients()
+		ingredients, err := ingredientService.FindIngredients(context.Background())
 
+		onionID, garlicID := 1, 2
 		expectedIngredients := []ingredient.Ingredient{
-			{Name: "onion", Quantity: 20, MeasureType: "unit"},
-			{Name: "garlic", Quantity: 2, MeasureType: "unit"},
+			{Id: &onionID, Name: "onion", Quantity: 20, MeasureType: "unit"},
+			{Id: &garlicID, Name: "garlic", Quantity: 2, MeasureType: "unit"},
 		}
 
 		assert.NoError(t, err)
@@ -46,17 +53,19 @@ func TestIngredientService_FindIngredients(t *testing.T) {
 func TestIngredientService_Update(t *testing.T) {
 	t.Run("it should update an ingredient value", func(t *testing.T) {
 		repository := in_memory_repository.NewIngredientStorageManager()
-		ingredientService := ingredientService.NewService(&repository)
+		ingredientService := ingredientService.NewService(repository, discardLogger)
 
-		ingredientService.Add(ingredient.Ingredient{Name: "onion", Quantity: 10, MeasureType: "unit"})
+		ingredientService.Add(context.Background(), ingredient.Ingredient{Name: "onion", Quantity: 10, MeasureType: "unit"})
 
-		err := ingredientService.Update(ingredient.Ingredient{Name: "garlic", Quantity: 1, MeasureType: "unit"})
+		id := 1
+		err := ingredientService.Update(context.Background(), ingredient.Ingredient{Id: &id, Name: "garlic", Quantity: 1, MeasureType: "unit"})
 
 		expectedIngredients := []ingredient.Ingredient{
-			{Name: "garlic", Quantity: 1, MeasureType: "unit"},
+			{Id: &id, Name: "garlic", Quantity: 1, MeasureType: "unit"},
 		}
 
 		assert.NoError(t, err)
-		assert.Equal(t, expectedIngredients, repository.Ingredients)
+		ingredients, _ := repository.FindIngredients(context.Background())
+		assert.Equal(t, expectedIngredients, ingredients)
 	})
 }
@@ -1,29 +1,77 @@
 package recipe
 
 import (
+	"context"
+	"log/slog"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/household"
 	"q-q-tem-pra-hoje/internal/domain/recipe"
+	"q-q-tem-pra-hoje/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type RecipeService struct {
 	recipe.RecipeManager
+	logger *slog.Logger
 }
 
-func NewRecipeService(rm recipe.RecipeManager) *RecipeService {
-	return &RecipeService{RecipeManager: rm}
+func NewRecipeService(rm recipe.RecipeManager, logger *slog.Logger) *RecipeService {
+	return &RecipeService{RecipeManager: rm, logger: logger}
 }
 
-func (rs *RecipeService) Create(recipe recipe.Recipe) error {
-	return rs.AddRecipe(recipe)
+func (rs *RecipeService) Create(ctx context.Context, r recipe.Recipe) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "RecipeService.Create")
+	defer func() { tracing.End(span, err) }()
+
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	if r.Visibility == recipe.VisibilityHousehold {
+		if err := household.RequireEditor(ctx); err != nil {
+			return err
+		}
+	}
+
+	rs.logger.DebugContext(ctx, "creating recipe", "name", r.Name, "ingredients", len(r.Ingredients))
+	return rs.AddRecipe(ctx, r)
 }
 
-func (rs *RecipeService) FindRecipes() ([]recipe.Recipe, error) {
-	recipes, err := rs.GetAllRecipes()
+func (rs *RecipeService) FindRecipes(ctx context.Context) (recipes []recipe.Recipe, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "RecipeService.FindRecipes")
+	defer func() {
+		span.SetAttributes(attribute.Int("recipes.count", len(recipes)))
+		tracing.End(span, err)
+	}()
+
+	recipes, err = rs.GetAllRecipes(ctx)
 	if err != nil {
 		return nil, err
 	}
 	return recipes, nil
 }
 
-func (rs *RecipeService) Delete(id uint) error {
-	return rs.RecipeManager.DeleteRecipe(id)
+func (rs *RecipeService) ListRecipes(ctx context.Context, opts domain.ListOptions) (page domain.Page[recipe.Recipe], err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "RecipeService.ListRecipes")
+	defer func() {
+		span.SetAttributes(attribute.Int("recipes.count", len(page.Items)))
+		tracing.End(span, err)
+	}()
+
+	return rs.RecipeManager.ListRecipes(ctx, opts)
+}
+
+func (rs *RecipeService) FindRecipe(ctx context.Context, id uint) (found recipe.Recipe, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "RecipeService.FindRecipe")
+	defer func() { tracing.End(span, err) }()
+
+	return rs.GetRecipe(ctx, id)
+}
+
+func (rs *RecipeService) Delete(ctx context.Context, id uint) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "RecipeService.Delete")
+	defer func() { tracing.End(span, err) }()
+
+	rs.logger.DebugContext(ctx, "deleting recipe", "id", id)
+	return rs.RecipeManager.DeleteRecipe(ctx, id)
 }
@@ -1,6 +1,8 @@
 package recipe_test
 
 import (
+	"context"
+	"log/slog"
 	"q-q-tem-pra-hoje/internal/domain/ingredient"
 	"q-q-tem-pra-hoje/internal/domain/recipe"
 	"q-q-tem-pra-hoje/internal/repository/in_memory_repository"
@@ -10,6 +12,8 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+var discardLogger = slog.New(slog.DiscardHandler)
+
 func TestRecipeService_Add(t *testing.T) {
 	t.Run("it should add a valid recipe", func(t *testing.T) {
 
@@ -18,11 +22,14 @@ func TestRecipeService_Add(t *testing.T) {
 			{Name: "Rice", MeasureType: "mg", Quantity: 500},
 			{Name: "Garlic", MeasureType: "unit", Quantity: 2}})
 		inMemoryRecipeManager := in_memory_repository.NewRecipeManager([]recipe.Recipe{})
-		recipeService := recipeService.NewRecipeService(inMemoryRecipeManager)
+		recipeService := recipeService.NewRecipeService(inMemoryRecipeManager, discardLogger)
 
-		recipeService.AddRecipe(expectedRecipe)
+		recipeService.AddRecipe(context.Background(), expectedRecipe)
+		assert.NoError(t, err)
+		expectedRecipe.Visibility = recipe.VisibilityPrivate
+		found, err := inMemoryRecipeManager.GetRecipe(context.Background(), 1)
 		assert.NoError(t, err)
-		assert.Equal(t, expectedRecipe, inMemoryRecipeManager.Recipes[len(inMemoryRecipeManager.Recipes)-1])
+		assert.Equal(t, expectedRecipe, found)
 	})
 
 	t.Run("it should return an error for an invalid name", func(t *testing.T) {
@@ -32,9 +39,9 @@ func TestRecipeService_Add(t *testing.T) {
 			{Name: "Garlic", MeasureType: "unit", Quantity: 2},
 		})
 		manager := in_memory_repository.NewRecipeManager([]recipe.Recipe{})
-		service := recipeService.NewRecipeService(manager)
+		service := recipeService.NewRecipeService(manager, discardLogger)
 
-		service.Create(invalidRecipe)
+		service.Create(context.Background(), invalidRecipe)
 		assert.Error(t, err)
 		assert.Equal(t, "recipe name cannot be empty", err.Error())
 		assert.Equal(t, recipe.Recipe{}, invalidRecipe)
@@ -43,9 +50,9 @@ func TestRecipeService_Add(t *testing.T) {
 	t.Run("it should return an error for invalid ingredients", func(t *testing.T) {
 		invalidRecipe, err := recipe.NewRecipe(34, "Rice", []ingredient.Ingredient{}) // No ingredients
 		manager := in_memory_repository.NewRecipeManager([]recipe.Recipe{})
-		service := recipeService.NewRecipeService(manager)
+		service := recipeService.NewRecipeService(manager, discardLogger)
 
-		service.Create(invalidRecipe)
+		service.Create(context.Background(), invalidRecipe)
 		assert.Error(t, err)
 		assert.Equal(t, "recipe must have at least one ingredient", err.Error())
 		assert.Equal(t, recipe.Recipe{}, invalidRecipe)
@@ -76,9 +83,13 @@ func TestRecipesService_FindRecipes(t *testing.T) {
 			}},
 		}
 		repository := in_memory_repository.NewRecipeManager(expectedRecipes)
-		service := recipeService.NewRecipeService(repository)
+		service := recipeService.NewRecipeService(repository, discardLogger)
+		for i := range expectedRecipes {
+			id := i + 1
+			expectedRecipes[i].Id, expectedRecipes[i].Visibility = &id, recipe.VisibilityPublic
+		}
 
-		recipes, err := service.FindRecipes()
+		recipes, err := service.FindRecipes(context.Background())
 
 		assert.Empty(t, err)
 		assert.Equal(t, expectedRecipes, recipes)
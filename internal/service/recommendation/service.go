@@ -1,25 +1,39 @@
 package recommendation
 
 import (
+	"context"
+	"log/slog"
 	"q-q-tem-pra-hoje/internal/domain/ingredient"
 	"q-q-tem-pra-hoje/internal/domain/recipe"
 	"q-q-tem-pra-hoje/internal/domain/recommendation"
+	"q-q-tem-pra-hoje/internal/tracing"
 	"sort"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type RecommendationService struct {
 	recipe.RecipeManager
+	logger *slog.Logger
 }
 
-func NewRecommendationService(rm recipe.RecipeManager) *RecommendationService {
-	return &RecommendationService{RecipeManager: rm}
+func NewRecommendationService(rm recipe.RecipeManager, logger *slog.Logger) *RecommendationService {
+	return &RecommendationService{RecipeManager: rm, logger: logger}
 }
 
-func (rs *RecommendationService) GetRecommendations(ingredients *[]ingredient.Ingredient) ([]recommendation.Recommendation, error) {
-	recipes, err := rs.GetAllRecipes()
+func (rs *RecommendationService) GetRecommendations(ctx context.Context, ingredients *[]ingredient.Ingredient) (_ []recommendation.Recommendation, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "RecommendationService.GetRecommendations")
+	defer func() { tracing.End(span, err) }()
+
+	recipes, err := rs.GetAllRecipes(ctx)
 	if err != nil {
 		return nil, err
 	}
+
+	span.SetAttributes(attribute.Int("ingredients.count", len(*ingredients)), attribute.Int("recipes.count", len(recipes)))
+	_, scoringSpan := tracing.Tracer().Start(ctx, "RecommendationService.scoreRecipes")
+	defer scoringSpan.End()
+
 	availableIngredientMap := make(map[string]bool)
 
 	for _, ing := range *ingredients {
@@ -55,5 +69,7 @@ func (rs *RecommendationService) GetRecommendations(ingredients *[]ingredient.In
 		recommendations = append(recommendations, recommendation.Recommendation{Recommendation: i + 1, Recipe: scoredRecipe.recipe})
 	}
 
+	rs.logger.DebugContext(ctx, "recommendations computed", "ingredients", len(*ingredients), "recipes", len(recipes))
+
 	return recommendations, nil
 }
@@ -1,6 +1,8 @@
 package recommendation_test
 
 import (
+	"context"
+	"log/slog"
 	"q-q-tem-pra-hoje/internal/domain/ingredient"
 	"q-q-tem-pra-hoje/internal/domain/recipe"
 	"q-q-tem-pra-hoje/internal/domain/recommendation"
@@ -11,6 +13,8 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+var discardLogger = slog.New(slog.DiscardHandler)
+
 func TestRecommendationService_GetRecommendations(t *testing.T) {
 	t.Run("it should recommend recipes based on quantity of ingredients", func(t *testing.T) {
 		availableIngredients := []ingredient.Ingredient{
@@ -40,7 +44,11 @@ func TestRecommendationService_GetRecommendations(t *testing.T) {
 			}},
 		}
 		repository := in_memory_repository.NewRecipeManager(recipes)
-		service := service.NewRecommendationService(repository)
+		service := service.NewRecommendationService(repository, discardLogger)
+		for i := range recipes {
+			id := i + 1
+			recipes[i].Id, recipes[i].Visibility = &id, recipe.VisibilityPublic
+		}
 
 		expectedRecommendations := []recommendation.Recommendation{
 			{Recommendation: 1, Recipe: recipes[2]},
@@ -50,7 +58,7 @@ func TestRecommendationService_GetRecommendations(t *testing.T) {
 			{Recommendation: 5, Recipe: recipes[3]},
 		}
 
-		recommendations, err := service.GetRecommendations(&availableIngredients)
+		recommendations, err := service.GetRecommendations(context.Background(), &availableIngredients)
 
 		assert.Empty(t, err)
 		assert.Equal(t, expectedRecommendations, recommendations)
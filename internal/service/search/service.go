@@ -0,0 +1,47 @@
+package search
+
+import (
+	"context"
+	"log/slog"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/search"
+	"q-q-tem-pra-hoje/internal/tracing"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// DefaultLimit is the number of results returned when none is requested.
+const DefaultLimit = 20
+
+type SearchService struct {
+	search.SearchManager
+	logger *slog.Logger
+}
+
+func NewSearchService(sm search.SearchManager, logger *slog.Logger) *SearchService {
+	return &SearchService{SearchManager: sm, logger: logger}
+}
+
+func (ss *SearchService) Search(ctx context.Context, query string, limit int) (results []search.Result, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "SearchService.Search")
+	defer func() {
+		span.SetAttributes(attribute.Int("results.count", len(results)))
+		tracing.End(span, err)
+	}()
+
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, domain.NewValidation(domain.FieldError{Field: "q", Message: "search query cannot be empty"})
+	}
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+
+	results, err = ss.SearchManager.Search(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	ss.logger.DebugContext(ctx, "search completed", "query", query, "results", len(results))
+	return results, nil
+}
@@ -0,0 +1,76 @@
+package search_test
+
+import (
+	"context"
+	"log/slog"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/ingredient"
+	"q-q-tem-pra-hoje/internal/domain/recipe"
+	"q-q-tem-pra-hoje/internal/domain/search"
+	"q-q-tem-pra-hoje/internal/repository/in_memory_repository"
+	searchService "q-q-tem-pra-hoje/internal/service/search"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+func newService() *searchService.SearchService {
+	ism := in_memory_repository.NewIngredientStorageManager()
+	ism.AddIngredient(context.Background(), ingredient.Ingredient{Name: "Berinjela", MeasureType: "unit", Quantity: 2})
+	ism.AddIngredient(context.Background(), ingredient.Ingredient{Name: "Abobrinha", MeasureType: "unit", Quantity: 1})
+
+	id1, id2, id3 := 1, 2, 3
+	rm := in_memory_repository.NewRecipeManager([]recipe.Recipe{
+		{Id: &id1, Name: "Berinjela à parmegiana"},
+		{Id: &id2, Name: "Torta de abóbrinha"},
+		{Id: &id3, Name: "Feijoada"},
+	})
+	return searchService.NewSearchService(in_memory_repository.NewSearchManager(ism, rm), discardLogger)
+}
+
+func TestSearchService_Search(t *testing.T) {
+	t.Run("it should find recipes and ingredients ignoring case and accents", func(t *testing.T) {
+		results, err := newService().Search(context.Background(), "ABOBRINHA", 0)
+
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{"Torta de abóbrinha", "Abobrinha"}, resultNames(results))
+	})
+
+	t.Run("it should tolerate typos but rank exact matches first", func(t *testing.T) {
+		results, err := newService().Search(context.Background(), "berinjla", 0)
+
+		assert.NoError(t, err)
+		assert.Len(t, results, 2)
+
+		results, err = newService().Search(context.Background(), "berinjela parmegiana", 0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Berinjela à parmegiana"}, resultNames(results))
+		assert.Equal(t, search.KindRecipe, results[0].Kind)
+		assert.Equal(t, 1, results[0].Id)
+	})
+
+	t.Run("it should honour the limit", func(t *testing.T) {
+		results, err := newService().Search(context.Background(), "berinjela", 1)
+
+		assert.NoError(t, err)
+		assert.Len(t, results, 1)
+	})
+
+	t.Run("it should reject an empty query", func(t *testing.T) {
+		_, err := newService().Search(context.Background(), "  ", 0)
+
+		var validation *domain.ValidationError
+		assert.ErrorAs(t, err, &validation)
+	})
+}
+
+func resultNames(results []search.Result) []string {
+	names := make([]string, len(results))
+	for i, r := range results {
+		names[i] = r.Name
+	}
+	return names
+}
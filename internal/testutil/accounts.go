@@ -0,0 +1,468 @@
+package testutil
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/apikey"
+	"q-q-tem-pra-hoje/internal/domain/household"
+	"q-q-tem-pra-hoje/internal/domain/ingredient"
+	"q-q-tem-pra-hoje/internal/domain/recipe"
+	"q-q-tem-pra-hoje/internal/domain/search"
+	"q-q-tem-pra-hoje/internal/domain/user"
+	apiKeyService "q-q-tem-pra-hoje/internal/service/apikey"
+	authService "q-q-tem-pra-hoje/internal/service/auth"
+	householdService "q-q-tem-pra-hoje/internal/service/household"
+	ingredientService "q-q-tem-pra-hoje/internal/service/ingredient"
+	recipeService "q-q-tem-pra-hoje/internal/service/recipe"
+	searchService "q-q-tem-pra-hoje/internal/service/search"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// RunUserContract asserts that accounts and sessions work through the
+// services over a backend, and that what one user stores stays theirs.
+// newBackend is called once per group of subtests, which build on each
+// other, and must return empty repositories.
+func RunUserContract(t *testing.T, newBackend func(t *testing.T) Backend) {
+	t.Run("sessions", func(t *testing.T) {
+		backend := newBackend(t)
+		service := authService.NewAuthService(backend.Users, backend.Sessions, time.Hour, discardLogger)
+		credentials := user.Credentials{Email: "cook@example.com", Password: "correct horse"}
+
+		t.Run("should register, log in and log out", func(t *testing.T) {
+			registered, err := service.Register(context.Background(), credentials)
+			require.NoError(t, err)
+
+			token, _, err := service.Login(context.Background(), user.Credentials{Email: "COOK@example.com", Password: credentials.Password})
+			require.NoError(t, err)
+
+			userID, err := service.Authenticate(context.Background(), token)
+			require.NoError(t, err)
+			assert.Equal(t, registered.Id, userID)
+
+			require.NoError(t, service.Logout(context.Background(), token))
+			_, err = service.Authenticate(context.Background(), token)
+			var unauthenticated *domain.UnauthenticatedError
+			assert.ErrorAs(t, err, &unauthenticated)
+		})
+
+		t.Run("should reject an email registered with another case", func(t *testing.T) {
+			_, err := backend.Users.AddUser(context.Background(), user.User{Email: "Cook@Example.com", PasswordHash: "x"})
+
+			var conflict *domain.ConflictError
+			assert.ErrorAs(t, err, &conflict)
+		})
+	})
+
+	t.Run("ownership", func(t *testing.T) {
+		backend := newBackend(t)
+		accounts := addUsers(t, backend.Users, "alice@example.com", "bob@example.com")
+		households := householdService.NewHouseholdService(backend.Households, time.Hour, discardLogger)
+		asAlice := actAs(t, households, accounts[0].Id, nil)
+		asBob := actAs(t, households, accounts[1].Id, nil)
+
+		ingredients := ingredientService.NewService(backend.Ingredients, discardLogger)
+		recipes := recipeService.NewRecipeService(backend.Recipes, discardLogger)
+
+		rice := ingredient.Ingredient{Name: "Rice", MeasureType: "g", Quantity: 500}
+		require.NoError(t, ingredients.Add(asAlice, rice))
+		require.NoError(t, ingredients.Add(asBob, rice))
+		require.NoError(t, ingredients.Add(asBob, rice))
+
+		plainRice := recipe.Recipe{Name: "Plain rice", Ingredients: []ingredient.Ingredient{rice}}
+		require.NoError(t, recipes.Create(asAlice, plainRice))
+
+		t.Run("should keep each user's pantry apart", func(t *testing.T) {
+			alicePantry, err := ingredients.FindIngredients(asAlice)
+			require.NoError(t, err)
+			bobPantry, err := ingredients.FindIngredients(asBob)
+			require.NoError(t, err)
+
+			assert.Len(t, alicePantry, 1)
+			assert.Equal(t, 500, alicePantry[0].Quantity)
+			assert.Len(t, bobPantry, 1)
+			assert.Equal(t, 1000, bobPantry[0].Quantity)
+		})
+
+		t.Run("should hide other users' recipes", func(t *testing.T) {
+			bobRecipes, err := recipes.FindRecipes(asBob)
+			require.NoError(t, err)
+			assert.Empty(t, bobRecipes)
+
+			aliceRecipes, err := recipes.FindRecipes(asAlice)
+			require.NoError(t, err)
+			require.Len(t, aliceRecipes, 1)
+
+			assertNotFound(t, recipes.Delete(asBob, uint(*aliceRecipes[0].Id)))
+		})
+
+		t.Run("should let two users use the same recipe name", func(t *testing.T) {
+			assert.NoError(t, recipes.Create(asBob, plainRice))
+		})
+	})
+}
+
+// RunHouseholdContract asserts that households, their members and
+// invitations work through the services over a backend, and that pantries,
+// recipes and search results stay within the households they belong to.
+// newBackend is called once per group of subtests, which build on each
+// other, and must return empty repositories.
+func RunHouseholdContract(t *testing.T, newBackend func(t *testing.T) Backend) {
+	t.Run("membership", func(t *testing.T) {
+		backend := newBackend(t)
+		households := householdService.NewHouseholdService(backend.Households, time.Hour, discardLogger)
+		accounts := addUsers(t, backend.Users, "alice@example.com", "bob@example.com", "carol@example.com")
+		alice, bob, carol := accounts[0], accounts[1], accounts[2]
+		asAlice := user.WithUserID(context.Background(), alice.Id)
+		asBob := user.WithUserID(context.Background(), bob.Id)
+		asCarol := user.WithUserID(context.Background(), carol.Id)
+
+		kitchen, err := households.Create(asAlice, household.Household{Name: " Kitchen "})
+		require.NoError(t, err)
+		kitchenID := kitchen.Household.Id
+
+		t.Run("should make the creator its owner", func(t *testing.T) {
+			assert.Equal(t, "Kitchen", kitchen.Household.Name)
+			assert.Equal(t, household.RoleOwner, kitchen.Role)
+
+			memberships, err := households.FindMemberships(asAlice)
+			require.NoError(t, err)
+			assert.Equal(t, []household.Membership{kitchen}, memberships)
+		})
+
+		t.Run("should hide a household from non members", func(t *testing.T) {
+			_, err := households.FindMembers(asBob, kitchenID)
+
+			assertNotFound(t, err)
+		})
+
+		t.Run("should let an invitation code be used once", func(t *testing.T) {
+			code, _, err := households.Invite(asAlice, kitchenID, household.RoleViewer)
+			require.NoError(t, err)
+
+			joined, err := households.Join(asBob, code)
+			require.NoError(t, err)
+			assert.Equal(t, household.RoleViewer, joined.Role)
+
+			_, err = households.Join(asBob, code)
+			assertNotFound(t, err)
+
+			members, err := households.FindMembers(asBob, kitchenID)
+			require.NoError(t, err)
+			assert.Equal(t, []household.Member{
+				{UserId: alice.Id, Email: "alice@example.com", Role: household.RoleOwner},
+				{UserId: bob.Id, Email: "bob@example.com", Role: household.RoleViewer},
+			}, members)
+		})
+
+		t.Run("should keep a code that fails to add its holder", func(t *testing.T) {
+			code, _, err := households.Invite(asAlice, kitchenID, household.RoleMember)
+			require.NoError(t, err)
+
+			_, err = households.Join(asAlice, code)
+			var conflict *domain.ConflictError
+			require.ErrorAs(t, err, &conflict)
+
+			joined, err := households.Join(asCarol, code)
+			require.NoError(t, err)
+			assert.Equal(t, household.RoleMember, joined.Role)
+		})
+
+		t.Run("should only let owners invite", func(t *testing.T) {
+			_, _, err := households.Invite(asBob, kitchenID, household.RoleOwner)
+
+			var forbidden *domain.ForbiddenError
+			assert.ErrorAs(t, err, &forbidden)
+		})
+
+		t.Run("should keep the last owner", func(t *testing.T) {
+			err := households.RemoveMember(asAlice, kitchenID, alice.Id)
+
+			var conflict *domain.ConflictError
+			assert.ErrorAs(t, err, &conflict)
+		})
+
+		t.Run("should let a member leave", func(t *testing.T) {
+			require.NoError(t, households.RemoveMember(asBob, kitchenID, bob.Id))
+
+			_, err := households.FindMembers(asBob, kitchenID)
+			assertNotFound(t, err)
+		})
+	})
+
+	t.Run("races", func(t *testing.T) {
+		backend := newBackend(t)
+		households := householdService.NewHouseholdService(backend.Households, time.Hour, discardLogger)
+		accounts := addUsers(t, backend.Users, "alice@example.com", "bob@example.com")
+		alice, bob := accounts[0], accounts[1]
+
+		t.Run("should add one personal household however many first requests race", func(t *testing.T) {
+			found := make([]household.Membership, 8)
+			errs := make([]error, len(found))
+			var wg sync.WaitGroup
+			for i := range found {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					found[i], errs[i] = households.Resolve(context.Background(), alice.Id, nil)
+				}()
+			}
+			wg.Wait()
+
+			for i := range found {
+				require.NoError(t, errs[i])
+				assert.Equal(t, found[0], found[i])
+			}
+			memberships, err := households.FindMemberships(user.WithUserID(context.Background(), alice.Id))
+			require.NoError(t, err)
+			assert.Len(t, memberships, 1)
+		})
+
+		t.Run("should keep an owner when every owner leaves at once", func(t *testing.T) {
+			asAlice := actAs(t, households, alice.Id, nil)
+			asBob := user.WithUserID(context.Background(), bob.Id)
+			m, _ := household.MembershipFromContext(asAlice)
+			code, _, err := households.Invite(asAlice, m.Household.Id, household.RoleOwner)
+			require.NoError(t, err)
+			_, err = households.Join(asBob, code)
+			require.NoError(t, err)
+
+			errs := make([]error, 2)
+			var wg sync.WaitGroup
+			for i, leave := range []struct {
+				ctx    context.Context
+				userID int
+			}{{asAlice, alice.Id}, {asBob, bob.Id}} {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					errs[i] = households.RemoveMember(leave.ctx, m.Household.Id, leave.userID)
+				}()
+			}
+			wg.Wait()
+
+			var conflict *domain.ConflictError
+			assert.True(t, errs[0] == nil || errs[1] == nil, "one owner should leave")
+			assert.True(t, errors.As(errs[0], &conflict) || errors.As(errs[1], &conflict), "one owner should stay")
+			members, err := backend.Households.FindMembers(context.Background(), m.Household.Id)
+			require.NoError(t, err)
+			require.Len(t, members, 1)
+			assert.Equal(t, household.RoleOwner, members[0].Role)
+		})
+	})
+
+	t.Run("isolation", func(t *testing.T) {
+		backend := newBackend(t)
+		households := householdService.NewHouseholdService(backend.Households, time.Hour, discardLogger)
+		accounts := addUsers(t, backend.Users, "alice@example.com", "bob@example.com", "carol@example.com", "dave@example.com")
+		alice, bob, carol, dave := accounts[0], accounts[1], accounts[2], accounts[3]
+
+		asAlice := actAs(t, households, alice.Id, nil)
+		asBob := actAs(t, households, bob.Id, nil)
+		aliceMembership, _ := household.MembershipFromContext(asAlice)
+		aliceHousehold := aliceMembership.Household.Id
+
+		join := func(u user.User, role household.Role) context.Context {
+			code, _, err := households.Invite(asAlice, aliceHousehold, role)
+			require.NoError(t, err)
+			_, err = households.Join(user.WithUserID(context.Background(), u.Id), code)
+			require.NoError(t, err)
+			return actAs(t, households, u.Id, &aliceHousehold)
+		}
+		// Carol's personal household comes first, so it stays her default.
+		carolAtHome := actAs(t, households, carol.Id, nil)
+		carolAtAlice := join(carol, household.RoleMember)
+		daveAtAlice := join(dave, household.RoleViewer)
+
+		ingredients := ingredientService.NewService(backend.Ingredients, discardLogger)
+		recipes := recipeService.NewRecipeService(backend.Recipes, discardLogger)
+		searches := searchService.NewSearchService(backend.Search, discardLogger)
+
+		rice := ingredient.Ingredient{Name: "Rice", MeasureType: "g", Quantity: 500}
+		require.NoError(t, ingredients.Add(asAlice, rice))
+		require.NoError(t, ingredients.Add(carolAtAlice, rice))
+		require.NoError(t, ingredients.Add(asBob, rice))
+
+		withRice := []ingredient.Ingredient{rice}
+		require.NoError(t, recipes.Create(asAlice, recipe.Recipe{Name: "Secret rice", Ingredients: withRice}))
+		require.NoError(t, recipes.Create(asAlice, recipe.Recipe{Name: "Family rice", Ingredients: withRice, Visibility: recipe.VisibilityHousehold}))
+		require.NoError(t, recipes.Create(asAlice, recipe.Recipe{Name: "Rice for all", Ingredients: withRice, Visibility: recipe.VisibilityPublic}))
+		require.NoError(t, recipes.Create(asBob, recipe.Recipe{Name: "Bob's rice", Ingredients: withRice, Visibility: recipe.VisibilityHousehold}))
+
+		visibleNames := func(ctx context.Context) []string {
+			found, err := recipes.FindRecipes(ctx)
+			require.NoError(t, err)
+			return recipeNames(found)
+		}
+
+		t.Run("should share the pantry within a household only", func(t *testing.T) {
+			shared, err := ingredients.FindIngredients(carolAtAlice)
+			require.NoError(t, err)
+			require.Len(t, shared, 1)
+			assert.Equal(t, 1000, shared[0].Quantity)
+
+			bobPantry, err := ingredients.FindIngredients(asBob)
+			require.NoError(t, err)
+			require.Len(t, bobPantry, 1)
+			assert.Equal(t, 500, bobPantry[0].Quantity)
+
+			carolPantry, err := ingredients.FindIngredients(carolAtHome)
+			require.NoError(t, err)
+			assert.Empty(t, carolPantry)
+
+			page, err := ingredients.ListIngredients(carolAtHome, domain.ListOptions{Search: "rice"})
+			require.NoError(t, err)
+			assert.Empty(t, page.Items)
+		})
+
+		t.Run("should not change another household's pantry", func(t *testing.T) {
+			shared, err := ingredients.FindIngredients(asAlice)
+			require.NoError(t, err)
+			id := *shared[0].Id
+
+			assertNotFound(t, ingredients.Update(asBob, ingredient.Ingredient{Id: &id, Name: "Rice", MeasureType: "g", Quantity: 1}))
+			assertNotFound(t, ingredients.Delete(asBob, uint(id)))
+		})
+
+		t.Run("should let viewers read but not change the pantry", func(t *testing.T) {
+			pantry, err := ingredients.FindIngredients(daveAtAlice)
+			require.NoError(t, err)
+			assert.Len(t, pantry, 1)
+
+			var forbidden *domain.ForbiddenError
+			assert.ErrorAs(t, ingredients.Add(daveAtAlice, rice), &forbidden)
+			assert.ErrorAs(t, ingredients.Delete(daveAtAlice, uint(*pantry[0].Id)), &forbidden)
+			assert.ErrorAs(t, recipes.Create(daveAtAlice, recipe.Recipe{Name: "Dave's rice", Ingredients: withRice, Visibility: recipe.VisibilityHousehold}), &forbidden)
+		})
+
+		t.Run("should show recipes by visibility", func(t *testing.T) {
+			assert.Equal(t, []string{"Secret rice", "Family rice", "Rice for all"}, visibleNames(asAlice))
+			assert.Equal(t, []string{"Family rice", "Rice for all"}, visibleNames(carolAtAlice))
+			assert.Equal(t, []string{"Rice for all"}, visibleNames(carolAtHome))
+			assert.Equal(t, []string{"Rice for all", "Bob's rice"}, visibleNames(asBob))
+
+			page, err := recipes.ListRecipes(carolAtHome, domain.ListOptions{Sort: domain.SortByName})
+			require.NoError(t, err)
+			require.Len(t, page.Items, 1)
+			assert.Equal(t, recipe.VisibilityPublic, page.Items[0].Visibility)
+		})
+
+		t.Run("should only let authors delete recipes", func(t *testing.T) {
+			shared, err := recipes.FindRecipes(carolAtAlice)
+			require.NoError(t, err)
+
+			for _, r := range shared {
+				assertNotFound(t, recipes.Delete(carolAtAlice, uint(*r.Id)))
+			}
+		})
+
+		t.Run("should only search what the user may see", func(t *testing.T) {
+			results, err := searches.Search(asBob, "rice", 0)
+			require.NoError(t, err)
+
+			var found []string
+			for _, r := range results {
+				found = append(found, string(r.Kind)+" "+r.Name)
+			}
+			assert.ElementsMatch(t, []string{
+				string(search.KindRecipe) + " Rice for all",
+				string(search.KindRecipe) + " Bob's rice",
+				string(search.KindIngredient) + " Rice",
+			}, found)
+		})
+	})
+}
+
+// RunAPIKeyContract asserts that API keys work through the service over a
+// backend. newBackend is called once, the subtests build on each other, and
+// it must return empty repositories.
+func RunAPIKeyContract(t *testing.T, newBackend func(t *testing.T) Backend) {
+	backend := newBackend(t)
+	households := householdService.NewHouseholdService(backend.Households, time.Hour, discardLogger)
+	keys := apiKeyService.NewAPIKeyService(backend.APIKeys, discardLogger)
+	accounts := addUsers(t, backend.Users, "alice@example.com", "bob@example.com")
+	asAlice := actAs(t, households, accounts[0].Id, nil)
+	asBob := actAs(t, households, accounts[1].Id, nil)
+
+	created, token, err := keys.Create(asAlice, apikey.Key{Name: "Scanner", Scopes: []apikey.Scope{apikey.ScopePantryRead, apikey.ScopePantryWrite}})
+	require.NoError(t, err)
+
+	t.Run("should keep the scopes and household of a key", func(t *testing.T) {
+		found, err := keys.Authenticate(context.Background(), token)
+
+		require.NoError(t, err)
+		assert.Equal(t, created.Id, found.Id)
+		assert.Equal(t, created.HouseholdId, found.HouseholdId)
+		assert.Equal(t, []apikey.Scope{apikey.ScopePantryRead, apikey.ScopePantryWrite}, found.Scopes)
+		require.NotNil(t, found.LastUsedAt)
+	})
+
+	t.Run("should record the last use", func(t *testing.T) {
+		listed, err := keys.FindKeys(asAlice)
+
+		require.NoError(t, err)
+		require.Len(t, listed, 1)
+		require.NotNil(t, listed[0].LastUsedAt)
+		assert.WithinDuration(t, time.Now(), *listed[0].LastUsedAt, time.Minute)
+	})
+
+	t.Run("should hide keys from other users", func(t *testing.T) {
+		listed, err := keys.FindKeys(asBob)
+		require.NoError(t, err)
+		assert.Empty(t, listed)
+
+		_, _, err = keys.Rotate(asBob, created.Id)
+		assertNotFound(t, err)
+		assertNotFound(t, keys.Revoke(asBob, created.Id))
+	})
+
+	t.Run("should only accept the rotated token", func(t *testing.T) {
+		rotated, newToken, err := keys.Rotate(asAlice, created.Id)
+		require.NoError(t, err)
+		assert.Equal(t, created.Name, rotated.Name)
+
+		_, err = keys.Authenticate(context.Background(), token)
+		assert.Error(t, err)
+		_, err = keys.Authenticate(context.Background(), newToken)
+		assert.NoError(t, err)
+		token = newToken
+	})
+
+	t.Run("should reject a revoked key", func(t *testing.T) {
+		require.NoError(t, keys.Revoke(asAlice, created.Id))
+
+		_, err := keys.Authenticate(context.Background(), token)
+
+		var unauthenticated *domain.UnauthenticatedError
+		assert.ErrorAs(t, err, &unauthenticated)
+	})
+}
+
+// actAs returns a context acting for userID within householdID, or within
+// their oldest household when it is nil, the way the HTTP layer does.
+func actAs(t *testing.T, households *householdService.HouseholdService, userID int, householdID *int) context.Context {
+	t.Helper()
+	ctx := user.WithUserID(context.Background(), userID)
+	membership, err := households.Resolve(ctx, userID, householdID)
+	require.NoError(t, err)
+	return household.WithMembership(ctx, membership)
+}
+
+func addUsers(t *testing.T, users user.UserManager, emails ...string) []user.User {
+	t.Helper()
+	added := make([]user.User, len(emails))
+	for i, email := range emails {
+		u, err := users.AddUser(context.Background(), user.User{Email: email, PasswordHash: "x"})
+		require.NoError(t, err)
+		added[i] = u
+	}
+	return added
+}
@@ -0,0 +1,79 @@
+package testutil
+
+import (
+	"context"
+	"errors"
+	"q-q-tem-pra-hoje/internal/domain/backup"
+	"q-q-tem-pra-hoje/internal/domain/ingredient"
+	"q-q-tem-pra-hoje/internal/domain/recipe"
+	backupService "q-q-tem-pra-hoje/internal/service/backup"
+	householdService "q-q-tem-pra-hoje/internal/service/household"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RunBackupContract asserts that exports import into another household of
+// the same backend, and that a failed import leaves nothing behind.
+// newBackend is called once, the subtests build on each other, and it must
+// return empty repositories.
+func RunBackupContract(t *testing.T, newBackend func(t *testing.T) Backend) {
+	backend := newBackend(t)
+	households := householdService.NewHouseholdService(backend.Households, time.Hour, discardLogger)
+	accounts := addUsers(t, backend.Users, "alice@example.com", "bob@example.com")
+	asAlice := actAs(t, households, accounts[0].Id, nil)
+	asBob := actAs(t, households, accounts[1].Id, nil)
+
+	service := backupService.NewBackupService(backend.Ingredients, backend.Recipes, backend.Transactor, discardLogger)
+
+	rice := ingredient.Ingredient{Name: "Rice", MeasureType: "g", Quantity: 500}
+	require.NoError(t, backend.Ingredients.AddIngredient(asAlice, rice))
+	require.NoError(t, backend.Recipes.AddRecipe(asAlice, recipe.Recipe{Name: "Plain rice", Ingredients: []ingredient.Ingredient{rice}, Visibility: recipe.VisibilityPublic}))
+	require.NoError(t, backend.Ingredients.AddIngredient(asBob, ingredient.Ingredient{Name: "Beans", MeasureType: "g", Quantity: 250}))
+
+	document, err := service.Export(asAlice)
+	require.NoError(t, err)
+
+	t.Run("should replace another household's pantry with the backup", func(t *testing.T) {
+		report, err := service.Import(asBob, document, backup.ImportOptions{Mode: backup.ModeReplace})
+
+		require.NoError(t, err)
+		assert.Equal(t, backup.Counts{Added: 1, Deleted: 1}, report.Ingredients)
+		// Alice's public recipe is not Bob's, so he gets a copy of his own.
+		assert.Equal(t, backup.Counts{Added: 1}, report.Recipes)
+
+		exported, err := service.Export(asBob)
+		require.NoError(t, err)
+		assert.Equal(t, document.Ingredients, exported.Ingredients)
+		assert.Equal(t, document.Recipes, exported.Recipes)
+	})
+
+	t.Run("should roll back the pantry when storing a recipe fails", func(t *testing.T) {
+		failing := backupService.NewBackupService(backend.Ingredients, failingRecipeManager{backend.Recipes}, backend.Transactor, discardLogger)
+		changed := document
+		changed.Ingredients = []ingredient.Ingredient{{Name: "Rice", MeasureType: "g", Quantity: 1000}}
+		changed.Recipes = []recipe.Recipe{{Name: "Soup", Ingredients: []ingredient.Ingredient{{Name: "Water", MeasureType: "ml", Quantity: 500}}}}
+
+		_, err := failing.Import(asBob, changed, backup.ImportOptions{})
+
+		assert.ErrorIs(t, err, errStorage)
+		pantry, err := backend.Ingredients.FindIngredients(asBob)
+		require.NoError(t, err)
+		require.Len(t, pantry, 1)
+		assert.Equal(t, 500, pantry[0].Quantity)
+	})
+}
+
+var errStorage = errors.New("storage is down")
+
+// failingRecipeManager fails to store recipes, after the pantry has been
+// imported.
+type failingRecipeManager struct {
+	recipe.RecipeManager
+}
+
+func (failingRecipeManager) UpsertRecipe(ctx context.Context, r recipe.Recipe) (bool, error) {
+	return false, errStorage
+}
@@ -0,0 +1,571 @@
+package testutil
+
+import (
+	"context"
+	"errors"
+	"q-q-tem-pra-hoje/internal/domain"
+	"q-q-tem-pra-hoje/internal/domain/apikey"
+	"q-q-tem-pra-hoje/internal/domain/household"
+	"q-q-tem-pra-hoje/internal/domain/ingredient"
+	"q-q-tem-pra-hoje/internal/domain/recipe"
+	"q-q-tem-pra-hoje/internal/domain/search"
+	"q-q-tem-pra-hoje/internal/domain/user"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Backend is a set of repositories to hold to the contract. The user and
+// household managers create the accounts that scoped contexts act for.
+// BreakRecipeIngredients makes every later insert of a recipe ingredient
+// fail; it is nil for backends whose writes cannot fail.
+type Backend struct {
+	Ingredients ingredient.IngredientStorageManager
+	Recipes     recipe.RecipeManager
+	Search      search.SearchManager
+	Users       user.UserManager
+	Sessions    user.SessionManager
+	Households  household.HouseholdManager
+	APIKeys     apikey.APIKeyManager
+	Transactor  domain.Transactor
+
+	BreakRecipeIngredients func(t *testing.T)
+}
+
+// RunIngredientStorageContract asserts the behavior every
+// IngredientStorageManager shares. newBackend is called for every subtest
+// and must return empty repositories.
+func RunIngredientStorageContract(t *testing.T, newBackend func(t *testing.T) Backend) {
+	ctx := context.Background()
+
+	t.Run("it should assign ids and find ingredients in the order they were added", func(t *testing.T) {
+		ism := newBackend(t).Ingredients
+		require.NoError(t, ism.AddIngredient(ctx, ingredient.Ingredient{Name: "onion", MeasureType: "unit", Quantity: 2}))
+		require.NoError(t, ism.AddIngredient(ctx, ingredient.Ingredient{Name: "garlic", MeasureType: "unit", Quantity: 3}))
+
+		found, err := ism.FindIngredients(ctx)
+
+		require.NoError(t, err)
+		require.Len(t, found, 2)
+		assert.Equal(t, []string{"onion", "garlic"}, ingredientNames(found))
+		require.NotNil(t, found[0].Id)
+		require.NotNil(t, found[1].Id)
+		assert.Less(t, *found[0].Id, *found[1].Id)
+		assert.Equal(t, "unit", found[1].MeasureType)
+		assert.Equal(t, 3, found[1].Quantity)
+	})
+
+	t.Run("it should merge an ingredient into the one of the same name when it is added", func(t *testing.T) {
+		ism := newBackend(t).Ingredients
+		require.NoError(t, ism.AddIngredient(ctx, ingredient.Ingredient{Name: "onion", MeasureType: "unit", Quantity: 2}))
+		require.NoError(t, ism.AddIngredient(ctx, ingredient.Ingredient{Name: "garlic", MeasureType: "unit", Quantity: 1}))
+		require.NoError(t, ism.AddIngredient(ctx, ingredient.Ingredient{Name: "onion", MeasureType: "g", Quantity: 3}))
+
+		found, err := ism.FindIngredients(ctx)
+
+		require.NoError(t, err)
+		require.Len(t, found, 2)
+		assert.Equal(t, "onion", found[0].Name)
+		assert.Equal(t, 5, found[0].Quantity)
+		assert.Equal(t, "unit", found[0].MeasureType)
+	})
+
+	t.Run("it should update an ingredient", func(t *testing.T) {
+		ism := newBackend(t).Ingredients
+		id := addIngredient(t, ism, ctx, "onion")
+
+		err := ism.Update(ctx, ingredient.Ingredient{Id: &id, Name: "red onion", MeasureType: "g", Quantity: 7})
+
+		require.NoError(t, err)
+		found, err := ism.FindIngredients(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []ingredient.Ingredient{{Id: &id, Name: "red onion", MeasureType: "g", Quantity: 7}}, found)
+	})
+
+	t.Run("it should not find an unknown ingredient to update or delete", func(t *testing.T) {
+		ism := newBackend(t).Ingredients
+		id := addIngredient(t, ism, ctx, "onion")
+		unknown := id + 100
+
+		assertNotFound(t, ism.Update(ctx, ingredient.Ingredient{Id: &unknown, Name: "garlic", MeasureType: "unit", Quantity: 1}))
+		assertNotFound(t, ism.Delete(ctx, uint(unknown)))
+	})
+
+	t.Run("it should delete an ingredient once", func(t *testing.T) {
+		ism := newBackend(t).Ingredients
+		onion := addIngredient(t, ism, ctx, "onion")
+		addIngredient(t, ism, ctx, "garlic")
+
+		require.NoError(t, ism.Delete(ctx, uint(onion)))
+
+		found, err := ism.FindIngredients(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"garlic"}, ingredientNames(found))
+		assertNotFound(t, ism.Delete(ctx, uint(onion)))
+	})
+
+	t.Run("it should page through filtered and sorted ingredients", func(t *testing.T) {
+		ism := newBackend(t).Ingredients
+		for _, ing := range []ingredient.Ingredient{
+			{Name: "black pepper", MeasureType: "g", Quantity: 5},
+			{Name: "salt", MeasureType: "g", Quantity: 30},
+			{Name: "white pepper", MeasureType: "g", Quantity: 2},
+			{Name: "pink pepper", MeasureType: "g", Quantity: 9},
+		} {
+			require.NoError(t, ism.AddIngredient(ctx, ing))
+		}
+
+		byQuantity := listAll(t, func(after *domain.Cursor) (domain.Page[ingredient.Ingredient], error) {
+			return ism.ListIngredients(ctx, domain.ListOptions{Search: "pepper", Sort: domain.SortByQuantity, Descending: true, Limit: 2, After: after})
+		})
+		byName := listAll(t, func(after *domain.Cursor) (domain.Page[ingredient.Ingredient], error) {
+			return ism.ListIngredients(ctx, domain.ListOptions{Sort: domain.SortByName, Limit: 3, After: after})
+		})
+		byCreation := listAll(t, func(after *domain.Cursor) (domain.Page[ingredient.Ingredient], error) {
+			return ism.ListIngredients(ctx, domain.ListOptions{Descending: true, Limit: 1, After: after})
+		})
+
+		assert.Equal(t, []string{"pink pepper", "black pepper", "white pepper"}, ingredientNames(byQuantity))
+		assert.Equal(t, []string{"black pepper", "pink pepper", "salt", "white pepper"}, ingredientNames(byName))
+		assert.Equal(t, []string{"pink pepper", "white pepper", "salt", "black pepper"}, ingredientNames(byCreation))
+	})
+
+	t.Run("it should reject a sort it does not support", func(t *testing.T) {
+		ism := newBackend(t).Ingredients
+
+		_, err := ism.ListIngredients(ctx, domain.ListOptions{Sort: "color"})
+
+		var validation *domain.ValidationError
+		assert.ErrorAs(t, err, &validation)
+	})
+
+	t.Run("it should keep every household's pantry to itself", func(t *testing.T) {
+		backend := newBackend(t)
+		ism := backend.Ingredients
+		alice := newScope(t, backend, "alice")
+		bob := newScope(t, backend, "bob")
+		onion := addIngredient(t, ism, alice, "onion")
+		addIngredient(t, ism, bob, "onion")
+
+		alicePantry, err := ism.FindIngredients(alice)
+		require.NoError(t, err)
+		bobPantry, err := ism.FindIngredients(bob)
+		require.NoError(t, err)
+		everything, err := ism.FindIngredients(ctx)
+		require.NoError(t, err)
+
+		require.Len(t, alicePantry, 1)
+		assert.Equal(t, 1, alicePantry[0].Quantity)
+		assert.Len(t, bobPantry, 1)
+		assert.Len(t, everything, 2)
+		count, err := ism.CountIngredients(alice)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+		count, err = ism.CountIngredients(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+		assertNotFound(t, ism.Update(bob, ingredient.Ingredient{Id: &onion, Name: "onion", MeasureType: "unit", Quantity: 9}))
+		assertNotFound(t, ism.Delete(bob, uint(onion)))
+	})
+}
+
+// RunRecipeContract asserts the behavior every RecipeManager shares.
+// newBackend is called for every subtest and must return empty repositories.
+// The order of a recipe's ingredients is left to each backend.
+func RunRecipeContract(t *testing.T, newBackend func(t *testing.T) Backend) {
+	ctx := context.Background()
+	rice := []ingredient.Ingredient{
+		{Name: "rice", MeasureType: "g", Quantity: 200},
+		{Name: "garlic", MeasureType: "unit", Quantity: 2},
+	}
+
+	t.Run("it should get a recipe with its ingredients", func(t *testing.T) {
+		rm := newBackend(t).Recipes
+		id := addRecipe(t, rm, ctx, recipe.Recipe{Name: "rice", Ingredients: rice, Visibility: recipe.VisibilityPublic})
+
+		found, err := rm.GetRecipe(ctx, uint(id))
+
+		require.NoError(t, err)
+		assert.Equal(t, id, *found.Id)
+		assert.Equal(t, "rice", found.Name)
+		assert.Equal(t, recipe.VisibilityPublic, found.Visibility)
+		assert.ElementsMatch(t, rice, found.Ingredients)
+	})
+
+	t.Run("it should make recipes private unless told otherwise", func(t *testing.T) {
+		rm := newBackend(t).Recipes
+		id := addRecipe(t, rm, ctx, recipe.Recipe{Name: "rice", Ingredients: rice})
+
+		found, err := rm.GetRecipe(ctx, uint(id))
+
+		require.NoError(t, err)
+		assert.Equal(t, recipe.VisibilityPrivate, found.Visibility)
+	})
+
+	t.Run("it should keep the first of ingredients sharing a name", func(t *testing.T) {
+		rm := newBackend(t).Recipes
+		id := addRecipe(t, rm, ctx, recipe.Recipe{Name: "rice", Ingredients: []ingredient.Ingredient{
+			{Name: "rice", MeasureType: "g", Quantity: 200},
+			{Name: "rice", MeasureType: "g", Quantity: 500},
+		}})
+
+		found, err := rm.GetRecipe(ctx, uint(id))
+
+		require.NoError(t, err)
+		assert.Equal(t, []ingredient.Ingredient{{Name: "rice", MeasureType: "g", Quantity: 200}}, found.Ingredients)
+	})
+
+	t.Run("it should find recipes in the order they were added", func(t *testing.T) {
+		rm := newBackend(t).Recipes
+		for _, name := range []string{"soup", "rice", "fries"} {
+			addRecipe(t, rm, ctx, recipe.Recipe{Name: name, Ingredients: rice})
+		}
+
+		found, err := rm.GetAllRecipes(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"soup", "rice", "fries"}, recipeNames(found))
+	})
+
+	t.Run("it should reject a second recipe of the same name by the same owner", func(t *testing.T) {
+		backend := newBackend(t)
+		rm := backend.Recipes
+		alice := newScope(t, backend, "alice")
+		bob := newScope(t, backend, "bob")
+		addRecipe(t, rm, alice, recipe.Recipe{Name: "rice", Ingredients: rice})
+
+		err := rm.AddRecipe(alice, recipe.Recipe{Name: "rice", Ingredients: rice})
+
+		var conflict *domain.ConflictError
+		assert.ErrorAs(t, err, &conflict)
+		assert.NoError(t, rm.AddRecipe(bob, recipe.Recipe{Name: "rice", Ingredients: rice}))
+	})
+
+	t.Run("it should not keep a recipe whose ingredients fail to store", func(t *testing.T) {
+		backend := newBackend(t)
+		if backend.BreakRecipeIngredients == nil {
+			t.Skip("the backend cannot fail to store ingredients")
+		}
+		backend.BreakRecipeIngredients(t)
+
+		err := backend.Recipes.AddRecipe(ctx, recipe.Recipe{Name: "rice", Ingredients: rice})
+
+		require.Error(t, err)
+		found, err := backend.Recipes.GetAllRecipes(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, found)
+	})
+
+	t.Run("it should upsert recipes by name", func(t *testing.T) {
+		rm := newBackend(t).Recipes
+		soup := []ingredient.Ingredient{{Name: "tomato", MeasureType: "unit", Quantity: 4}}
+
+		added, err := rm.UpsertRecipe(ctx, recipe.Recipe{Name: "rice", Ingredients: rice, Visibility: recipe.VisibilityPrivate})
+		require.NoError(t, err)
+		assert.True(t, added)
+		added, err = rm.UpsertRecipe(ctx, recipe.Recipe{Name: "rice", Ingredients: soup, Visibility: recipe.VisibilityPublic})
+		require.NoError(t, err)
+		assert.False(t, added)
+
+		found, err := rm.GetAllRecipes(ctx)
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, soup, found[0].Ingredients)
+		assert.Equal(t, recipe.VisibilityPublic, found[0].Visibility)
+	})
+
+	t.Run("it should delete a recipe once", func(t *testing.T) {
+		rm := newBackend(t).Recipes
+		id := addRecipe(t, rm, ctx, recipe.Recipe{Name: "rice", Ingredients: rice})
+
+		require.NoError(t, rm.DeleteRecipe(ctx, uint(id)))
+
+		_, err := rm.GetRecipe(ctx, uint(id))
+		assertNotFound(t, err)
+		assertNotFound(t, rm.DeleteRecipe(ctx, uint(id)))
+		found, err := rm.GetAllRecipes(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, found)
+	})
+
+	t.Run("it should page through filtered and sorted recipes", func(t *testing.T) {
+		rm := newBackend(t).Recipes
+		for _, name := range []string{"tomato soup", "fried rice", "onion soup", "fries"} {
+			addRecipe(t, rm, ctx, recipe.Recipe{Name: name, Ingredients: rice})
+		}
+
+		byName := listAll(t, func(after *domain.Cursor) (domain.Page[recipe.Recipe], error) {
+			return rm.ListRecipes(ctx, domain.ListOptions{Search: "soup", Sort: domain.SortByName, Limit: 1, After: after})
+		})
+		byCreation := listAll(t, func(after *domain.Cursor) (domain.Page[recipe.Recipe], error) {
+			return rm.ListRecipes(ctx, domain.ListOptions{Descending: true, Limit: 3, After: after})
+		})
+
+		assert.Equal(t, []string{"onion soup", "tomato soup"}, recipeNames(byName))
+		assert.Equal(t, []string{"fries", "onion soup", "fried rice", "tomato soup"}, recipeNames(byCreation))
+		require.NotEmpty(t, byName)
+		assert.ElementsMatch(t, rice, byName[0].Ingredients)
+	})
+
+	t.Run("it should reject a sort it does not support", func(t *testing.T) {
+		rm := newBackend(t).Recipes
+
+		_, err := rm.ListRecipes(ctx, domain.ListOptions{Sort: domain.SortByQuantity})
+
+		var validation *domain.ValidationError
+		assert.ErrorAs(t, err, &validation)
+	})
+
+	t.Run("it should only show recipes to whom their visibility allows", func(t *testing.T) {
+		backend := newBackend(t)
+		rm := backend.Recipes
+		alice := newScope(t, backend, "alice")
+		bob := newScope(t, backend, "bob")
+		carol := joinScope(t, backend, "carol", alice)
+		private := addRecipe(t, rm, alice, recipe.Recipe{Name: "private rice", Ingredients: rice, Visibility: recipe.VisibilityPrivate})
+		addRecipe(t, rm, alice, recipe.Recipe{Name: "household rice", Ingredients: rice, Visibility: recipe.VisibilityHousehold})
+		addRecipe(t, rm, alice, recipe.Recipe{Name: "public rice", Ingredients: rice, Visibility: recipe.VisibilityPublic})
+
+		visibleTo := func(ctx context.Context) []string {
+			found, err := rm.GetAllRecipes(ctx)
+			require.NoError(t, err)
+			return recipeNames(found)
+		}
+
+		assert.Equal(t, []string{"private rice", "household rice", "public rice"}, visibleTo(alice))
+		assert.Equal(t, []string{"household rice", "public rice"}, visibleTo(carol))
+		assert.Equal(t, []string{"public rice"}, visibleTo(bob))
+		assert.Equal(t, []string{"private rice", "household rice", "public rice"}, visibleTo(ctx))
+		for scope, want := range map[context.Context]int{alice: 3, carol: 2, bob: 1, ctx: 3} {
+			count, err := rm.CountRecipes(scope)
+			require.NoError(t, err)
+			assert.Equal(t, want, count)
+		}
+		_, err := rm.GetRecipe(bob, uint(private))
+		assertNotFound(t, err)
+	})
+
+	t.Run("it should tell who wrote a recipe", func(t *testing.T) {
+		backend := newBackend(t)
+		rm := backend.Recipes
+		alice := newScope(t, backend, "alice")
+		aliceID, _ := user.UserIDFromContext(alice)
+		written := addRecipe(t, rm, alice, recipe.Recipe{Name: "alice's rice", Ingredients: rice, Visibility: recipe.VisibilityPublic})
+		seeded := addRecipe(t, rm, ctx, recipe.Recipe{Name: "seeded rice", Ingredients: rice, Visibility: recipe.VisibilityPublic})
+
+		found, err := rm.GetRecipe(alice, uint(written))
+		require.NoError(t, err)
+		require.NotNil(t, found.UserId)
+		assert.Equal(t, aliceID, *found.UserId)
+
+		found, err = rm.GetRecipe(alice, uint(seeded))
+		require.NoError(t, err)
+		assert.Nil(t, found.UserId)
+
+		all, err := rm.GetAllRecipes(alice)
+		require.NoError(t, err)
+		require.Len(t, all, 2)
+		assert.Equal(t, aliceID, *all[0].UserId)
+		assert.Nil(t, all[1].UserId)
+
+		page, err := rm.ListRecipes(alice, domain.ListOptions{Sort: domain.SortByCreated})
+		require.NoError(t, err)
+		require.Len(t, page.Items, 2)
+		assert.Equal(t, aliceID, *page.Items[0].UserId)
+		assert.Nil(t, page.Items[1].UserId)
+	})
+
+	t.Run("it should only let owners delete their recipes", func(t *testing.T) {
+		backend := newBackend(t)
+		rm := backend.Recipes
+		alice := newScope(t, backend, "alice")
+		bob := newScope(t, backend, "bob")
+		id := addRecipe(t, rm, alice, recipe.Recipe{Name: "public rice", Ingredients: rice, Visibility: recipe.VisibilityPublic})
+
+		assertNotFound(t, rm.DeleteRecipe(bob, uint(id)))
+
+		_, err := rm.GetRecipe(bob, uint(id))
+		assert.NoError(t, err)
+		assert.NoError(t, rm.DeleteRecipe(alice, uint(id)))
+	})
+}
+
+// RunTransactionContract asserts that the Transactor of a backend makes
+// changes to its ingredients and recipes atomic. newBackend is called for
+// every subtest and must return empty repositories.
+func RunTransactionContract(t *testing.T, newBackend func(t *testing.T) Backend) {
+	rice := []ingredient.Ingredient{{Name: "rice", MeasureType: "g", Quantity: 200}}
+
+	t.Run("it should commit every change when the function succeeds", func(t *testing.T) {
+		backend := newBackend(t)
+		alice := newScope(t, backend, "alice")
+
+		err := backend.Transactor.Transaction(alice, func(ctx context.Context) error {
+			if err := backend.Ingredients.AddIngredient(ctx, ingredient.Ingredient{Name: "rice", MeasureType: "g", Quantity: 500}); err != nil {
+				return err
+			}
+			found, err := backend.Ingredients.FindIngredients(ctx)
+			if err != nil {
+				return err
+			}
+			assert.Equal(t, []string{"rice"}, ingredientNames(found))
+			_, err = backend.Recipes.UpsertRecipe(ctx, recipe.Recipe{Name: "plain rice", Ingredients: rice})
+			return err
+		})
+
+		require.NoError(t, err)
+		pantry, err := backend.Ingredients.FindIngredients(alice)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"rice"}, ingredientNames(pantry))
+		recipes, err := backend.Recipes.GetAllRecipes(alice)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"plain rice"}, recipeNames(recipes))
+	})
+
+	t.Run("it should undo every change when the function fails", func(t *testing.T) {
+		backend := newBackend(t)
+		alice := newScope(t, backend, "alice")
+		onion := addIngredient(t, backend.Ingredients, alice, "onion")
+		soup := addRecipe(t, backend.Recipes, alice, recipe.Recipe{Name: "soup", Ingredients: rice})
+		failure := errors.New("failed halfway")
+
+		err := backend.Transactor.Transaction(alice, func(ctx context.Context) error {
+			require.NoError(t, backend.Ingredients.AddIngredient(ctx, ingredient.Ingredient{Name: "rice", MeasureType: "g", Quantity: 500}))
+			require.NoError(t, backend.Ingredients.Update(ctx, ingredient.Ingredient{Id: &onion, Name: "onion", MeasureType: "unit", Quantity: 9}))
+			_, err := backend.Recipes.UpsertRecipe(ctx, recipe.Recipe{Name: "soup", Ingredients: []ingredient.Ingredient{{Name: "water", MeasureType: "ml", Quantity: 1}}})
+			require.NoError(t, err)
+			require.NoError(t, backend.Recipes.AddRecipe(ctx, recipe.Recipe{Name: "plain rice", Ingredients: rice}))
+			require.NoError(t, backend.Recipes.DeleteRecipe(ctx, uint(soup)))
+			return failure
+		})
+
+		assert.ErrorIs(t, err, failure)
+		pantry, err := backend.Ingredients.FindIngredients(alice)
+		require.NoError(t, err)
+		assert.Equal(t, []ingredient.Ingredient{{Id: &onion, Name: "onion", MeasureType: "unit", Quantity: 1}}, pantry)
+		found, err := backend.Recipes.GetRecipe(alice, uint(soup))
+		require.NoError(t, err)
+		assert.Equal(t, rice, found.Ingredients)
+		recipes, err := backend.Recipes.GetAllRecipes(alice)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"soup"}, recipeNames(recipes))
+	})
+
+	t.Run("it should let nested transactions join the outer one", func(t *testing.T) {
+		backend := newBackend(t)
+		alice := newScope(t, backend, "alice")
+
+		err := backend.Transactor.Transaction(alice, func(ctx context.Context) error {
+			err := backend.Transactor.Transaction(ctx, func(ctx context.Context) error {
+				return backend.Ingredients.AddIngredient(ctx, ingredient.Ingredient{Name: "rice", MeasureType: "g", Quantity: 500})
+			})
+			require.NoError(t, err)
+			return errors.New("failed after the nested transaction")
+		})
+
+		require.Error(t, err)
+		pantry, err := backend.Ingredients.FindIngredients(alice)
+		require.NoError(t, err)
+		assert.Empty(t, pantry)
+	})
+}
+
+// newScope registers a user with a household of their own and returns a
+// context acting for them within it.
+func newScope(t *testing.T, backend Backend, name string) context.Context {
+	t.Helper()
+	ctx := context.Background()
+	u, err := backend.Users.AddUser(ctx, user.User{Email: name + "@example.com", PasswordHash: "not a hash"})
+	require.NoError(t, err)
+	h, err := backend.Households.AddHousehold(ctx, household.Household{Name: name}, u.Id)
+	require.NoError(t, err)
+	return withMembership(t, backend, h.Id, u.Id)
+}
+
+// joinScope registers a user as a member of the household of another scope.
+func joinScope(t *testing.T, backend Backend, name string, of context.Context) context.Context {
+	t.Helper()
+	ctx := context.Background()
+	u, err := backend.Users.AddUser(ctx, user.User{Email: name + "@example.com", PasswordHash: "not a hash"})
+	require.NoError(t, err)
+	m, ok := household.MembershipFromContext(of)
+	require.True(t, ok)
+	require.NoError(t, backend.Households.AddMember(ctx, m.Household.Id, u.Id, household.RoleMember))
+	return withMembership(t, backend, m.Household.Id, u.Id)
+}
+
+func withMembership(t *testing.T, backend Backend, householdID int, userID int) context.Context {
+	t.Helper()
+	m, err := backend.Households.FindMembership(context.Background(), householdID, userID)
+	require.NoError(t, err)
+	return household.WithMembership(user.WithUserID(context.Background(), userID), m)
+}
+
+func addIngredient(t *testing.T, ism ingredient.IngredientStorageManager, ctx context.Context, name string) int {
+	t.Helper()
+	require.NoError(t, ism.AddIngredient(ctx, ingredient.Ingredient{Name: name, MeasureType: "unit", Quantity: 1}))
+	found, err := ism.FindIngredients(ctx)
+	require.NoError(t, err)
+	for _, ing := range found {
+		if ing.Name == name {
+			return *ing.Id
+		}
+	}
+	t.Fatalf("ingredient %q was not added", name)
+	return 0
+}
+
+func addRecipe(t *testing.T, rm recipe.RecipeManager, ctx context.Context, r recipe.Recipe) int {
+	t.Helper()
+	require.NoError(t, rm.AddRecipe(ctx, r))
+	found, err := rm.GetAllRecipes(ctx)
+	require.NoError(t, err)
+	for _, f := range found {
+		if f.Name == r.Name {
+			return *f.Id
+		}
+	}
+	t.Fatalf("recipe %q was not added", r.Name)
+	return 0
+}
+
+// listAll follows the cursors of list until its last page.
+func listAll[T any](t *testing.T, list func(after *domain.Cursor) (domain.Page[T], error)) []T {
+	t.Helper()
+	var items []T
+	var after *domain.Cursor
+	for range 100 {
+		page, err := list(after)
+		require.NoError(t, err)
+		items = append(items, page.Items...)
+		if page.Next == nil {
+			return items
+		}
+		after = page.Next
+	}
+	t.Fatalf("list did not end after %d pages", 100)
+	return nil
+}
+
+func assertNotFound(t *testing.T, err error) {
+	t.Helper()
+	var notFound *domain.NotFoundError
+	assert.ErrorAs(t, err, &notFound)
+}
+
+func ingredientNames(ingredients []ingredient.Ingredient) []string {
+	names := make([]string, len(ingredients))
+	for i, ing := range ingredients {
+		names[i] = ing.Name
+	}
+	return names
+}
+
+func recipeNames(recipes []recipe.Recipe) []string {
+	names := make([]string, len(recipes))
+	for i, r := range recipes {
+		names[i] = r.Name
+	}
+	return names
+}
@@ -4,15 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "github.com/lib/pq"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
 	"log"
-	"os"
-	"path/filepath"
+	"q-q-tem-pra-hoje/internal/database"
 )
 
 var db *sql.DB
@@ -68,36 +64,10 @@ func GetDB() *sql.DB {
 	return db
 }
 
+// RunMigrations applies the migrations embedded into the binary, the same
+// ones the server applies.
 func RunMigrations(db *sql.DB) {
-	driver, err := postgres.WithInstance(db, &postgres.Config{})
-	if err != nil {
-		log.Fatalf("failed to create migrate driver: %v", err)
-	}
-
-	wd, err := os.Getwd()
-	if err != nil {
-		log.Fatalf("failed to get working directory: %v", err)
-	}
-
-	projectRoot := wd
-	for {
-		if _, err := os.Stat(filepath.Join(projectRoot, "go.mod")); err == nil {
-			break
-		}
-		parent := filepath.Dir(projectRoot)
-		if parent == projectRoot {
-			log.Fatalf("could not find project root (go.mod)")
-		}
-		projectRoot = parent
-	}
-
-	migrationsPath := filepath.Join(projectRoot, "migrations")
-	m, err := migrate.NewWithDatabaseInstance("file://"+migrationsPath, "postgres", driver)
-	if err != nil {
-		log.Fatalf("failed to create migrator: %v", err)
-	}
-
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		log.Fatalf("failed to run migrations: %v", err)
+	if err := database.Migrate(context.Background(), db); err != nil {
+		log.Fatal(err)
 	}
 }
@@ -0,0 +1,21 @@
+package testutil
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"q-q-tem-pra-hoje/internal/database"
+	"testing"
+)
+
+// NewSQLiteDB opens a migrated SQLite database in a file of its own that is
+// removed when t ends. Unlike SetupTestDB it needs no Docker.
+func NewSQLiteDB(t testing.TB) *sql.DB {
+	t.Helper()
+	db, err := database.OpenSQLite(context.Background(), filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
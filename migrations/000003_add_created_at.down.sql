@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS recipes_created_at_id_idx;
+DROP INDEX IF EXISTS ingredients_storage_quantity_id_idx;
+DROP INDEX IF EXISTS ingredients_storage_created_at_id_idx;
+DROP INDEX IF EXISTS ingredients_storage_name_id_idx;
+
+ALTER TABLE recipes DROP COLUMN IF EXISTS created_at;
+ALTER TABLE ingredients_storage DROP COLUMN IF EXISTS created_at;
@@ -0,0 +1,8 @@
+-- Creation timestamps for sorting, and indexes backing the keyset pagination
+ALTER TABLE ingredients_storage ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX IF NOT EXISTS ingredients_storage_name_id_idx ON ingredients_storage (name, id);
+CREATE INDEX IF NOT EXISTS ingredients_storage_created_at_id_idx ON ingredients_storage (created_at, id);
+CREATE INDEX IF NOT EXISTS ingredients_storage_quantity_id_idx ON ingredients_storage (quantity, id);
+CREATE INDEX IF NOT EXISTS recipes_created_at_id_idx ON recipes (created_at, id);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS ingredients_storage_name_trgm_idx;
+DROP INDEX IF EXISTS ingredients_storage_name_fts_idx;
+DROP INDEX IF EXISTS recipes_name_trgm_idx;
+DROP INDEX IF EXISTS recipes_name_fts_idx;
+
+DROP FUNCTION IF EXISTS immutable_unaccent(text);
+
+DROP EXTENSION IF EXISTS pg_trgm;
+DROP EXTENSION IF EXISTS unaccent;
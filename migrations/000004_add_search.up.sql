@@ -0,0 +1,14 @@
+-- Full-text and trigram search over recipe and pantry ingredient names
+CREATE EXTENSION IF NOT EXISTS unaccent;
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- unaccent() is only STABLE because its dictionary could be reloaded; naming
+-- the dictionary explicitly makes it safe to use in index expressions.
+CREATE OR REPLACE FUNCTION immutable_unaccent(text) RETURNS text
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT
+    AS $$ SELECT public.unaccent('public.unaccent'::regdictionary, $1) $$;
+
+CREATE INDEX IF NOT EXISTS recipes_name_fts_idx ON recipes USING GIN (to_tsvector('portuguese', immutable_unaccent(name)));
+CREATE INDEX IF NOT EXISTS recipes_name_trgm_idx ON recipes USING GIN (immutable_unaccent(lower(name)) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS ingredients_storage_name_fts_idx ON ingredients_storage USING GIN (to_tsvector('portuguese', immutable_unaccent(name)));
+CREATE INDEX IF NOT EXISTS ingredients_storage_name_trgm_idx ON ingredients_storage USING GIN (immutable_unaccent(lower(name)) gin_trgm_ops);
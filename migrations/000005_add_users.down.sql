@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS recipes_user_id_name_idx;
+ALTER TABLE recipes ADD CONSTRAINT recipes_name_key UNIQUE (name);
+
+DROP INDEX IF EXISTS ingredients_storage_user_id_idx;
+ALTER TABLE recipes DROP COLUMN IF EXISTS user_id;
+ALTER TABLE ingredients_storage DROP COLUMN IF EXISTS user_id;
+
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS users;
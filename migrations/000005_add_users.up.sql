@@ -0,0 +1,30 @@
+-- Accounts, login sessions, and the owner of every pantry ingredient and recipe
+CREATE TABLE IF NOT EXISTS users (
+    id SERIAL PRIMARY KEY,
+    email TEXT NOT NULL,
+    password_hash TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_idx ON users (lower(email));
+
+CREATE TABLE IF NOT EXISTS sessions (
+    token_hash TEXT PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS sessions_user_id_idx ON sessions (user_id);
+
+-- Rows created before accounts existed keep a NULL owner: they stay in the
+-- database but are not visible to any user.
+ALTER TABLE ingredients_storage ADD COLUMN IF NOT EXISTS user_id INT REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS user_id INT REFERENCES users(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS ingredients_storage_user_id_idx ON ingredients_storage (user_id);
+
+-- Recipe names only need to be unique within one user's recipes. Ownerless
+-- rows still clash with each other (NULLS NOT DISTINCT needs Postgres 15).
+ALTER TABLE recipes DROP CONSTRAINT IF EXISTS recipes_name_key;
+CREATE UNIQUE INDEX IF NOT EXISTS recipes_user_id_name_idx ON recipes (user_id, name) NULLS NOT DISTINCT;
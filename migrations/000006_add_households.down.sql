@@ -0,0 +1,21 @@
+-- Hand each pantry back to the oldest owner of its household.
+ALTER TABLE ingredients_storage ADD COLUMN IF NOT EXISTS user_id INT REFERENCES users(id) ON DELETE CASCADE;
+UPDATE ingredients_storage i SET user_id = (
+    SELECT m.user_id FROM household_members m
+    WHERE m.household_id = i.household_id AND m.role = 'owner'
+    ORDER BY m.joined_at, m.user_id
+    LIMIT 1
+);
+CREATE INDEX IF NOT EXISTS ingredients_storage_user_id_idx ON ingredients_storage (user_id);
+
+DROP INDEX IF EXISTS recipes_public_idx;
+DROP INDEX IF EXISTS recipes_household_id_idx;
+DROP INDEX IF EXISTS ingredients_storage_household_id_idx;
+
+ALTER TABLE recipes DROP COLUMN IF EXISTS visibility;
+ALTER TABLE recipes DROP COLUMN IF EXISTS household_id;
+ALTER TABLE ingredients_storage DROP COLUMN IF EXISTS household_id;
+
+DROP TABLE IF EXISTS household_invitations;
+DROP TABLE IF EXISTS household_members;
+DROP TABLE IF EXISTS households;
@@ -0,0 +1,52 @@
+-- Households own the pantry and the recipes their members share with them
+CREATE TABLE IF NOT EXISTS households (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS household_members (
+    household_id INT NOT NULL REFERENCES households(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'member', 'viewer')),
+    joined_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (household_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS household_members_user_id_idx ON household_members (user_id);
+
+-- Only the hash of an invitation code is stored; joining deletes the row.
+CREATE TABLE IF NOT EXISTS household_invitations (
+    code_hash TEXT PRIMARY KEY,
+    household_id INT NOT NULL REFERENCES households(id) ON DELETE CASCADE,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'member', 'viewer')),
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE ingredients_storage ADD COLUMN IF NOT EXISTS household_id INT REFERENCES households(id) ON DELETE CASCADE;
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS household_id INT REFERENCES households(id) ON DELETE SET NULL;
+ALTER TABLE recipes ADD COLUMN IF NOT EXISTS visibility TEXT NOT NULL DEFAULT 'private'
+    CHECK (visibility IN ('private', 'household', 'public'));
+
+-- Every existing user gets a personal household holding their pantry.
+DO $$
+DECLARE
+    account RECORD;
+    created INT;
+BEGIN
+    FOR account IN SELECT id FROM users ORDER BY id LOOP
+        INSERT INTO households (name) VALUES ('Personal') RETURNING id INTO created;
+        INSERT INTO household_members (household_id, user_id, role) VALUES (created, account.id, 'owner');
+        UPDATE ingredients_storage SET household_id = created WHERE user_id = account.id;
+        UPDATE recipes SET household_id = created WHERE user_id = account.id;
+    END LOOP;
+END $$;
+
+-- The pantry now belongs to the household rather than to a single user.
+DROP INDEX IF EXISTS ingredients_storage_user_id_idx;
+ALTER TABLE ingredients_storage DROP COLUMN IF EXISTS user_id;
+
+CREATE INDEX IF NOT EXISTS ingredients_storage_household_id_idx ON ingredients_storage (household_id);
+CREATE INDEX IF NOT EXISTS recipes_household_id_idx ON recipes (household_id) WHERE visibility = 'household';
+CREATE INDEX IF NOT EXISTS recipes_public_idx ON recipes (id) WHERE visibility = 'public';
@@ -0,0 +1,3 @@
+-- The removed sample recipes are not restored; apply them with
+-- `seed -env demo` instead.
+SELECT 1;
@@ -0,0 +1,41 @@
+-- Removes the sample recipes that migration 000002 inserts, which
+-- belong to nobody and have no ingredients. Recipes someone has since given
+-- ingredients are kept. The demo recipes are now applied with `seed -env demo`.
+DELETE FROM recipes r
+WHERE r.user_id IS NULL
+    AND r.household_id IS NULL
+    AND NOT EXISTS (SELECT 1 FROM recipes_ingredients i WHERE i.recipe_id = r.id)
+    AND r.name IN (
+        'Quibe',
+        'Berinjela à parmegiana',
+        'Panqueca e recheios',
+        'Torta de palmito',
+        'Moqueca de palmito',
+        'Rondelli de abobrinha',
+        'Panqueca de espinafre',
+        'Torta queijo e cebola caramelizada',
+        'Hambúrguer de grão de bico',
+        'Bolinho de espinafre',
+        'Almôndegas',
+        'Gnocchi de ricotta a la Mariah',
+        'Tropeiro',
+        'Lentilha laranja',
+        'Ovo com ragu de cogumelos',
+        'Bolinho de queijo de cabra',
+        'Yakisoba legumes',
+        'Risoto alho poro',
+        'Torta de abóbrinha',
+        'Bife da Vovó Neusa',
+        'Panqueca de repolho',
+        'Quiche manjericao +tomate',
+        'Bolo salgado',
+        'Mjadra',
+        'Couve-flor; gratinada',
+        'Feijoada',
+        'Medalhão',
+        'Strogonoff cogu/carne',
+        'Carbonara',
+        'Lasanha',
+        'Suflê de queijo prático',
+        'Arroz piamontese'
+    );
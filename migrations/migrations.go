@@ -0,0 +1,14 @@
+// Package migrations embeds the schema migrations, so the binary applies
+// them wherever it runs from.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
+
+// SQLiteFS holds the separate migration set of the SQLite storage, under
+// the sqlite directory.
+//
+//go:embed sqlite/*.sql
+var SQLiteFS embed.FS
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS recipes_ingredients;
+DROP TABLE IF EXISTS recipes;
+DROP TABLE IF EXISTS ingredients_storage;
+DROP TABLE IF EXISTS household_invitations;
+DROP TABLE IF EXISTS household_members;
+DROP TABLE IF EXISTS households;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,96 @@
+-- The SQLite schema, equivalent to the Postgres one after 000008. Times are
+-- stored as UTC text with millisecond precision, so they sort as they compare.
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    email TEXT NOT NULL,
+    password_hash TEXT NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_idx ON users (lower(email));
+
+CREATE TABLE IF NOT EXISTS sessions (
+    token_hash TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TEXT NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+);
+
+CREATE INDEX IF NOT EXISTS sessions_user_id_idx ON sessions (user_id);
+
+CREATE TABLE IF NOT EXISTS households (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+);
+
+CREATE TABLE IF NOT EXISTS household_members (
+    household_id INTEGER NOT NULL REFERENCES households(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'member', 'viewer')),
+    joined_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+    PRIMARY KEY (household_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS household_members_user_id_idx ON household_members (user_id);
+
+CREATE TABLE IF NOT EXISTS household_invitations (
+    code_hash TEXT PRIMARY KEY,
+    household_id INTEGER NOT NULL REFERENCES households(id) ON DELETE CASCADE,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'member', 'viewer')),
+    expires_at TEXT NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+);
+
+CREATE TABLE IF NOT EXISTS ingredients_storage (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    measure_type TEXT NOT NULL,
+    quantity INTEGER NOT NULL,
+    household_id INTEGER REFERENCES households(id) ON DELETE CASCADE,
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+);
+
+CREATE INDEX IF NOT EXISTS ingredients_storage_name_id_idx ON ingredients_storage (name, id);
+CREATE INDEX IF NOT EXISTS ingredients_storage_created_at_id_idx ON ingredients_storage (created_at, id);
+CREATE INDEX IF NOT EXISTS ingredients_storage_quantity_id_idx ON ingredients_storage (quantity, id);
+CREATE INDEX IF NOT EXISTS ingredients_storage_household_id_idx ON ingredients_storage (household_id);
+
+CREATE TABLE IF NOT EXISTS recipes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    household_id INTEGER REFERENCES households(id) ON DELETE SET NULL,
+    visibility TEXT NOT NULL DEFAULT 'private' CHECK (visibility IN ('private', 'household', 'public')),
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+);
+
+-- Unique indexes treat NULLs as distinct in SQLite, so ownerless recipes are
+-- folded onto 0 to clash with each other as they do in Postgres.
+CREATE UNIQUE INDEX IF NOT EXISTS recipes_user_id_name_idx ON recipes (ifnull(user_id, 0), name);
+CREATE INDEX IF NOT EXISTS recipes_created_at_id_idx ON recipes (created_at, id);
+CREATE INDEX IF NOT EXISTS recipes_household_id_idx ON recipes (household_id) WHERE visibility = 'household';
+CREATE INDEX IF NOT EXISTS recipes_public_idx ON recipes (id) WHERE visibility = 'public';
+
+CREATE TABLE IF NOT EXISTS recipes_ingredients (
+    recipe_id INTEGER NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    measure_type TEXT NOT NULL,
+    quantity INTEGER NOT NULL,
+    PRIMARY KEY (recipe_id, name)
+);
+
+-- Scopes are a JSON array of strings.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    household_id INTEGER NOT NULL REFERENCES households(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    scopes TEXT NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now')),
+    last_used_at TEXT
+);
+
+CREATE INDEX IF NOT EXISTS api_keys_user_id_idx ON api_keys (user_id);
@@ -0,0 +1,8 @@
+// Package seeds embeds the seed fixtures, one directory per environment,
+// applied with the seed command.
+package seeds
+
+import "embed"
+
+//go:embed */*
+var FS embed.FS
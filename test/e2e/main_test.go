@@ -1,11 +1,20 @@
 package e2e_test
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"q-q-tem-pra-hoje/internal/testutil"
+	"strings"
 	"testing"
 )
 
+var discardLogger = slog.New(slog.DiscardHandler)
+
 func TestMain(m *testing.M) {
 	dsn, teardown := testutil.SetupTestDB()
 
@@ -18,3 +27,72 @@ func TestMain(m *testing.M) {
 
 	os.Exit(code)
 }
+
+// signUp registers an account named after the test through the API and
+// returns its id and a bearer token for it.
+func signUp(t *testing.T, ts *httptest.Server) (int, string) {
+	t.Helper()
+	email := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "-")) + "@example.com"
+	credentials := fmt.Sprintf(`{"email":%q,"password":"password123"}`, email)
+
+	var registered struct {
+		ID int `json:"id"`
+	}
+	postJSON(t, ts.URL+"/api/v1/auth/register", credentials, http.StatusCreated, &registered)
+
+	var session struct {
+		Token string `json:"token"`
+	}
+	postJSON(t, ts.URL+"/api/v1/auth/login", credentials, http.StatusOK, &session)
+
+	t.Cleanup(func() { testutil.GetDB().Exec("DELETE FROM users WHERE id = $1", registered.ID) })
+	return registered.ID, session.Token
+}
+
+func postJSON(t *testing.T, url string, body string, expectedStatus int, out any) {
+	t.Helper()
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to post to %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != expectedStatus {
+		raw, _ := io.ReadAll(resp.Body)
+		t.Fatalf("expected status %d from %s, got %d: %s", expectedStatus, url, resp.StatusCode, raw)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		t.Fatalf("failed to decode %s response: %v", url, err)
+	}
+}
+
+// householdOf returns the household requests with token act within by
+// default, creating the personal household of a new account.
+func householdOf(t *testing.T, ts *httptest.Server, token string) int {
+	t.Helper()
+	resp := authorized(t, http.MethodGet, ts.URL+"/api/v1/households", nil, token)
+	defer resp.Body.Close()
+
+	var households []struct {
+		ID int `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&households); err != nil || len(households) == 0 {
+		t.Fatalf("failed to read the households: %v", err)
+	}
+	return households[0].ID
+}
+
+// authorized sends a request with the given bearer token.
+func authorized(t *testing.T, method string, url string, body io.Reader, token string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to send %s %s: %v", method, url, err)
+	}
+	return resp
+}
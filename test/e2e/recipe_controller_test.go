@@ -21,7 +21,7 @@ func idPointer(id int) *int {
 func TestRecipeController_Add(t *testing.T) {
 	db := testutil.GetDB()
 
-	handler := app.NewHandler(db)
+	handler := app.NewHandler(db, discardLogger)
 
 	ts := httptest.NewServer(handler)
 	t.Cleanup(func() {
@@ -30,6 +30,7 @@ func TestRecipeController_Add(t *testing.T) {
 	})
 
 	t.Run("should create a recipe", func(t *testing.T) {
+		_, token := signUp(t, ts)
 
 		body := `{"name":"Rice", "ingredients": [
         {"name": "Onion", "measureType":"unit","quantity":1},
@@ -37,10 +38,7 @@ func TestRecipeController_Add(t *testing.T) {
         {"name": "Garlic", "measureType":"unit","quantity":2}
       ]}`
 
-		resp, err := http.Post(ts.URL+"/recipe", "application/json", bytes.NewBufferString(body))
-		if err != nil {
-			t.Fatalf("failed to get ingredients: %v", err)
-		}
+		resp := authorized(t, http.MethodPost, ts.URL+"/recipe", bytes.NewBufferString(body), token)
 
 		if resp.StatusCode != http.StatusCreated {
 			t.Errorf("expected status %d, got %d", http.StatusCreated, resp.StatusCode)
@@ -50,7 +48,7 @@ func TestRecipeController_Add(t *testing.T) {
 
 func TestRecipeController_GetRecipes(t *testing.T) {
 	db := testutil.GetDB()
-	handler := app.NewHandler(db)
+	handler := app.NewHandler(db, discardLogger)
 
 	ts := httptest.NewServer(handler)
 
@@ -60,10 +58,12 @@ func TestRecipeController_GetRecipes(t *testing.T) {
 	})
 
 	t.Run("should retrieve the recipes", func(t *testing.T) {
+		userID, token := signUp(t, ts)
 
-		query := `INSERT INTO ingredients_storage(name, measure_type, quantity)
-            VALUES ($1, $2, $3), ($4, $5, $6);`
-		_, err := db.Exec(query, "Onion", "unit", 1, "Rice", "mg", 500)
+		householdID := householdOf(t, ts, token)
+		query := `INSERT INTO ingredients_storage(name, measure_type, quantity, household_id)
+            VALUES ($1, $2, $3, $7), ($4, $5, $6, $7);`
+		_, err := db.Exec(query, "Onion", "unit", 1, "Rice", "mg", 500, householdID)
 
 		if err != nil {
 			t.Fatal(err)
@@ -79,7 +79,7 @@ func TestRecipeController_GetRecipes(t *testing.T) {
 
 		for _, recipe := range recipes {
 			var recipeId int
-			err := db.QueryRow("INSERT INTO recipes (name) VALUES ($1) RETURNING id;", recipe.Name).Scan(&recipeId)
+			err := db.QueryRow("INSERT INTO recipes (name, user_id) VALUES ($1, $2) RETURNING id;", recipe.Name, userID).Scan(&recipeId)
 			if err != nil {
 				t.Fatalf("failed to insert recipe %q: %v", recipe.Name, err)
 			}
@@ -96,10 +96,7 @@ func TestRecipeController_GetRecipes(t *testing.T) {
 			}
 		}
 
-		resp, err := http.Get(ts.URL + "/recipe")
-		if err != nil {
-			t.Fatalf("failed to get recipes: %v", err)
-		}
+		resp := authorized(t, http.MethodGet, ts.URL+"/recipe", nil, token)
 
 		if resp.StatusCode != http.StatusOK {
 			t.Errorf("expected status %d, got %d", http.StatusOK, resp.StatusCode)
@@ -121,7 +118,7 @@ func TestRecipeController_GetRecipes(t *testing.T) {
 
 func TestRecipeController_Delete(t *testing.T) {
 	db := testutil.GetDB()
-	handler := app.NewHandler(db)
+	handler := app.NewHandler(db, discardLogger)
 	ts := httptest.NewServer(handler)
 
 	t.Cleanup(func() {
@@ -130,6 +127,7 @@ func TestRecipeController_Delete(t *testing.T) {
 	})
 
 	t.Run("should delete a recipe", func(t *testing.T) {
+		userID, token := signUp(t, ts)
 
 		recipes := []recipe.Recipe{
 			{Id: idPointer(1), Name: "Rice with Garlic", Ingredients: []ingredient.Ingredient{
@@ -147,7 +145,7 @@ func TestRecipeController_Delete(t *testing.T) {
 
 		for _, recipe := range recipes {
 			var recipeId int
-			err := db.QueryRow("INSERT INTO recipes (id, name) VALUES ($1, $2) RETURNING id;", recipe.Id, recipe.Name).Scan(&recipeId)
+			err := db.QueryRow("INSERT INTO recipes (id, name, user_id) VALUES ($1, $2, $3) RETURNING id;", recipe.Id, recipe.Name, userID).Scan(&recipeId)
 			if err != nil {
 				t.Fatalf("failed to insert recipe %q: %v", recipe.Name, err)
 			}
@@ -164,18 +162,7 @@ func TestRecipeController_Delete(t *testing.T) {
 			}
 		}
 
-		req, err := http.NewRequest(http.MethodDelete, ts.URL+"/recipe?id=2", bytes.NewBufferString(`{}`))
-		if err != nil {
-			t.Fatalf("failed to create request: %v", err)
-		}
-
-		req.Header.Set("Content-Type", "application/json")
-		client := &http.Client{}
-
-		resp, err := client.Do(req)
-		if err != nil {
-			t.Fatalf("failed to delete a recipe: %v", err)
-		}
+		resp := authorized(t, http.MethodDelete, ts.URL+"/recipe?id=2", bytes.NewBufferString(`{}`), token)
 
 		if resp.StatusCode != http.StatusNoContent {
 			t.Errorf("expected status %d, got %d", http.StatusOK, resp.StatusCode)
@@ -19,17 +19,20 @@ func intPtr(i int) *int {
 
 func TestRecommendationController_GetRecommendation(t *testing.T) {
 	db := testutil.GetDB()
+	handler := app.NewHandler(db, discardLogger)
+
+	ts := httptest.NewServer(handler)
+	userID, token := signUp(t, ts)
+
 	tx, err := db.Begin()
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	handler := app.NewHandler(db)
-
-	ts := httptest.NewServer(handler)
-	query := `INSERT INTO ingredients_storage(name, measure_type, quantity)
-            VALUES ($1, $2, $3), ($4, $5, $6);`
-	_, err = tx.Exec(query, "Onion", "unit", 1, "Rice", "mg", 500)
+	householdID := householdOf(t, ts, token)
+	query := `INSERT INTO ingredients_storage(name, measure_type, quantity, household_id)
+            VALUES ($1, $2, $3, $7), ($4, $5, $6, $7);`
+	_, err = tx.Exec(query, "Onion", "unit", 1, "Rice", "mg", 500, householdID)
 
 	if err != nil {
 		t.Fatal(err)
@@ -56,7 +59,7 @@ func TestRecommendationController_GetRecommendation(t *testing.T) {
 
 	for _, recipe := range recipes {
 		var recipeId int
-		err := tx.QueryRow("INSERT INTO recipes (name) VALUES ($1) RETURNING id;", recipe.Name).Scan(&recipeId)
+		err := tx.QueryRow("INSERT INTO recipes (name, user_id) VALUES ($1, $2) RETURNING id;", recipe.Name, userID).Scan(&recipeId)
 		if err != nil {
 			t.Fatalf("failed to insert recipe %q: %v", recipe.Name, err)
 		}
@@ -104,10 +107,7 @@ func TestRecommendationController_GetRecommendation(t *testing.T) {
 			}},
 		}
 
-		resp, err := http.Get(ts.URL + "/recommendation")
-		if err != nil {
-			t.Fatalf("Failed to get recommendations: %v", err)
-		}
+		resp := authorized(t, http.MethodGet, ts.URL+"/recommendation", nil, token)
 
 		if resp.StatusCode != http.StatusOK {
 			t.Errorf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
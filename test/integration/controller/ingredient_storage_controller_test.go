@@ -2,9 +2,11 @@ package controller_integration_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"github.com/stretchr/testify/assert"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"q-q-tem-pra-hoje/internal/domain/ingredient"
@@ -15,6 +17,8 @@ import (
 	"testing"
 )
 
+var discardLogger = slog.New(slog.DiscardHandler)
+
 func TestIngredientStorageController_Add(t *testing.T) {
 	t.Run("should call the service properly", func(t *testing.T) {
 
@@ -22,8 +26,8 @@ func TestIngredientStorageController_Add(t *testing.T) {
 
 		repo := in_memory_repository.NewIngredientStorageManager()
 
-		srvc := service.NewService(&repo)
-		ctrl := controller.NewIngredientController(srvc)
+		srvc := service.NewService(repo, discardLogger)
+		ctrl := controller.NewIngredientController(srvc, discardLogger)
 
 		server := httptest.NewServer(ctrl)
 		defer server.Close()
@@ -35,12 +39,14 @@ func TestIngredientStorageController_Add(t *testing.T) {
 		defer resp.Body.Close()
 
 		assert.Equal(t, http.StatusCreated, resp.StatusCode)
-		assert.Len(t, repo.Ingredients, 1, "repository should contain exactly one ingredient")
+		ingredients, err := repo.FindIngredients(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, ingredients, 1, "repository should contain exactly one ingredient")
 
 		expectedIngredient := ingredient.Ingredient{Name: "Salt", MeasureType: "unit", Quantity: 1}
-		assert.Equal(t, expectedIngredient.MeasureType, repo.Ingredients[0].MeasureType)
-		assert.Equal(t, expectedIngredient.Name, repo.Ingredients[0].Name)
-		assert.Equal(t, expectedIngredient.Quantity, repo.Ingredients[0].Quantity)
+		assert.Equal(t, expectedIngredient.MeasureType, ingredients[0].MeasureType)
+		assert.Equal(t, expectedIngredient.Name, ingredients[0].Name)
+		assert.Equal(t, expectedIngredient.Quantity, ingredients[0].Quantity)
 
 		assert.Empty(t, resp.Body)
 	})
@@ -49,16 +55,17 @@ func TestIngredientStorageController_Add(t *testing.T) {
 func TestIngredientStorageController_GetAll(t *testing.T) {
 	t.Run("should retrieve all ingredients from the repository", func(t *testing.T) {
 		repository := in_memory_repository.NewIngredientStorageManager()
+		saltID, pepperID := 1, 2
 		ingredients := []ingredient.Ingredient{
-			{Name: "Salt", MeasureType: "unit", Quantity: 1},
-			{Name: "Pepper", MeasureType: "unit", Quantity: 2},
+			{Id: &saltID, Name: "Salt", MeasureType: "unit", Quantity: 1},
+			{Id: &pepperID, Name: "Pepper", MeasureType: "unit", Quantity: 2},
 		}
 		for _, ing := range ingredients {
-			repository.Ingredients = append(repository.Ingredients, ing)
+			repository.AddIngredient(context.Background(), ing)
 		}
 
-		svc := service.NewService(&repository)
-		ctrl := controller.NewIngredientController(svc)
+		svc := service.NewService(repository, discardLogger)
+		ctrl := controller.NewIngredientController(svc, discardLogger)
 
 		server := httptest.NewServer(ctrl)
 		resp, err := http.Get(server.URL + "/ingredient")
@@ -85,10 +92,68 @@ func TestIngredientStorageController_GetAll(t *testing.T) {
 
 }
 
+func TestIngredientStorageController_GetAll_Paginated(t *testing.T) {
+	t.Run("should page through the filtered and sorted ingredients", func(t *testing.T) {
+		repository := in_memory_repository.NewIngredientStorageManager()
+		for _, ing := range []ingredient.Ingredient{
+			{Name: "Black pepper", MeasureType: "g", Quantity: 5},
+			{Name: "Salt", MeasureType: "g", Quantity: 30},
+			{Name: "White pepper", MeasureType: "g", Quantity: 2},
+			{Name: "Pink pepper", MeasureType: "g", Quantity: 9},
+		} {
+			repository.AddIngredient(context.Background(), ing)
+		}
+
+		svc := service.NewService(repository, discardLogger)
+		server := httptest.NewServer(controller.NewIngredientController(svc, discardLogger))
+		defer server.Close()
+
+		var names []string
+		url := server.URL + "/ingredient?q=PEPPER&sort=-quantity&limit=2"
+		for url != "" {
+			resp, err := http.Get(url)
+			if err != nil {
+				t.Fatalf("failed to retrieve ingredients: %v", err)
+			}
+			var page []map[string]any
+			err = json.NewDecoder(resp.Body).Decode(&page)
+			resp.Body.Close()
+			if err != nil {
+				t.Fatalf("failed to decode ingredients: %v", err)
+			}
+			for _, ing := range page {
+				names = append(names, ing["name"].(string))
+			}
+
+			url = ""
+			if cursor := resp.Header.Get("X-Next-Cursor"); cursor != "" {
+				url = server.URL + "/ingredient?q=PEPPER&sort=-quantity&limit=2&cursor=" + cursor
+			}
+		}
+
+		assert.Equal(t, []string{"Pink pepper", "Black pepper", "White pepper"}, names)
+	})
+
+	t.Run("should reject an unknown sort field", func(t *testing.T) {
+		repository := in_memory_repository.NewIngredientStorageManager()
+		svc := service.NewService(repository, discardLogger)
+		server := httptest.NewServer(controller.NewIngredientController(svc, discardLogger))
+		defer server.Close()
+
+		resp, err := http.Get(server.URL + "/ingredient?sort=color")
+		if err != nil {
+			t.Fatalf("failed to retrieve ingredients: %v", err)
+		}
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	})
+}
+
 func TestIngredientController_Update_Integration(t *testing.T) {
 	repo := in_memory_repository.NewIngredientStorageManager()
-	svc := service.NewService(&repo)
-	ctrl := controller.NewIngredientController(svc)
+	svc := service.NewService(repo, discardLogger)
+	ctrl := controller.NewIngredientController(svc, discardLogger)
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("PATCH /ingredient/{id}", func(w http.ResponseWriter, r *http.Request) {
@@ -103,7 +168,7 @@ func TestIngredientController_Update_Integration(t *testing.T) {
 		MeasureType: "unit",
 		Quantity:    1,
 	}
-	repo.AddIngredient(initialIng)
+	repo.AddIngredient(context.Background(), initialIng)
 
 	requestBody := `{"name":"Salt","measureType":"unit","quantity":5}`
 	req, err := http.NewRequest(http.MethodPatch, server.URL+"/ingredient/1", strings.NewReader(requestBody))
@@ -121,7 +186,8 @@ func TestIngredientController_Update_Integration(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 
-	ingredients := repo.Ingredients
+	ingredients, err := repo.FindIngredients(context.Background())
+	assert.NoError(t, err)
 	assert.NotEmpty(t, ingredients)
 	assert.Len(t, ingredients, 1)
 	updated := ingredients[0]
@@ -133,9 +199,10 @@ func TestIngredientController_Update_Integration(t *testing.T) {
 func TestIngredientStorageController_Delete(t *testing.T) {
 	t.Run("should delete an ingredient by Id", func(t *testing.T) {
 		repository := in_memory_repository.NewIngredientStorageManager()
+		repository.AddIngredient(context.Background(), ingredient.Ingredient{Name: "Salt", MeasureType: "unit", Quantity: 1})
 
-		svc := service.NewService(&repository)
-		ctrl := controller.NewIngredientController(svc)
+		svc := service.NewService(repository, discardLogger)
+		ctrl := controller.NewIngredientController(svc, discardLogger)
 
 		server := httptest.NewServer(ctrl)
 		req, err := http.NewRequest(
@@ -155,6 +222,8 @@ func TestIngredientStorageController_Delete(t *testing.T) {
 		defer resp.Body.Close()
 
 		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
-
+		ingredients, err := repository.FindIngredients(context.Background())
+		assert.NoError(t, err)
+		assert.Empty(t, ingredients)
 	})
 }
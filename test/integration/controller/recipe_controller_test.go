@@ -2,6 +2,7 @@ package controller_integration_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"github.com/stretchr/testify/assert"
 	"io"
@@ -19,8 +20,8 @@ func TestRecipeController_Add(t *testing.T) {
 	t.Run("should add a recipe", func(t *testing.T) {
 
 		repository := in_memory_repository.NewRecipeManager([]recipe.Recipe{})
-		service := recipeService.NewRecipeService(repository)
-		controller := controller.RecipeController{RecipeProvider: service}
+		service := recipeService.NewRecipeService(repository, discardLogger)
+		controller := controller.RecipeController{RecipeProvider: service, Logger: discardLogger}
 
 		body := `{"name":"Rice", "ingredients": [
         {"name": "Onion", "measureType":"unit","quantity":1},
@@ -33,7 +34,7 @@ func TestRecipeController_Add(t *testing.T) {
 
 		controller.Add(w, r)
 
-		expectedRecipes, err := recipe.NewRecipe(0, "Rice", []ingredient.Ingredient{
+		expectedRecipe, err := recipe.NewRecipe(1, "Rice", []ingredient.Ingredient{
 			{Name: "Onion", MeasureType: "unit", Quantity: 1},
 			{Name: "Rice", MeasureType: "mg", Quantity: 500},
 			{Name: "Garlic", MeasureType: "unit", Quantity: 2}})
@@ -42,8 +43,10 @@ func TestRecipeController_Add(t *testing.T) {
 			t.Fatalf("failed to create a recipe: %v", err)
 		}
 
-		assert.Equal(t, []recipe.Recipe{expectedRecipes}, repository.Recipes)
-		assert.True(t, repository.MethodCalled)
+		expectedRecipe.Visibility = recipe.VisibilityPrivate
+		recipes, err := repository.GetAllRecipes(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []recipe.Recipe{expectedRecipe}, recipes)
 
 	})
 }
@@ -70,8 +73,12 @@ func TestRecipeController_Get(t *testing.T) {
 			}},
 		}
 		repository := in_memory_repository.NewRecipeManager(expectedRecipes)
-		service := recipeService.NewRecipeService(repository)
-		controller := controller.RecipeController{RecipeProvider: service}
+		for i := range expectedRecipes {
+			id := i + 1
+			expectedRecipes[i].Id = &id
+		}
+		service := recipeService.NewRecipeService(repository, discardLogger)
+		controller := controller.RecipeController{RecipeProvider: service, Logger: discardLogger}
 		server := httptest.NewServer(controller)
 		defer server.Close()
 
@@ -93,16 +100,17 @@ func TestRecipeController_Get(t *testing.T) {
 		err = json.Unmarshal(body, &resultRecipes)
 		assert.Equal(t, expectedRecipes, resultRecipes)
 		assert.Equal(t, resp.StatusCode, http.StatusOK)
-		assert.True(t, repository.MethodCalled)
 
 	})
 }
 
 func TestRecipeController_Delete(t *testing.T) {
 	t.Run("should delete a recipe by Id", func(t *testing.T) {
-		repository := in_memory_repository.NewRecipeManager([]recipe.Recipe{})
-		service := recipeService.NewRecipeService(repository)
-		ctrl := controller.RecipeController{RecipeProvider: service}
+		repository := in_memory_repository.NewRecipeManager([]recipe.Recipe{
+			{Name: "Fries", Ingredients: []ingredient.Ingredient{{Name: "Potato", MeasureType: "unit", Quantity: 2}}},
+		})
+		service := recipeService.NewRecipeService(repository, discardLogger)
+		ctrl := controller.RecipeController{RecipeProvider: service, Logger: discardLogger}
 		server := httptest.NewServer(ctrl)
 
 		req, err := http.NewRequest(http.MethodDelete, server.URL+"/recipes?id=1", bytes.NewBufferString(``))
@@ -119,7 +127,9 @@ func TestRecipeController_Delete(t *testing.T) {
 		}
 
 		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
-		assert.True(t, repository.MethodCalled)
+		recipes, err := repository.GetAllRecipes(context.Background())
+		assert.NoError(t, err)
+		assert.Empty(t, recipes)
 
 	})
 }
@@ -1,6 +1,7 @@
 package controller_integration_test
 
 import (
+	"context"
 	"encoding/json"
 	"github.com/stretchr/testify/assert"
 	"io"
@@ -39,13 +40,17 @@ func TestRecommendationController_GetRecommendation(t *testing.T) {
 		}
 		ingredientRepository := in_memory_repository.NewIngredientStorageManager()
 		recipeRepository := in_memory_repository.NewRecipeManager(recipes)
+		for i := range recipes {
+			id := i + 1
+			recipes[i].Id = &id
+		}
 
-		ingredientService := ingredientService.NewService(&ingredientRepository)
-		service := recommendationService.NewRecommendationService(recipeRepository)
-		controller := controller.RecommendationController{RecommendationProvider: service, IngredientProvider: ingredientService}
+		ingredientService := ingredientService.NewService(ingredientRepository, discardLogger)
+		service := recommendationService.NewRecommendationService(recipeRepository, discardLogger)
+		controller := controller.RecommendationController{RecommendationProvider: service, IngredientProvider: ingredientService, Logger: discardLogger}
 
-		ingredientService.Add(ingredient.Ingredient{Name: "Onion", Quantity: 1, MeasureType: "unit"})
-		ingredientService.Add(ingredient.Ingredient{Name: "Rice", Quantity: 500, MeasureType: "mg"})
+		ingredientService.Add(context.Background(), ingredient.Ingredient{Name: "Onion", Quantity: 1, MeasureType: "unit"})
+		ingredientService.Add(context.Background(), ingredient.Ingredient{Name: "Rice", Quantity: 500, MeasureType: "mg"})
 
 		mux := http.NewServeMux()
 		mux.HandleFunc("/recommendation", controller.GetRecommendation)
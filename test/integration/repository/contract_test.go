@@ -0,0 +1,63 @@
+package repository_integration_test
+
+import (
+	"q-q-tem-pra-hoje/internal/repository/postgres"
+	"q-q-tem-pra-hoje/internal/testutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newPostgresBackend(t *testing.T) testutil.Backend {
+	db := testutil.GetDB()
+	cleanUpTable(t, db)
+	t.Cleanup(func() { cleanUpTable(t, db) })
+
+	ism := postgres.NewIngredientStorageManager(db, discardLogger)
+	return testutil.Backend{
+		Ingredients: &ism,
+		Recipes:     postgres.NewRecipeManager(db, discardLogger),
+		Search:      postgres.NewSearchManager(db, discardLogger),
+		Users:       postgres.NewUserManager(db, discardLogger),
+		Sessions:    postgres.NewSessionManager(db, discardLogger),
+		Households:  postgres.NewHouseholdManager(db, discardLogger),
+		APIKeys:     postgres.NewAPIKeyManager(db, discardLogger),
+		Transactor:  postgres.NewTransactor(db, discardLogger),
+		BreakRecipeIngredients: func(t *testing.T) {
+			_, err := db.Exec("ALTER TABLE recipes_ingredients ADD CONSTRAINT break_recipe_ingredients CHECK (false) NOT VALID")
+			require.NoError(t, err)
+			t.Cleanup(func() {
+				_, err := db.Exec("ALTER TABLE recipes_ingredients DROP CONSTRAINT break_recipe_ingredients")
+				require.NoError(t, err)
+			})
+		},
+	}
+}
+
+func TestIngredientStorageManager_Contract(t *testing.T) {
+	testutil.RunIngredientStorageContract(t, newPostgresBackend)
+}
+
+func TestRecipeManager_Contract(t *testing.T) {
+	testutil.RunRecipeContract(t, newPostgresBackend)
+}
+
+func TestTransactor_Contract(t *testing.T) {
+	testutil.RunTransactionContract(t, newPostgresBackend)
+}
+
+func TestUsers_Contract(t *testing.T) {
+	testutil.RunUserContract(t, newPostgresBackend)
+}
+
+func TestHouseholds_Contract(t *testing.T) {
+	testutil.RunHouseholdContract(t, newPostgresBackend)
+}
+
+func TestAPIKeys_Contract(t *testing.T) {
+	testutil.RunAPIKeyContract(t, newPostgresBackend)
+}
+
+func TestBackup_Contract(t *testing.T) {
+	testutil.RunBackupContract(t, newPostgresBackend)
+}
@@ -1,7 +1,9 @@
 package repository_integration_test
 
 import (
+	"context"
 	"github.com/stretchr/testify/assert"
+	"q-q-tem-pra-hoje/internal/domain"
 	"q-q-tem-pra-hoje/internal/domain/ingredient"
 	"q-q-tem-pra-hoje/internal/repository/postgres"
 	ingredientService "q-q-tem-pra-hoje/internal/service/ingredient"
@@ -13,18 +15,18 @@ func TestIngredientService_Add(t *testing.T) {
 	db := testutil.GetDB()
 	t.Cleanup(func() { cleanUpTable(t, db) })
 
-	ingredientManager := postgres.NewIngredientStorageManager(db)
-	service := ingredientService.NewService(&ingredientManager)
+	ingredientManager := postgres.NewIngredientStorageManager(db, discardLogger)
+	service := ingredientService.NewService(&ingredientManager, discardLogger)
 
 	ingredientCreated := ingredient.Ingredient{Name: "Salt", Quantity: 1, MeasureType: "unit"}
 	secondIngredientCreated := ingredient.Ingredient{Name: "Salt", Quantity: 1, MeasureType: "unit"}
 
 	t.Run("it should add ingredients to database", func(t *testing.T) {
 
-		err := service.Add(ingredientCreated)
+		err := service.Add(context.Background(), ingredientCreated)
 		assert.NoError(t, err)
 
-		err = service.Add(secondIngredientCreated)
+		err = service.Add(context.Background(), secondIngredientCreated)
 		assert.NoError(t, err)
 
 		var ingredientFound ingredient.Ingredient
@@ -43,9 +45,9 @@ func TestIngredientService_FindIngredients(t *testing.T) {
 
 	t.Run("should find aggregated ingredients from the database", func(t *testing.T) {
 
-		ingredientManager := postgres.NewIngredientStorageManager(db)
-		ingredientService := ingredientService.NewService(&ingredientManager)
-		ingredientsFound, err := ingredientService.FindIngredients()
+		ingredientManager := postgres.NewIngredientStorageManager(db, discardLogger)
+		ingredientService := ingredientService.NewService(&ingredientManager, discardLogger)
+		ingredientsFound, err := ingredientService.FindIngredients(context.Background())
 
 		expectedIngredients := []ingredient.Ingredient{{Name: "onion", MeasureType: "unit", Quantity: 10}, {Name: "garlic", MeasureType: "unit", Quantity: 10}}
 
@@ -66,13 +68,13 @@ func TestIngredientService_Update(t *testing.T) {
 
 	t.Run("should update an ingredient value", func(t *testing.T) {
 
-		ingredientManager := postgres.NewIngredientStorageManager(db)
-		ingredientService := ingredientService.NewService(&ingredientManager)
+		ingredientManager := postgres.NewIngredientStorageManager(db, discardLogger)
+		ingredientService := ingredientService.NewService(&ingredientManager, discardLogger)
 
 		id := 2
 		updatedIngredient := ingredient.Ingredient{Id: &id, Name: "garlic", Quantity: 1, MeasureType: "unit"}
 
-		err := ingredientService.Update(updatedIngredient)
+		err := ingredientService.Update(context.Background(), updatedIngredient)
 		if err != nil {
 			t.Fatalf("fail to update an ingredient %v", err)
 		}
@@ -98,11 +100,11 @@ func TestIngredientService_Delete(t *testing.T) {
 
 	t.Run("should remove an ingredient", func(t *testing.T) {
 
-		ingredientManager := postgres.NewIngredientStorageManager(db)
-		ingredientService := ingredientService.NewService(&ingredientManager)
+		ingredientManager := postgres.NewIngredientStorageManager(db, discardLogger)
+		ingredientService := ingredientService.NewService(&ingredientManager, discardLogger)
 
 		id := uint(2)
-		err := ingredientService.Delete(id)
+		err := ingredientService.Delete(context.Background(), id)
 		if err != nil {
 			t.Fatalf("fail to delete an ingredient %v", err)
 		}
@@ -116,3 +118,60 @@ func TestIngredientService_Delete(t *testing.T) {
 		}
 	})
 }
+
+func TestIngredientService_ListIngredients(t *testing.T) {
+	db := testutil.GetDB()
+	t.Cleanup(func() { cleanUpTable(t, db) })
+
+	ingredientManager := postgres.NewIngredientStorageManager(db, discardLogger)
+	service := ingredientService.NewService(&ingredientManager, discardLogger)
+	for _, ing := range []ingredient.Ingredient{
+		{Name: "Black pepper", MeasureType: "g", Quantity: 5},
+		{Name: "Salt", MeasureType: "g", Quantity: 30},
+		{Name: "White pepper", MeasureType: "g", Quantity: 2},
+		{Name: "Pink pepper", MeasureType: "g", Quantity: 9},
+	} {
+		if err := service.Add(context.Background(), ing); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("should page through the filtered ingredients in sort order", func(t *testing.T) {
+		opts := domain.ListOptions{Search: "PEPPER", Sort: domain.SortByQuantity, Descending: true, Limit: 2}
+
+		first, err := service.ListIngredients(context.Background(), opts)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Pink pepper", "Black pepper"}, ingredientNames(first.Items))
+		assert.NotNil(t, first.Next)
+
+		opts.After = first.Next
+		second, err := service.ListIngredients(context.Background(), opts)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"White pepper"}, ingredientNames(second.Items))
+		assert.Nil(t, second.Next)
+	})
+
+	t.Run("should sort by name and creation", func(t *testing.T) {
+		byName, err := service.ListIngredients(context.Background(), domain.ListOptions{Sort: domain.SortByName})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Black pepper", "Pink pepper", "Salt", "White pepper"}, ingredientNames(byName.Items))
+
+		byCreated, err := service.ListIngredients(context.Background(), domain.ListOptions{Sort: domain.SortByCreated, Descending: true, Limit: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Pink pepper"}, ingredientNames(byCreated.Items))
+	})
+
+	t.Run("should treat LIKE wildcards in the search literally", func(t *testing.T) {
+		page, err := service.ListIngredients(context.Background(), domain.ListOptions{Search: "%"})
+		assert.NoError(t, err)
+		assert.Empty(t, page.Items)
+	})
+}
+
+func ingredientNames(ingredients []ingredient.Ingredient) []string {
+	names := make([]string, len(ingredients))
+	for i, ing := range ingredients {
+		names[i] = ing.Name
+	}
+	return names
+}
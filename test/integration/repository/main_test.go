@@ -2,6 +2,7 @@ package repository_integration_test
 
 import (
 	"database/sql"
+	"log/slog"
 	"os"
 	"q-q-tem-pra-hoje/internal/domain/ingredient"
 	"q-q-tem-pra-hoje/internal/domain/recipe"
@@ -9,6 +10,8 @@ import (
 	"testing"
 )
 
+var discardLogger = slog.New(slog.DiscardHandler)
+
 func TestMain(m *testing.M) {
 	dsn, teardown := testutil.SetupTestDB()
 
@@ -31,6 +34,14 @@ func cleanUpTable(t *testing.T, db *sql.DB) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	_, err = db.Exec("TRUNCATE TABLE users RESTART IDENTITY CASCADE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec("TRUNCATE TABLE households RESTART IDENTITY CASCADE")
+	if err != nil {
+		t.Fatal(err)
+	}
 }
 
 func createDataset(t *testing.T, db *sql.DB) {
@@ -1,7 +1,9 @@
 package repository_integration_test
 
 import (
+	"context"
 	"github.com/stretchr/testify/assert"
+	"q-q-tem-pra-hoje/internal/domain"
 	"q-q-tem-pra-hoje/internal/domain/ingredient"
 	"q-q-tem-pra-hoje/internal/domain/recipe"
 	"q-q-tem-pra-hoje/internal/repository/postgres"
@@ -14,8 +16,8 @@ func TestRecipeService_AddRecipe(t *testing.T) {
 	db := testutil.GetDB()
 	t.Cleanup(func() { cleanUpTable(t, db) })
 
-	recipeManager := postgres.NewRecipeManager(db)
-	service := recipeService.NewRecipeService(recipeManager)
+	recipeManager := postgres.NewRecipeManager(db, discardLogger)
+	service := recipeService.NewRecipeService(recipeManager, discardLogger)
 
 	t.Run("should add a recipe in database", func(t *testing.T) {
 		ingredients := []ingredient.Ingredient{
@@ -25,7 +27,7 @@ func TestRecipeService_AddRecipe(t *testing.T) {
 		}
 
 		newRecipe := recipe.Recipe{Name: "Rice with Onion and Garlic", Ingredients: ingredients}
-		err := service.AddRecipe(newRecipe)
+		err := service.AddRecipe(context.Background(), newRecipe)
 		if err != nil {
 			t.Errorf("error at addRecipe: %v", err)
 		}
@@ -64,9 +66,10 @@ func TestRecipeService_AddRecipe(t *testing.T) {
 		}
 
 		newRecipe := recipe.Recipe{Name: "Rice with Onion and Garlic", Ingredients: ingredients}
-		err := service.AddRecipe(newRecipe)
+		err := service.AddRecipe(context.Background(), newRecipe)
 		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "failed to insert recipe: pq: duplicate key value violates unique constraint")
+		var conflict *domain.ConflictError
+		assert.ErrorAs(t, err, &conflict)
 	})
 }
 
@@ -76,8 +79,8 @@ func TestRecipeService_GetAllRecipes(t *testing.T) {
 	createDataset(t, db)
 	t.Cleanup(func() { cleanUpTable(t, db) })
 
-	recipeManager := postgres.NewRecipeManager(db)
-	service := recipeService.NewRecipeService(recipeManager)
+	recipeManager := postgres.NewRecipeManager(db, discardLogger)
+	service := recipeService.NewRecipeService(recipeManager, discardLogger)
 
 	expectedRecipes := []recipe.Recipe{
 		{Name: "Rice with Onion and Garlic",
@@ -98,7 +101,7 @@ func TestRecipeService_GetAllRecipes(t *testing.T) {
 	}
 
 	t.Run("should retrieve all recipes with their ingredients", func(t *testing.T) {
-		recipes, err := service.GetAllRecipes()
+		recipes, err := service.GetAllRecipes(context.Background())
 		if err != nil {
 			t.Fatalf("failed to get all recipes: %v", err)
 		}
@@ -121,7 +124,7 @@ func TestRecipeService_GetAllRecipes(t *testing.T) {
 			t.Fatalf("failed to clear recipes: %v", err)
 		}
 
-		recipes, err := service.GetAllRecipes()
+		recipes, err := service.GetAllRecipes(context.Background())
 		if err != nil {
 			t.Fatalf("failed to get all recipes: %v", err)
 		}
@@ -135,17 +138,17 @@ func TestRecipeService_DeleteRecipe(t *testing.T) {
 	createDataset(t, db)
 	t.Cleanup(func() { cleanUpTable(t, db) })
 
-	recipeManager := postgres.NewRecipeManager(db)
-	service := recipeService.NewRecipeService(recipeManager)
+	recipeManager := postgres.NewRecipeManager(db, discardLogger)
+	service := recipeService.NewRecipeService(recipeManager, discardLogger)
 
 	t.Run("should delete a recipe and its ingredients", func(t *testing.T) {
-		recipes, err := service.GetAllRecipes()
+		recipes, err := service.GetAllRecipes(context.Background())
 		if err != nil {
 			t.Fatalf("failed to get all recipes: %v", err)
 		}
 		assert.NotEmpty(t, recipes)
 
-		err = service.DeleteRecipe(uint(1))
+		err = service.DeleteRecipe(context.Background(), uint(1))
 		assert.NoError(t, err)
 
 		var count int
@@ -155,8 +158,74 @@ func TestRecipeService_DeleteRecipe(t *testing.T) {
 	})
 
 	t.Run("should return error when recipe doesn't exist", func(t *testing.T) {
-		err := service.DeleteRecipe(1)
+		err := service.DeleteRecipe(context.Background(), 1)
 		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "recipe not found")
+		var notFound *domain.NotFoundError
+		assert.ErrorAs(t, err, &notFound)
+	})
+}
+
+func TestRecipeService_ListRecipes(t *testing.T) {
+	db := testutil.GetDB()
+	createDataset(t, db)
+	t.Cleanup(func() { cleanUpTable(t, db) })
+
+	recipeManager := postgres.NewRecipeManager(db, discardLogger)
+	service := recipeService.NewRecipeService(recipeManager, discardLogger)
+
+	t.Run("should page through the recipes with their ingredients", func(t *testing.T) {
+		opts := domain.ListOptions{Sort: domain.SortByName, Limit: 1}
+
+		first, err := service.ListRecipes(context.Background(), opts)
+		assert.NoError(t, err)
+		assert.Len(t, first.Items, 1)
+		assert.Equal(t, "Rice with Onion and Garlic", first.Items[0].Name)
+		assert.Len(t, first.Items[0].Ingredients, 3)
+
+		opts.After = first.Next
+		second, err := service.ListRecipes(context.Background(), opts)
+		assert.NoError(t, err)
+		assert.Len(t, second.Items, 1)
+		assert.Equal(t, "Tomato Soup", second.Items[0].Name)
+		assert.Nil(t, second.Next)
+	})
+
+	t.Run("should search recipes by name", func(t *testing.T) {
+		page, err := service.ListRecipes(context.Background(), domain.ListOptions{Search: "soup"})
+		assert.NoError(t, err)
+		assert.Len(t, page.Items, 1)
+		assert.Equal(t, "Tomato Soup", page.Items[0].Name)
+	})
+
+	t.Run("should reject sorting recipes by quantity", func(t *testing.T) {
+		_, err := service.ListRecipes(context.Background(), domain.ListOptions{Sort: domain.SortByQuantity})
+		var validation *domain.ValidationError
+		assert.ErrorAs(t, err, &validation)
+	})
+}
+
+func TestRecipeManager_UpsertRecipe(t *testing.T) {
+	db := testutil.GetDB()
+	t.Cleanup(func() { cleanUpTable(t, db) })
+
+	recipeManager := postgres.NewRecipeManager(db, discardLogger)
+
+	t.Run("it should replace the ingredients of the recipe with the same name", func(t *testing.T) {
+		ctx := context.Background()
+		first := recipe.Recipe{Name: "Tropeiro", Ingredients: []ingredient.Ingredient{{Name: "feijão", MeasureType: "g", Quantity: 500}}, Visibility: recipe.VisibilityPublic}
+		second := recipe.Recipe{Name: "Tropeiro", Ingredients: []ingredient.Ingredient{{Name: "farinha de mandioca", MeasureType: "g", Quantity: 200}}, Visibility: recipe.VisibilityPublic}
+
+		added, err := recipeManager.UpsertRecipe(ctx, first)
+		assert.NoError(t, err)
+		assert.True(t, added)
+		added, err = recipeManager.UpsertRecipe(ctx, second)
+		assert.NoError(t, err)
+		assert.False(t, added)
+
+		recipes, err := recipeManager.GetAllRecipes(ctx)
+		assert.NoError(t, err)
+		if assert.Len(t, recipes, 1) {
+			assert.Equal(t, second.Ingredients, recipes[0].Ingredients)
+		}
 	})
 }
@@ -1,6 +1,7 @@
 package repository_integration_test
 
 import (
+	"context"
 	"github.com/stretchr/testify/assert"
 	"q-q-tem-pra-hoje/internal/domain/ingredient"
 	"q-q-tem-pra-hoje/internal/domain/recipe"
@@ -16,9 +17,9 @@ func TestCreateRecommendations(t *testing.T) {
 	createDataset(t, db)
 	t.Cleanup(func() { cleanUpTable(t, db) })
 
-	recipeManager := postgres.NewRecipeManager(db)
+	recipeManager := postgres.NewRecipeManager(db, discardLogger)
 
-	service := recommendationService.NewRecommendationService(recipeManager)
+	service := recommendationService.NewRecommendationService(recipeManager, discardLogger)
 
 	t.Run("should create recommendations", func(t *testing.T) {
 
@@ -27,7 +28,7 @@ func TestCreateRecommendations(t *testing.T) {
 			{Name: "Rice", MeasureType: "mg", Quantity: 500},
 		}
 
-		recommendations, err := service.GetRecommendations(&availableIngredients)
+		recommendations, err := service.GetRecommendations(context.Background(), &availableIngredients)
 
 		if err != nil {
 			t.Errorf("error creating the recommendations: %v", err)
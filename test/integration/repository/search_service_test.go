@@ -0,0 +1,60 @@
+package repository_integration_test
+
+import (
+	"context"
+	"q-q-tem-pra-hoje/internal/domain/search"
+	"q-q-tem-pra-hoje/internal/repository/postgres"
+	searchService "q-q-tem-pra-hoje/internal/service/search"
+	"q-q-tem-pra-hoje/internal/testutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchService_Search(t *testing.T) {
+	db := testutil.GetDB()
+	t.Cleanup(func() { cleanUpTable(t, db) })
+
+	for _, name := range []string{"Berinjela à parmegiana", "Torta de abóbrinha", "Feijoada"} {
+		if _, err := db.Exec("INSERT INTO recipes (name) VALUES ($1)", name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.Exec("INSERT INTO ingredients_storage (name, measure_type, quantity) VALUES ('Berinjela', 'unit', 2)"); err != nil {
+		t.Fatal(err)
+	}
+
+	service := searchService.NewSearchService(postgres.NewSearchManager(db, discardLogger), discardLogger)
+
+	t.Run("should find recipes and ingredients by name", func(t *testing.T) {
+		results, err := service.Search(context.Background(), "berinjela", 0)
+
+		assert.NoError(t, err)
+		assert.Len(t, results, 2)
+		assert.ElementsMatch(t, []search.Kind{search.KindRecipe, search.KindIngredient}, []search.Kind{results[0].Kind, results[1].Kind})
+	})
+
+	t.Run("should ignore accents", func(t *testing.T) {
+		results, err := service.Search(context.Background(), "abobrinha", 0)
+
+		assert.NoError(t, err)
+		assert.Len(t, results, 1)
+		assert.Equal(t, "Torta de abóbrinha", results[0].Name)
+	})
+
+	t.Run("should tolerate typos", func(t *testing.T) {
+		results, err := service.Search(context.Background(), "feijoda", 0)
+
+		assert.NoError(t, err)
+		assert.Len(t, results, 1)
+		assert.Equal(t, "Feijoada", results[0].Name)
+	})
+
+	t.Run("should rank the closest name first and honour the limit", func(t *testing.T) {
+		results, err := service.Search(context.Background(), "berinjela parmegiana", 1)
+
+		assert.NoError(t, err)
+		assert.Len(t, results, 1)
+		assert.Equal(t, "Berinjela à parmegiana", results[0].Name)
+	})
+}
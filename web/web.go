@@ -0,0 +1,147 @@
+// Package web renders the browser UI, whose templates and assets are
+// embedded into the binary.
+package web
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+//go:embed templates static
+var files embed.FS
+
+// hashLength is how much of an asset's content hash goes in its URL.
+const hashLength = 10
+
+// UI renders the pages and serves the static assets. Every asset is
+// reachable under a fingerprinted URL, such as /static/css/main.1a2b3c4d5e.css,
+// which may be cached forever since a change to the file changes the URL.
+// Pages link to those, so they are the only thing browsers revalidate.
+type UI struct {
+	pages map[string]*template.Template
+	// assets maps the path under /static/, plain or fingerprinted, to the
+	// file served there.
+	assets map[string]asset
+}
+
+type asset struct {
+	name      string
+	content   []byte
+	etag      string
+	immutable bool
+}
+
+type measureType struct {
+	Value string
+	Label string
+}
+
+// measureTypes are offered wherever a quantity is entered.
+var measureTypes = []measureType{{"unit", "Unit"}, {"g", "Gram"}, {"mg", "Milligram"}}
+
+// View is what every page template is rendered with.
+type View struct {
+	Title string
+	// CSRF is the token forms must post back. It is empty when nobody is
+	// logged in, which also hides the navigation.
+	CSRF   string
+	Errors []string
+	Data   any
+}
+
+// New parses the embedded pages. It panics if they are broken, which would
+// be a build defect.
+func New() *UI {
+	ui := &UI{pages: map[string]*template.Template{}, assets: map[string]asset{}}
+	fingerprinted := map[string]string{}
+
+	err := fs.WalkDir(files, "static", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := files.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(content)
+		hash := hex.EncodeToString(sum[:])[:hashLength]
+		plain := strings.TrimPrefix(name, "static/")
+		ext := path.Ext(plain)
+		hashed := strings.TrimSuffix(plain, ext) + "." + hash + ext
+
+		ui.assets[plain] = asset{name: plain, content: content, etag: `"` + hash + `"`}
+		ui.assets[hashed] = asset{name: plain, content: content, etag: `"` + hash + `"`, immutable: true}
+		fingerprinted[plain] = "/static/" + hashed
+		return nil
+	})
+	if err != nil {
+		panic(fmt.Sprintf("web: reading embedded assets: %v", err))
+	}
+
+	layout := template.Must(template.New("layout.html").Funcs(template.FuncMap{
+		"asset": func(name string) (string, error) {
+			url, ok := fingerprinted[name]
+			if !ok {
+				return "", fmt.Errorf("unknown asset %q", name)
+			}
+			return url, nil
+		},
+		"measureTypes": func() []measureType { return measureTypes },
+	}).ParseFS(files, "templates/layout.html"))
+
+	pages, err := fs.Glob(files, "templates/*.html")
+	if err != nil {
+		panic(fmt.Sprintf("web: listing templates: %v", err))
+	}
+	for _, page := range pages {
+		name := strings.TrimSuffix(path.Base(page), ".html")
+		if name == "layout" {
+			continue
+		}
+		ui.pages[name] = template.Must(template.Must(layout.Clone()).ParseFS(files, page))
+	}
+	return ui
+}
+
+// Render writes the named page. Pages carry personal data and CSRF tokens,
+// so they are never cached.
+func (ui *UI) Render(w http.ResponseWriter, status int, page string, view View) error {
+	tmpl, ok := ui.pages[page]
+	if !ok {
+		return fmt.Errorf("unknown page %q", page)
+	}
+	var rendered bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&rendered, "layout", view); err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_, err := rendered.WriteTo(w)
+	return err
+}
+
+// Static serves the assets under /static/.
+func (ui *UI) Static(w http.ResponseWriter, r *http.Request) {
+	a, ok := ui.assets[strings.TrimPrefix(r.URL.Path, "/static/")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if a.immutable {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	w.Header().Set("ETag", a.etag)
+	http.ServeContent(w, r, a.name, time.Time{}, bytes.NewReader(a.content))
+}
@@ -0,0 +1,99 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var stylesheet = regexp.MustCompile(`href="(/static/css/main\.[0-9a-f]{10}\.css)"`)
+
+func serve(handler http.HandlerFunc, target string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	handler(w, req)
+	return w
+}
+
+func render(t *testing.T, ui *UI, page string, view View) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	require.NoError(t, ui.Render(w, http.StatusOK, page, view))
+	return w
+}
+
+func TestUI_Render(t *testing.T) {
+	ui := New()
+
+	t.Run("it should render pages in the layout with fingerprinted assets", func(t *testing.T) {
+		w := render(t, ui, "login", View{Title: "Log in", Data: struct{ Email, Next, CSRF string }{}})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		assert.Contains(t, w.Body.String(), `action="/login"`)
+		assert.Regexp(t, stylesheet, w.Body.String())
+		assert.Regexp(t, `src="/static/js/main\.[0-9a-f]{10}\.js"`, w.Body.String())
+	})
+
+	t.Run("it should only show the navigation to a session", func(t *testing.T) {
+		anonymous := render(t, ui, "error", View{Title: "Not Found"})
+		loggedIn := render(t, ui, "error", View{Title: "Not Found", CSRF: "token"})
+
+		assert.NotContains(t, anonymous.Body.String(), `action="/logout"`)
+		assert.Contains(t, loggedIn.Body.String(), `action="/logout"`)
+		assert.Contains(t, loggedIn.Body.String(), `value="token"`)
+	})
+
+	t.Run("it should escape what it renders", func(t *testing.T) {
+		w := render(t, ui, "error", View{Title: "Bad Request", Errors: []string{"<script>"}})
+
+		assert.NotContains(t, w.Body.String(), "<script>")
+		assert.Contains(t, w.Body.String(), "&lt;script&gt;")
+	})
+
+	t.Run("it should refuse unknown pages", func(t *testing.T) {
+		assert.Error(t, ui.Render(httptest.NewRecorder(), http.StatusOK, "missing", View{}))
+	})
+}
+
+func TestUI_Static(t *testing.T) {
+	ui := New()
+	page := render(t, ui, "error", View{Title: "Not Found"})
+	match := stylesheet.FindStringSubmatch(page.Body.String())
+	require.Len(t, match, 2)
+	fingerprinted := match[1]
+
+	t.Run("it should let fingerprinted assets be cached forever", func(t *testing.T) {
+		w := serve(ui.Static, fingerprinted)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/css; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "public, max-age=31536000, immutable", w.Header().Get("Cache-Control"))
+		assert.NotEmpty(t, w.Body.String())
+	})
+
+	t.Run("it should make plain asset paths revalidate", func(t *testing.T) {
+		w := serve(ui.Static, "/static/css/main.css")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+
+		revalidated := serve(ui.Static, "/static/css/main.css", "If-None-Match", w.Header().Get("ETag"))
+		assert.Equal(t, http.StatusNotModified, revalidated.Code)
+	})
+
+	for _, target := range []string{"/static/css/missing.css", "/static/css/main.0000000000.css", "/static/../templates/layout.html"} {
+		t.Run("it should not serve "+target, func(t *testing.T) {
+			w := serve(ui.Static, target)
+
+			assert.Equal(t, http.StatusNotFound, w.Code)
+		})
+	}
+}